@@ -0,0 +1,135 @@
+package admin
+
+import (
+	"errors"
+	"go-snob/internal/conventions"
+	"go-snob/internal/feedback"
+	"go-snob/pkg/http/pipeline"
+	"net/http"
+	"strconv"
+
+	"go.uber.org/zap"
+)
+
+// Server admin API, every handler requires the admin token
+type Server struct {
+	logger      *zap.Logger
+	token       string
+	feedback    *feedback.Store
+	conventions *conventions.Store
+}
+
+func NewServer(logger *zap.Logger, token string) *Server {
+	return &Server{logger: logger, token: token}
+}
+
+func (s *Server) WithFeedback(store *feedback.Store) *Server {
+	s.feedback = store
+	return s
+}
+
+func (s *Server) WithConventions(store *conventions.Store) *Server {
+	s.conventions = store
+	return s
+}
+
+// FeedbackStats precision of review comments by category, model, config version and repository
+func (s *Server) FeedbackStats() http.Handler {
+	return s.newPipeline(
+		pipeline.AllowedMethods(http.MethodGet),
+		pipeline.BearerToken(s.token),
+		pipeline.Out(func(_ *pipeline.Ctx) (feedback.Stats, error) {
+			return s.feedback.Stats(), nil
+		}),
+		pipeline.In(pipeline.EncodeJSON[feedback.Stats]()),
+	)
+}
+
+// ListConventions rules of ?repo=owner/name, all rules without it
+func (s *Server) ListConventions() http.Handler {
+	return s.newPipeline(
+		pipeline.BearerToken(s.token),
+		pipeline.Out(func(ctx *pipeline.Ctx) ([]conventions.Rule, error) {
+			return s.conventions.List(ctx.Request.URL.Query().Get("repo")), nil
+		}),
+		pipeline.In(pipeline.EncodeJSON[[]conventions.Rule]()),
+	)
+}
+
+func (s *Server) AddConvention() http.Handler {
+	return s.newPipeline(
+		pipeline.BearerToken(s.token),
+		pipeline.AllowedContentType("application/json"),
+		pipeline.Out(pipeline.DecodeJSON[conventions.Rule]()),
+		pipeline.InOut(func(ctx *pipeline.Ctx, r conventions.Rule) (conventions.Rule, error) {
+			r.Source = conventions.SourceAdmin
+			rule, err := s.conventions.Add(r)
+			if err != nil {
+				return conventions.Rule{}, ruleError(err)
+			}
+			return rule, nil
+		}),
+		pipeline.In(pipeline.EncodeJSON[conventions.Rule]()),
+	)
+}
+
+// UpdateConvention replaces text and scope of /{id}
+func (s *Server) UpdateConvention() http.Handler {
+	return s.newPipeline(
+		pipeline.BearerToken(s.token),
+		pipeline.AllowedContentType("application/json"),
+		pipeline.Out(pipeline.DecodeJSON[conventions.Rule]()),
+		pipeline.InOut(func(ctx *pipeline.Ctx, r conventions.Rule) (conventions.Rule, error) {
+			id, err := ruleID(ctx)
+			if err != nil {
+				return conventions.Rule{}, err
+			}
+			r.ID = id
+			rule, err := s.conventions.Update(r)
+			if err != nil {
+				return conventions.Rule{}, ruleError(err)
+			}
+			return rule, nil
+		}),
+		pipeline.In(pipeline.EncodeJSON[conventions.Rule]()),
+	)
+}
+
+func (s *Server) DeleteConvention() http.Handler {
+	return s.newPipeline(
+		pipeline.BearerToken(s.token),
+		func(ctx *pipeline.Ctx, next pipeline.NextFunc) {
+			id, err := ruleID(ctx)
+			if err != nil {
+				ctx.Fail(err)
+				return
+			}
+			if err := s.conventions.Delete(id); err != nil {
+				ctx.Fail(ruleError(err))
+				return
+			}
+			ctx.Writer.WriteHeader(http.StatusNoContent)
+			next()
+		},
+	)
+}
+
+func ruleID(ctx *pipeline.Ctx) (int64, error) {
+	id, err := strconv.ParseInt(ctx.Request.PathValue("id"), 10, 64)
+	if err != nil {
+		return 0, pipeline.NewError(http.StatusBadRequest, "bad rule id", err)
+	}
+	return id, nil
+}
+
+// ruleError store errors are about the rule sent, they're shown as is
+func ruleError(err error) error {
+	if errors.Is(err, conventions.ErrNotFound) {
+		return pipeline.NewError(http.StatusNotFound, err.Error(), err)
+	}
+	return pipeline.NewError(http.StatusBadRequest, err.Error(), err)
+}
+
+func (s *Server) newPipeline(mw ...pipeline.MiddlewareFunc) *pipeline.Pipeline {
+	return pipeline.NewPipeline(s.logger, mw...)
+}
@@ -2,20 +2,42 @@ package webhook
 
 import (
 	"go-snob/pkg/giteawebhook"
+	"go-snob/pkg/githubwebhook"
+	"go-snob/pkg/gitlabwebhook"
 	"net/http"
 
 	"go.uber.org/zap"
 )
 
 type Server struct {
-	logger  *zap.Logger
-	webhook *giteawebhook.Webhook
+	logger        *zap.Logger
+	webhook       *giteawebhook.Webhook
+	githubWebhook *githubwebhook.Webhook
+	gitlabWebhook *gitlabwebhook.Webhook
 }
 
 func NewServer(webhook *giteawebhook.Webhook) *Server {
 	return &Server{webhook: webhook}
 }
 
+func (s *Server) WithGitHub(webhook *githubwebhook.Webhook) *Server {
+	s.githubWebhook = webhook
+	return s
+}
+
+func (s *Server) WithGitLab(webhook *gitlabwebhook.Webhook) *Server {
+	s.gitlabWebhook = webhook
+	return s
+}
+
 func (s *Server) GiteaWebhook() http.Handler {
 	return s.webhook.Handler()
 }
+
+func (s *Server) GitHubWebhook() http.Handler {
+	return s.githubWebhook.Handler()
+}
+
+func (s *Server) GitLabWebhook() http.Handler {
+	return s.gitlabWebhook.Handler()
+}
@@ -1,6 +1,11 @@
 package main
 
-import "go.uber.org/zap/zapcore"
+import (
+	"errors"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
 
 type Config struct {
 	PathToYAMLCfg string        `long:"path-to-yaml" description:"Path to YAML cfg" env:"PATH_TO_YAML_CFG" required:"true"`
@@ -8,15 +13,47 @@ type Config struct {
 
 	HTTPListenAddr string `long:"http-listen-addr" description:"Listening host:port for public http-server" env:"HTTP_LISTEN_ADDR" required:"true"`
 
-	// SNOBUserGiteaToken SNOB user gitea. Used for calling api by himself
+	// SNOBUserGiteaToken SNOB user gitea. Used for calling api by himself when no gitea instances are
+	// configured in YAML or an instance has no auth of its own
 	SNOBUserGiteaToken string `long:"snob-user-gitea-token" description:"User SNOB Gitea Token" env:"SNOB_USER_GITEA_TOKEN" required:"true"`
 
 	// WebhookGiteaSecret webhook secret for HMAC signature
 	WebhookGiteaSecret string `long:"webhook-gitea-secret" description:"Webhook Gitea Secret" env:"WEBHOOK_GITEA_SECRET" required:"true"`
 	// CloudRuFoundationalModelsKey key to foundational models service provided by Cloud ru
 	CloudRuFoundationalModelsKey string `long:"cloud-ru-foundational-models-key" description:"CloudRuFoundationalModelsKey" env:"CLOUD_RU_FOUNDATIONAL_MODELS_KEY" required:"true"`
+
+	// SNOBUserGitHubToken SNOB user GitHub token. GitHub support is disabled if empty
+	SNOBUserGitHubToken string `long:"snob-user-github-token" description:"User SNOB GitHub Token" env:"SNOB_USER_GITHUB_TOKEN"`
+	// GitHubAPIURL GitHub REST API base url, differs for GitHub Enterprise Server
+	GitHubAPIURL string `long:"github-api-url" description:"GitHub REST API base URL" env:"GITHUB_API_URL" default:"https://api.github.com"`
+	// WebhookGitHubSecret webhook secret for X-Hub-Signature-256, required with SNOBUserGitHubToken
+	WebhookGitHubSecret string `long:"webhook-github-secret" description:"Webhook GitHub Secret" env:"WEBHOOK_GITHUB_SECRET"`
+
+	// SNOBUserGitLabToken SNOB user GitLab token. GitLab support is disabled if empty
+	SNOBUserGitLabToken string `long:"snob-user-gitlab-token" description:"User SNOB GitLab Token" env:"SNOB_USER_GITLAB_TOKEN"`
+	// GitLabAPIURL GitLab REST API base url
+	GitLabAPIURL string `long:"gitlab-api-url" description:"GitLab REST API base URL" env:"GITLAB_API_URL" default:"https://gitlab.com/api/v4"`
+	// WebhookGitLabSecret webhook secret token compared with X-Gitlab-Token, required with SNOBUserGitLabToken
+	WebhookGitLabSecret string `long:"webhook-gitlab-secret" description:"Webhook GitLab Secret" env:"WEBHOOK_GITLAB_SECRET"`
+
+	// AdminToken bearer token of the admin API. The admin API is disabled if empty
+	AdminToken string `long:"admin-token" description:"Admin API bearer token" env:"ADMIN_TOKEN"`
+	// FeedbackStore JSON file reactions on review comments are kept in. Feedback collection is disabled if empty
+	FeedbackStore    string        `long:"feedback-store" description:"Path to the feedback store file" env:"FEEDBACK_STORE"`
+	FeedbackInterval time.Duration `long:"feedback-interval" description:"Feedback collection interval" env:"FEEDBACK_INTERVAL" default:"1h"`
+	// ConventionsStore JSON file per repository rules are kept in. Conventions are disabled if empty
+	ConventionsStore string `long:"conventions-store" description:"Path to the conventions store file" env:"CONVENTIONS_STORE"`
 }
 
-type YAMLConfig struct {
-	SystemPrompt string `yaml:"system_prompt"`
+// validate flags go-flags can't express, secrets of webhooks enabled by a token are required like the
+// Gitea one, an unsigned webhook would let anyone start paid reviews
+func (c Config) validate() error {
+	var errs []error
+	if c.SNOBUserGitHubToken != "" && c.WebhookGitHubSecret == "" {
+		errs = append(errs, errors.New("--webhook-github-secret is required with --snob-user-github-token"))
+	}
+	if c.SNOBUserGitLabToken != "" && c.WebhookGitLabSecret == "" {
+		errs = append(errs, errors.New("--webhook-gitlab-secret is required with --snob-user-gitlab-token"))
+	}
+	return errors.Join(errs...)
 }
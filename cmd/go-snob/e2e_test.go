@@ -0,0 +1,257 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"go-snob/internal/actor/ai"
+	"go-snob/internal/config"
+	"go-snob/internal/testkit"
+	"go-snob/pkg/giteawebhook"
+	"go-snob/pkg/hotreload"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	e2eLogin  = "go-snob"
+	e2eSecret = "webhook-secret"
+	e2eOwner  = "acme"
+	e2eRepo   = "shop"
+	e2eIndex  = 7
+	e2eSHA    = "3f2c9a1b"
+	e2eDiff   = `diff --git a/cart.go b/cart.go
+--- a/cart.go
++++ b/cart.go
+@@ -1,5 +1,8 @@
+ package shop
+
+ func Total(items map[string]int) int {
+-	return 0
++	var prices map[string]int
++	prices["total"] = 0
++	total := 0
++	return total
+ }
+`
+)
+
+// e2e a running app wired to a fake Gitea and a fake LLM
+type e2e struct {
+	gitea         *testkit.Gitea
+	llm           *testkit.LLM
+	webhookURL    string
+	configVersion string
+}
+
+func startE2E(t *testing.T, extraYAML string) *e2e {
+	t.Helper()
+	env := &e2e{
+		gitea: testkit.NewGitea(t, e2eLogin),
+		llm:   testkit.NewLLM(t),
+	}
+	env.gitea.SetDiff(e2eOwner, e2eRepo, e2eIndex, e2eDiff)
+
+	// extraYAML follows the ai section, indented keys extend it
+	yaml := fmt.Sprintf(`system_prompt: |
+  Review the pull request.
+gitea:
+  - name: local
+    base_url: %s
+ai:
+  url: %s
+%s`, env.gitea.URL(), env.llm.URL(), extraYAML)
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(yaml), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	store, err := hotreload.NewStore(path, config.Parse, zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	env.configVersion = store.Current().Version
+
+	addr := freeAddr(t)
+	cfg := Config{
+		PathToYAMLCfg:                path,
+		HTTPListenAddr:               addr,
+		SNOBUserGiteaToken:           "gitea-token",
+		WebhookGiteaSecret:           e2eSecret,
+		CloudRuFoundationalModelsKey: "llm-key",
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	a, err := newApp(ctx, cfg, zap.NewNop())
+	if err != nil {
+		cancel()
+		t.Fatal(err)
+	}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_ = a.Run(ctx)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+
+	testkit.Eventually(t, 5*time.Second, func() bool {
+		resp, err := http.Get("http://" + addr + "/ping")
+		if err != nil {
+			return false
+		}
+		_ = resp.Body.Close()
+		return resp.StatusCode == http.StatusOK
+	})
+	env.webhookURL = "http://" + addr + "/webhook"
+	return env
+}
+
+func (env *e2e) requestReview(t *testing.T, secret string) int {
+	t.Helper()
+	return testkit.SendWebhook(t, env.webhookURL, secret, "pull_request", giteawebhook.PullRequestPayload{
+		Action: giteawebhook.ActionReviewRequest,
+		Number: e2eIndex,
+		PullRequest: giteawebhook.PullRequest{
+			Number:  e2eIndex,
+			Title:   "Compute cart total",
+			User:    giteawebhook.User{Login: "alice"},
+			HTMLURL: env.gitea.HTMLURL(e2eOwner, e2eRepo) + fmt.Sprintf("/pulls/%d", e2eIndex),
+			Head:    giteawebhook.Branch{Ref: "feature", SHA: e2eSHA},
+			Base:    giteawebhook.Branch{Ref: "main", SHA: "0a1b2c3d"},
+		},
+		RequestedReviewer: &giteawebhook.User{Login: e2eLogin},
+		Repository: giteawebhook.Repository{
+			Name:    e2eRepo,
+			Owner:   giteawebhook.User{Login: e2eOwner},
+			HTMLURL: env.gitea.HTMLURL(e2eOwner, e2eRepo),
+		},
+	})
+}
+
+func (env *e2e) waitReview(t *testing.T) testkit.Review {
+	t.Helper()
+	testkit.Eventually(t, 10*time.Second, func() bool { return len(env.gitea.Reviews()) > 0 })
+	reviews := env.gitea.Reviews()
+	if len(reviews) != 1 {
+		t.Fatalf("got %d reviews, want 1", len(reviews))
+	}
+	return reviews[0]
+}
+
+func (env *e2e) footer() string {
+	return fmt.Sprintf("\n\n---\n<sub>go-snob %s · config %s · model qwen3-coder</sub>", Version, env.configVersion)
+}
+
+var nilMapReview = ai.AIReviewResult{
+	Verdict: "REQUEST_CHANGES",
+	Summary: "Writing to a nil map panics.",
+	Comments: []ai.Comment{
+		{File: "cart.go", NewPosition: 5, Message: "prices is nil, the assignment panics", Category: "bug", Severity: "high"},
+		{File: "cart.go", NewPosition: 6, Message: "total could be returned directly", Category: "style", Severity: "low"},
+	},
+}
+
+func TestE2EReviewPosted(t *testing.T) {
+	env := startE2E(t, "")
+	env.llm.ReplyJSON(t, testkit.FormatReview, nilMapReview)
+
+	if code := env.requestReview(t, e2eSecret); code != http.StatusOK {
+		t.Fatalf("webhook status %d", code)
+	}
+
+	want := testkit.Review{
+		Owner:    e2eOwner,
+		Repo:     e2eRepo,
+		Index:    e2eIndex,
+		Body:     "Writing to a nil map panics." + env.footer(),
+		Event:    "REQUEST_CHANGES",
+		CommitID: e2eSHA,
+		Comments: []testkit.ReviewComment{
+			{Path: "cart.go", NewPosition: 5, Body: "prices is nil, the assignment panics"},
+			{Path: "cart.go", NewPosition: 6, Body: "total could be returned directly"},
+		},
+	}
+	got := env.waitReview(t)
+	got.ID = 0
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("posted review\n got %+v\nwant %+v", got, want)
+	}
+	if n := len(env.llm.Requests()); n != 1 {
+		t.Errorf("llm got %d requests, want 1", n)
+	}
+}
+
+func TestE2EStreamedReviewPosted(t *testing.T) {
+	env := startE2E(t, "  stream: true\n")
+	env.llm.ReplyJSON(t, testkit.FormatReview, nilMapReview)
+
+	if code := env.requestReview(t, e2eSecret); code != http.StatusOK {
+		t.Fatalf("webhook status %d", code)
+	}
+
+	got := env.waitReview(t)
+	if got.Body != "Writing to a nil map panics."+env.footer() || len(got.Comments) != 2 {
+		t.Errorf("posted review %+v", got)
+	}
+	if reqs := env.llm.Requests(); len(reqs) != 1 || !reqs[0].Stream {
+		t.Errorf("llm requests %+v, want one streamed", reqs)
+	}
+}
+
+func TestE2EVerificationDropsRejectedComment(t *testing.T) {
+	env := startE2E(t, "verification:\n  enabled: true\n")
+	env.llm.ReplyJSON(t, testkit.FormatReview, nilMapReview)
+	env.llm.ReplyJSON(t, testkit.FormatVerification, ai.AIVerificationResult{Verdicts: []ai.CommentVerdict{
+		{ID: 0, Valid: true, Confidence: .95, Reason: "nil map write"},
+		{ID: 1, Valid: false, Confidence: .9, Reason: "style nit"},
+	}})
+
+	if code := env.requestReview(t, e2eSecret); code != http.StatusOK {
+		t.Fatalf("webhook status %d", code)
+	}
+
+	got := env.waitReview(t)
+	want := []testkit.ReviewComment{{Path: "cart.go", NewPosition: 5, Body: "prices is nil, the assignment panics"}}
+	if !reflect.DeepEqual(got.Comments, want) {
+		t.Errorf("posted comments\n got %+v\nwant %+v", got.Comments, want)
+	}
+	if got.Event != "REQUEST_CHANGES" {
+		t.Errorf("event %q, want REQUEST_CHANGES", got.Event)
+	}
+}
+
+func TestE2EBadSignatureRejected(t *testing.T) {
+	env := startE2E(t, "")
+	env.llm.ReplyJSON(t, testkit.FormatReview, nilMapReview)
+
+	if code := env.requestReview(t, "wrong-secret"); code != http.StatusUnauthorized {
+		t.Fatalf("webhook status %d, want %d", code, http.StatusUnauthorized)
+	}
+	// a delivery that got through would be reviewed well within this
+	time.Sleep(200 * time.Millisecond)
+	if n := len(env.llm.Requests()); n != 0 {
+		t.Errorf("llm got %d requests, want 0", n)
+	}
+	if n := len(env.gitea.Reviews()); n != 0 {
+		t.Errorf("got %d reviews, want 0", n)
+	}
+}
+
+func freeAddr(t *testing.T) string {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := l.Addr().String()
+	_ = l.Close()
+	return addr
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"go-snob/internal"
+	"go-snob/internal/actor/ai"
+	"go-snob/internal/config"
+	"go-snob/internal/eval"
+	"go-snob/pkg/hotreload"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/jessevdk/go-flags"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"resty.dev/v3"
+)
+
+// EvalConfig flags of the eval subcommand
+type EvalConfig struct {
+	Corpus        string `long:"corpus" description:"Directory with eval cases" required:"true"`
+	PathToYAMLCfg string `long:"path-to-yaml" description:"Path to YAML cfg to evaluate" required:"true"`
+	// CompareYAMLCfg candidate config compared against the one of --path-to-yaml
+	CompareYAMLCfg string `long:"compare-yaml" description:"Path to YAML cfg to compare with"`
+
+	// Live sends requests to the AI endpoints of the config instead of the mock LLM
+	Live                         bool   `long:"live" description:"Use the configured AI endpoint instead of the mock LLM"`
+	CloudRuFoundationalModelsKey string `long:"cloud-ru-foundational-models-key" description:"CloudRuFoundationalModelsKey, required with --live" env:"CLOUD_RU_FOUNDATIONAL_MODELS_KEY"`
+
+	JSON         string        `long:"json" description:"Write reports as JSON to the file"`
+	MinRecall    float64       `long:"min-recall" description:"Fail if recall is lower"`
+	MinPrecision float64       `long:"min-precision" description:"Fail if precision is lower"`
+	LogLevel     zapcore.Level `long:"log-level" description:"Log level: -1 debug, 0 info, 1 warn, 2 error" default:"1"`
+}
+
+// runEval runs the corpus through the review pipeline with one or two configs and prints the scores.
+// It returns the exit code
+func runEval(args []string) int {
+	var cfg EvalConfig
+	parser := flags.NewParser(&cfg, flags.Default)
+	parser.Name = "go-snob eval"
+	if _, err := parser.ParseArgs(args); err != nil {
+		return 2
+	}
+	logger := newLogger(cfg.LogLevel)
+	if cfg.Live && cfg.CloudRuFoundationalModelsKey == "" {
+		logger.Error("--cloud-ru-foundational-models-key is required with --live")
+		return 2
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+
+	cases, err := eval.LoadCorpus(cfg.Corpus)
+	if err != nil {
+		logger.Error("failed to load corpus", zap.Error(err))
+		return 1
+	}
+
+	var mock *eval.MockLLM
+	if !cfg.Live {
+		mock, err = eval.NewMockLLM()
+		if err != nil {
+			logger.Error("failed to start mock llm", zap.Error(err))
+			return 1
+		}
+		defer mock.Close()
+	}
+
+	paths := []string{cfg.PathToYAMLCfg}
+	if cfg.CompareYAMLCfg != "" {
+		paths = append(paths, cfg.CompareYAMLCfg)
+	}
+	reports := make([]eval.Report, 0, len(paths))
+	for _, p := range paths {
+		r, err := evalConfig(ctx, logger, p, cfg.CloudRuFoundationalModelsKey, cases, mock)
+		if err != nil {
+			logger.Error("failed to evaluate config", zap.String("path", p), zap.Error(err))
+			return 1
+		}
+		reports = append(reports, r)
+		if err := eval.WriteReport(os.Stdout, r); err != nil {
+			logger.Error("failed to write report", zap.Error(err))
+			return 1
+		}
+	}
+	if len(reports) == 2 {
+		if err := eval.WriteComparison(os.Stdout, reports[0], reports[1]); err != nil {
+			logger.Error("failed to write comparison", zap.Error(err))
+			return 1
+		}
+	}
+
+	if cfg.JSON != "" {
+		b, err := json.MarshalIndent(reports, "", "  ")
+		if err != nil {
+			logger.Error("failed to marshal reports", zap.Error(err))
+			return 1
+		}
+		if err := os.WriteFile(cfg.JSON, b, 0o644); err != nil {
+			logger.Error("failed to write reports", zap.Error(err))
+			return 1
+		}
+	}
+
+	// thresholds apply to the candidate when comparing
+	score := reports[len(reports)-1].Score
+	if score.Recall < cfg.MinRecall || score.Precision < cfg.MinPrecision {
+		fmt.Fprintf(os.Stderr, "recall %.2f, precision %.2f is below --min-recall %.2f, --min-precision %.2f\n",
+			score.Recall, score.Precision, cfg.MinRecall, cfg.MinPrecision)
+		return 1
+	}
+	return 0
+}
+
+func evalConfig(
+	ctx context.Context,
+	logger *zap.Logger,
+	path string,
+	token string,
+	cases []eval.Case,
+	mock *eval.MockLLM,
+) (eval.Report, error) {
+	store, err := hotreload.NewStore(path, config.Parse, logger)
+	if err != nil {
+		return eval.Report{}, fmt.Errorf("load config: %w", err)
+	}
+	snapshot := store.Current()
+	yamlCfg := snapshot.Value
+
+	aiCfg := yamlCfg.AI
+	if mock != nil {
+		// every model of the chain is answered by the mock, which does not stream
+		aiCfg.URL = mock.URL()
+		aiCfg.Stream = false
+		aiCfg.Models = append([]config.AIModelConfig(nil), aiCfg.Models...)
+		for i := range aiCfg.Models {
+			aiCfg.Models[i].URL = ""
+		}
+	}
+	rc, err := aiCfg.HTTP.Apply(resty.New())
+	if err != nil {
+		return eval.Report{}, fmt.Errorf("init ai http client: %w", err)
+	}
+	orch := internal.NewOrchestrator(newAIClient(rc, aiCfg, token, logger), nil, nil, logger).WithVersion(Version)
+
+	r := eval.Run(ctx, cases, func(ctx context.Context, c eval.Case) (ai.AIReviewResult, error) {
+		return orch.ReviewDiff(ctx, c.Repo, c.Title, c.Description, c.Diff, yamlCfg)
+	}, mock)
+	r.Config = path
+	r.ConfigVersion = snapshot.Version
+	return r, nil
+}
@@ -3,19 +3,32 @@ package main
 import (
 	"context"
 	"fmt"
+	"go-snob/cmd/go-snob/api/http/admin"
 	apihttpwebhook "go-snob/cmd/go-snob/api/http/webhook"
 	"go-snob/internal"
 	"go-snob/internal/actor/ai"
+	"go-snob/internal/actor/vcs"
 	"go-snob/internal/actor/vcs/gitea"
+	"go-snob/internal/actor/vcs/github"
+	"go-snob/internal/actor/vcs/gitlab"
+	"go-snob/internal/capture"
+	"go-snob/internal/config"
+	"go-snob/internal/conventions"
+	"go-snob/internal/feedback"
 	"go-snob/pkg/app"
 	"go-snob/pkg/giteawebhook"
+	"go-snob/pkg/githubwebhook"
+	"go-snob/pkg/gitlabwebhook"
+	"go-snob/pkg/hotreload"
 	"go-snob/pkg/http"
 	"go-snob/pkg/recoverer"
 	"go-snob/pkg/restyprometheus"
 	"log"
+	nethttp "net/http"
 	"os"
 	"os/signal"
 	"path/filepath"
+	"reflect"
 	"strings"
 	"syscall"
 	"time"
@@ -23,7 +36,6 @@ import (
 	"github.com/jessevdk/go-flags"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
-	"gopkg.in/yaml.v3"
 	"resty.dev/v3"
 )
 
@@ -34,43 +46,177 @@ const (
 )
 
 func main() {
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "eval":
+			os.Exit(runEval(os.Args[2:]))
+		case "replay":
+			os.Exit(runReplay(os.Args[2:]))
+		}
+	}
+
 	cfg := newCfg()
-	yamlCfg := newYamlCfg(cfg.PathToYAMLCfg)
 	logger := newLogger(cfg.LogLevel)
 	recoverer.SetLogger(logger)
 
 	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM, syscall.SIGQUIT)
 	defer cancel()
 
-	giteaClient := gitea.NewClient(
-		restyprometheus.NewClient(resty.New(), "go-snob", "gitea_client"),
-		"http://localhost:3000/api/v1",
-		cfg.SNOBUserGiteaToken,
-	)
-	aiClient := ai.NewClient(
-		restyprometheus.NewClient(resty.New(), "go-snob", "ai_client"),
-		logger, "https://foundation-models.api.cloud.ru/v1/chat/completions",
-		cfg.CloudRuFoundationalModelsKey,
-		yamlCfg.SystemPrompt,
-	)
-	orch := internal.NewOrchestrator(aiClient, giteaClient, logger)
-
-	webhook := giteawebhook.NewWebhook(orch.Handler, logger).WithDebug().WithSecret(cfg.WebhookGiteaSecret)
-
-	server := apihttpwebhook.NewServer(webhook)
+	a, err := newApp(ctx, cfg, logger)
+	if err != nil {
+		logger.Fatal("failed to init app", zap.Error(err))
+	}
 
 	logger.Info("starting app init..")
-	err := app.NewApp(logger).WithModules(
-		http.NewServer(logger, cfg.HTTPListenAddr).
-			WithPingHandler().
-			WithHandler("/webhook", server.GiteaWebhook()),
-		webhook,
-	).Run(ctx)
+	err = a.Run(ctx)
 	if err != nil {
 		logger.Fatal("app run failed", zap.Error(err))
 	}
 }
 
+// newApp wires the service modules, ctx bounds the bot identity lookups done on start
+func newApp(ctx context.Context, cfg Config, logger *zap.Logger) (*app.App, error) {
+	if err := cfg.validate(); err != nil {
+		return nil, fmt.Errorf("validate flags: %w", err)
+	}
+	cfgStore, err := newConfigStore(cfg.PathToYAMLCfg, logger)
+	if err != nil {
+		return nil, err
+	}
+	yamlCfg := cfgStore.Current().Value
+
+	clients, err := newGiteaClients(yamlCfg.Gitea, cfg.SNOBUserGiteaToken)
+	if err != nil {
+		return nil, fmt.Errorf("init gitea clients: %w", err)
+	}
+
+	aiRestyClient, err := yamlCfg.AI.HTTP.Apply(restyprometheus.NewClient(resty.New(), "go-snob", "ai_client"))
+	if err != nil {
+		return nil, fmt.Errorf("init ai http client: %w", err)
+	}
+	aiClient := newAIClient(aiRestyClient, yamlCfg.AI, cfg.CloudRuFoundationalModelsKey, logger)
+	orch := internal.NewOrchestrator(aiClient, clients, cfgStore, logger).
+		WithVersion(Version).
+		WithSecrets(
+			cfg.SNOBUserGiteaToken, cfg.WebhookGiteaSecret, cfg.CloudRuFoundationalModelsKey,
+			cfg.SNOBUserGitHubToken, cfg.WebhookGitHubSecret,
+			cfg.SNOBUserGitLabToken, cfg.WebhookGitLabSecret,
+			cfg.AdminToken,
+		)
+
+	var feedbackStore *feedback.Store
+	if cfg.FeedbackStore != "" {
+		feedbackStore, err = feedback.NewStore(cfg.FeedbackStore)
+		if err != nil {
+			return nil, fmt.Errorf("open feedback store: %w", err)
+		}
+		orch.WithFeedback(feedbackStore)
+	}
+	var conventionsStore *conventions.Store
+	if cfg.ConventionsStore != "" {
+		conventionsStore, err = conventions.NewStore(cfg.ConventionsStore)
+		if err != nil {
+			return nil, fmt.Errorf("open conventions store: %w", err)
+		}
+		orch.WithConventions(conventionsStore)
+	}
+
+	webhook := giteawebhook.NewWebhook(
+		func(ctx context.Context, d giteawebhook.Delivery) {
+			ctx = capture.WithWebhook(ctx, d.Body)
+			if e, ok := gitea.NewEvent(d); ok {
+				orch.Handler(ctx, e)
+				return
+			}
+			if e, ok := gitea.NewPushEvent(d); ok {
+				orch.PushHandler(ctx, e)
+				return
+			}
+			if e, ok := gitea.NewCommentEvent(d); ok {
+				orch.CommentHandler(ctx, e)
+			}
+		},
+		logger,
+	).WithSecret(cfg.WebhookGiteaSecret)
+
+	server := apihttpwebhook.NewServer(webhook)
+	httpServer := http.NewServer(logger, cfg.HTTPListenAddr).
+		WithPingHandler().
+		WithMetricsHandler().
+		WithHandler("/webhook", server.GiteaWebhook())
+	modules := []app.Module{httpServer, webhook, cfgStore}
+
+	if feedbackStore != nil {
+		modules = append(modules, internal.NewFeedbackLoop(orch, cfg.FeedbackInterval))
+	}
+	if cfg.AdminToken != "" {
+		adminServer := admin.NewServer(logger, cfg.AdminToken)
+		if feedbackStore != nil {
+			adminServer.WithFeedback(feedbackStore)
+			httpServer.WithHandler("/admin/feedback/stats", adminServer.FeedbackStats())
+		}
+		if conventionsStore != nil {
+			adminServer.WithConventions(conventionsStore)
+			httpServer.WithHandlers(map[string]nethttp.Handler{
+				"GET /admin/conventions":         adminServer.ListConventions(),
+				"POST /admin/conventions":        adminServer.AddConvention(),
+				"PUT /admin/conventions/{id}":    adminServer.UpdateConvention(),
+				"DELETE /admin/conventions/{id}": adminServer.DeleteConvention(),
+			})
+		}
+	}
+
+	if cfg.SNOBUserGitHubToken != "" {
+		clients.Register(vcs.ForgeGitHub, "", github.NewClient(
+			restyprometheus.NewClient(resty.New(), "go-snob", "github_client"),
+			cfg.GitHubAPIURL,
+			cfg.SNOBUserGitHubToken,
+		))
+
+		githubWebhook := githubwebhook.NewWebhook(
+			func(ctx context.Context, d githubwebhook.Delivery) {
+				ctx = capture.WithWebhook(ctx, d.Body)
+				if e, ok := github.NewPushEvent(d); ok {
+					orch.PushHandler(ctx, e)
+					return
+				}
+				orch.Handler(ctx, github.NewEvent(d))
+			},
+			logger,
+		).WithSecret(cfg.WebhookGitHubSecret)
+		server.WithGitHub(githubWebhook)
+		httpServer.WithHandler("/webhook/github", server.GitHubWebhook())
+		modules = append(modules, githubWebhook)
+	}
+
+	if cfg.SNOBUserGitLabToken != "" {
+		clients.Register(vcs.ForgeGitLab, "", gitlab.NewClient(
+			restyprometheus.NewClient(resty.New(), "go-snob", "gitlab_client"),
+			cfg.GitLabAPIURL,
+			cfg.SNOBUserGitLabToken,
+		))
+
+		gitlabWebhook := gitlabwebhook.NewWebhook(
+			func(ctx context.Context, d gitlabwebhook.Delivery) {
+				ctx = capture.WithWebhook(ctx, d.Body)
+				orch.Handler(ctx, gitlab.NewEvent(d))
+			},
+			logger,
+		).WithSecret(cfg.WebhookGitLabSecret)
+		server.WithGitLab(gitlabWebhook)
+		httpServer.WithHandler("/webhook/gitlab", server.GitLabWebhook())
+		modules = append(modules, gitlabWebhook)
+	}
+
+	// identities are needed to tell review requests for the bot apart, an unavailable instance is
+	// retried on its first event
+	if err := clients.Identify(ctx); err != nil {
+		logger.Warn("failed to resolve bot identity", zap.Error(err))
+	}
+
+	return app.NewApp(logger).WithModules(modules...), nil
+}
+
 func fatalJSONLog(msg string, err error) string {
 	escape := func(s string) string {
 		return strings.ReplaceAll(strings.ReplaceAll(s, `\`, `\\`), `"`, `\"`)
@@ -98,22 +244,123 @@ func newCfg() Config {
 	return cfg
 }
 
-func newYamlCfg(path string) YAMLConfig {
-	wd, err := os.Getwd()
-	if err != nil {
-		log.Fatal(fatalJSONLog("Failed to get working directory.", err))
+func newConfigStore(path string, logger *zap.Logger) (*hotreload.Store[config.Config], error) {
+	if !filepath.IsAbs(path) {
+		wd, err := os.Getwd()
+		if err != nil {
+			return nil, fmt.Errorf("get working directory: %w", err)
+		}
+		path = filepath.Join(wd, path)
 	}
-	b, err := os.ReadFile(filepath.Join(wd, path))
+	store, err := hotreload.NewStore(path, config.Parse, logger)
 	if err != nil {
-		log.Fatal(fatalJSONLog("Failed to read YAML config.", err))
+		return nil, fmt.Errorf("load YAML config: %w", err)
 	}
-	var cfg YAMLConfig
-	err = yaml.Unmarshal(b, &cfg)
-	if err != nil {
-		log.Fatal(fatalJSONLog("Failed to unmarshal YAML config.", err))
+
+	return store.
+		WithMetrics("go-snob", "config").
+		OnReload(func(prev, cur *hotreload.Snapshot[config.Config]) {
+			if !reflect.DeepEqual(prev.Value.AI, cur.Value.AI) || !reflect.DeepEqual(prev.Value.Gitea, cur.Value.Gitea) {
+				logger.Warn("ai and gitea sections changed, they are applied on restart only",
+					zap.String("config_version", cur.Version))
+			}
+		}), nil
+}
+
+func newGiteaClients(cfgs []config.GiteaConfig, fallbackToken string) (*vcs.Registry, error) {
+	registry := vcs.NewRegistry()
+	for _, g := range cfgs {
+		metricsPrefix := "gitea_client"
+		if len(cfgs) > 1 {
+			metricsPrefix += "_" + metricsName(g.Name)
+		}
+
+		rc, err := g.HTTP.Apply(restyprometheus.NewClient(resty.New(), "go-snob", metricsPrefix))
+		if err != nil {
+			return nil, fmt.Errorf("gitea %q: %w", g.Name, err)
+		}
+
+		token := fallbackToken
+		if g.Auth.Type != "" {
+			if err := g.Auth.Apply(rc); err != nil {
+				return nil, fmt.Errorf("gitea %q: %w", g.Name, err)
+			}
+			token = ""
+		}
+
+		client := gitea.NewClient(rc, g.BaseURL, token)
+		if g.HTTP.Timeout > 0 {
+			client.WithTimeout(g.HTTP.Timeout)
+		}
+		registry.Register(vcs.ForgeGitea, g.RouteHost(len(cfgs) == 1), client)
 	}
+	return registry, nil
+}
 
-	return cfg
+func newAIClient(rc *resty.Client, c config.AIConfig, token string, logger *zap.Logger) *ai.Client {
+	aiClient := ai.NewClient(rc, logger, c.URL, token)
+	if c.HTTP.Timeout > 0 {
+		aiClient.WithTimeout(c.HTTP.Timeout)
+	}
+	if c.Stream {
+		aiClient.WithStreaming(c.IdleTimeout)
+	}
+	if len(c.Models) > 0 {
+		models, routes := newAIChains(c)
+		aiClient.WithModels(models...).WithRoutes(routes...)
+	}
+	return aiClient
+}
+
+// newAIChains resolves model names of routes, the default chain is every model in config order
+func newAIChains(c config.AIConfig) ([]ai.Model, []ai.Route) {
+	byName := make(map[string]ai.Model, len(c.Models))
+	models := make([]ai.Model, 0, len(c.Models))
+	for _, m := range c.Models {
+		model := ai.Model{
+			Name:             m.Name,
+			ID:               m.Model,
+			URL:              m.URL,
+			Token:            os.ExpandEnv(m.Token),
+			MaxTokens:        m.MaxTokens,
+			Temperature:      m.Temperature,
+			TopP:             m.TopP,
+			FrequencyPenalty: m.FrequencyPenalty,
+			PresencePenalty:  m.PresencePenalty,
+		}
+		byName[m.Name] = model
+		models = append(models, model)
+	}
+
+	routes := make([]ai.Route, 0, len(c.Routes))
+	for _, r := range c.Routes {
+		route := ai.Route{
+			Name:         r.Name,
+			MinDiffLines: r.MinDiffLines,
+			MaxDiffLines: r.MaxDiffLines,
+			Paths:        r.Paths,
+			Security:     r.Security,
+			Verification: r.Verification,
+		}
+		for _, name := range r.Models {
+			route.Models = append(route.Models, byName[name])
+		}
+		routes = append(routes, route)
+	}
+	return models, routes
+}
+
+// metricsName makes s usable as a part of prometheus metric name
+func metricsName(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '_' {
+			return r
+		}
+		if r >= 'A' && r <= 'Z' {
+			return r + 'a' - 'A'
+		}
+		return '_'
+	}, s)
 }
 
 func newLogger(lvl zapcore.Level) *zap.Logger {
@@ -0,0 +1,234 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go-snob/internal"
+	"go-snob/internal/actor/vcs"
+	"go-snob/internal/capture"
+	"go-snob/internal/config"
+	"go-snob/pkg/hotreload"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/jessevdk/go-flags"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"resty.dev/v3"
+)
+
+// replayLogin identity of the bot on the stubbed forge
+const replayLogin = "go-snob-replay"
+
+// ReplayConfig flags of the replay subcommand
+type ReplayConfig struct {
+	Bundle        string `long:"bundle" description:"Path to a captured bundle" required:"true"`
+	PathToYAMLCfg string `long:"path-to-yaml" description:"Path to YAML cfg to replay with" required:"true"`
+
+	// Live sends requests to the AI endpoints of the config instead of answering with recorded responses
+	Live                         bool   `long:"live" description:"Use the configured AI endpoint instead of recorded responses"`
+	CloudRuFoundationalModelsKey string `long:"cloud-ru-foundational-models-key" description:"CloudRuFoundationalModelsKey, required with --live" env:"CLOUD_RU_FOUNDATIONAL_MODELS_KEY"`
+
+	LogLevel zapcore.Level `long:"log-level" description:"Log level: -1 debug, 0 info, 1 warn, 2 error" default:"0"`
+}
+
+// runReplay runs the job of a pull request or push bundle again with the current code and config, forge
+// calls are answered from the bundle. It prints the recorded and the replayed review and returns the exit code
+func runReplay(args []string) int {
+	var cfg ReplayConfig
+	parser := flags.NewParser(&cfg, flags.Default)
+	parser.Name = "go-snob replay"
+	if _, err := parser.ParseArgs(args); err != nil {
+		return 2
+	}
+	logger := newLogger(cfg.LogLevel)
+	if cfg.Live && cfg.CloudRuFoundationalModelsKey == "" {
+		logger.Error("--cloud-ru-foundational-models-key is required with --live")
+		return 2
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+
+	bundle, err := capture.Load(cfg.Bundle)
+	if err != nil {
+		logger.Error("failed to load bundle", zap.Error(err))
+		return 1
+	}
+	client := &replayClient{bundle: bundle, logger: logger}
+	var run func(o *internal.Orchestrator)
+	switch bundle.Kind {
+	case capture.KindPullRequest:
+		var e vcs.Event
+		if err := json.Unmarshal(bundle.Event, &e); err != nil {
+			logger.Error("failed to decode bundle event", zap.Error(err))
+			return 1
+		}
+		// a review started by new commits or a draft leaving its state is replayed as requested, the
+		// conditions it depended on are gone
+		switch e.Action {
+		case vcs.ActionSynchronized, vcs.ActionReadyForReview, vcs.ActionEdited, vcs.ActionReviewRequested:
+			e.Action = vcs.ActionReviewRequested
+			e.RequestedReviewer = replayLogin
+		}
+		client.forge = e.Forge
+		run = func(o *internal.Orchestrator) { o.Handler(ctx, e) }
+	case capture.KindPush:
+		var e vcs.PushEvent
+		if err := json.Unmarshal(bundle.Event, &e); err != nil {
+			logger.Error("failed to decode bundle event", zap.Error(err))
+			return 1
+		}
+		client.forge, client.commits = e.Forge, e.Commits
+		run = func(o *internal.Orchestrator) { o.PushHandler(ctx, e) }
+	default:
+		logger.Error("unknown bundle kind", zap.String("kind", string(bundle.Kind)))
+		return 1
+	}
+
+	// the replayed job is captured to a directory of its own, the review comes from its bundle
+	dir, err := os.MkdirTemp("", "go-snob-replay")
+	if err != nil {
+		logger.Error("failed to create capture dir", zap.Error(err))
+		return 1
+	}
+	defer os.RemoveAll(dir)
+	cfgStore, err := hotreload.NewStore(cfg.PathToYAMLCfg, func(b []byte) (config.Config, error) {
+		c, err := config.Parse(b)
+		c.Capture.Dir, c.Capture.MaxBundles, c.Capture.MaxAge = dir, 0, 0
+		return c, err
+	}, logger)
+	if err != nil {
+		logger.Error("failed to load config", zap.Error(err))
+		return 1
+	}
+	aiCfg := cfgStore.Current().Value.AI
+	if !cfg.Live {
+		recorded, err := capture.NewRecordedLLM(bundle.Exchanges)
+		if err != nil {
+			logger.Error("failed to start recorded llm", zap.Error(err))
+			return 1
+		}
+		defer recorded.Close()
+
+		aiCfg.URL = recorded.URL()
+		aiCfg.Stream = false
+		aiCfg.Models = append([]config.AIModelConfig(nil), aiCfg.Models...)
+		for i := range aiCfg.Models {
+			aiCfg.Models[i].URL = ""
+		}
+	}
+	rc, err := aiCfg.HTTP.Apply(resty.New())
+	if err != nil {
+		logger.Error("failed to init ai http client", zap.Error(err))
+		return 1
+	}
+
+	clients := vcs.NewRegistry().Register(client.forge, "", client)
+	run(internal.NewOrchestrator(newAIClient(rc, aiCfg, cfg.CloudRuFoundationalModelsKey, logger), clients, cfgStore, logger).
+		WithVersion(Version))
+
+	var replayed *vcs.Review
+	if path, err := capture.Newest(dir); err != nil {
+		logger.Error("failed to find replayed bundle", zap.Error(err))
+		return 1
+	} else if path != "" {
+		b, err := capture.Load(path)
+		if err != nil {
+			logger.Error("failed to load replayed bundle", zap.Error(err))
+			return 1
+		}
+		replayed = b.Review
+	}
+
+	writeReview(os.Stdout, fmt.Sprintf("recorded (config %s)", bundle.ConfigVersion), bundle.Review)
+	writeReview(os.Stdout, fmt.Sprintf("replayed (config %s)", cfgStore.Current().Version), replayed)
+	return 0
+}
+
+// replayClient answers forge calls of a replayed job from its bundle, nothing is posted
+type replayClient struct {
+	bundle *capture.Bundle
+	logger *zap.Logger
+
+	forge vcs.Forge
+	// commits of a push bundle
+	commits []vcs.Commit
+}
+
+func (c *replayClient) CurrentUser(context.Context) (vcs.User, error) {
+	return vcs.User{Login: replayLogin}, nil
+}
+
+func (c *replayClient) GetDiff(context.Context, string, string, int) (string, error) {
+	if c.bundle.Diff == "" {
+		return "", errors.New("bundle has no diff")
+	}
+	return c.bundle.Diff, nil
+}
+
+func (c *replayClient) CreateReview(context.Context, string, string, int, vcs.Review) error {
+	return nil
+}
+
+func (c *replayClient) GetCommit(_ context.Context, _ string, _ string, sha string) (vcs.Commit, error) {
+	for _, commit := range c.commits {
+		if commit.SHA == sha {
+			return commit, nil
+		}
+	}
+	return vcs.Commit{}, fmt.Errorf("commit %s isn't recorded", sha)
+}
+
+func (c *replayClient) GetCommitDiff(ctx context.Context, owner string, repo string, _ string) (string, error) {
+	return c.GetDiff(ctx, owner, repo, 0)
+}
+
+// CommitPullRequest the recorded commit was reviewed, so it was pushed directly
+func (c *replayClient) CommitPullRequest(context.Context, string, string, string) (int, bool, error) {
+	return 0, false, nil
+}
+
+func (c *replayClient) CreateCommitComment(_ context.Context, _ string, _ string, _ string, comment vcs.ReviewComment) error {
+	c.logger.Info("commit comment not posted in replay", zap.String("body", comment.Body))
+	return nil
+}
+
+func (c *replayClient) CreateComment(_ context.Context, _ string, _ string, _ int, body string) error {
+	c.logger.Info("comment not posted in replay", zap.String("body", body))
+	return nil
+}
+
+func (c *replayClient) CreateStatus(_ context.Context, _ string, _ string, _ string, status vcs.Status) error {
+	c.logger.Info("status not set in replay", zap.String("state", string(status.State)))
+	return nil
+}
+
+func (c *replayClient) GetFile(_ context.Context, _ string, _ string, _ string, path string) ([]byte, error) {
+	return nil, fmt.Errorf("file %s isn't recorded", path)
+}
+
+func writeReview(w io.Writer, title string, r *vcs.Review) {
+	var b strings.Builder
+	fmt.Fprintf(&b, "## %s\n\n", title)
+	if r == nil {
+		b.WriteString("No review was posted.\n\n")
+		_, _ = io.WriteString(w, b.String())
+		return
+	}
+	fmt.Fprintf(&b, "Verdict: %s\n\n%s\n\n", r.Verdict, strings.TrimSpace(r.Body))
+	for _, c := range r.Comments {
+		line := c.NewLine
+		if line == 0 {
+			line = c.OldLine
+		}
+		fmt.Fprintf(&b, "- %s:%d %s\n", c.Path, line, c.Body)
+	}
+	b.WriteString("\n")
+	_, _ = io.WriteString(w, b.String())
+}
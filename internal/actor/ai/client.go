@@ -1,16 +1,26 @@
 package ai
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"go-snob/internal/capture"
+	"net"
 	"net/http"
+	"slices"
+	"strings"
 	"time"
 
 	"go.uber.org/zap"
 	"resty.dev/v3"
 )
 
-const baseTimeout = 30 * time.Second
+const (
+	baseTimeout = 30 * time.Second
+	// progressParseInterval limits how often a streamed answer is parsed for progress
+	progressParseInterval = time.Second
+)
 
 type Client struct {
 	client *resty.Client
@@ -18,23 +28,58 @@ type Client struct {
 
 	baseUrl string
 	token   string
+	timeout time.Duration
+	// idleTimeout limits the gap between streamed chunks, zero disables streaming
+	idleTimeout time.Duration
 
-	systemPrompt string
+	// models default chain, routes override it for matching requests
+	models []Model
+	routes []Route
 }
 
-func NewClient(client *resty.Client, logger *zap.Logger, baseUrl string, token string, systemPrompt string) *Client {
+func NewClient(client *resty.Client, logger *zap.Logger, baseUrl string, token string) *Client {
 	return &Client{
-		client:       client,
-		logger:       logger,
-		token:        token,
-		baseUrl:      baseUrl,
-		systemPrompt: systemPrompt,
+		client:  client,
+		logger:  logger,
+		token:   token,
+		baseUrl: baseUrl,
+		timeout: baseTimeout,
+		models:  []Model{DefaultModel},
 	}
 }
 
-func (c *Client) newRequest() *resty.Request {
-	return c.client.R().SetTimeout(baseTimeout).
-		SetHeader("Authorization", fmt.Sprintf("Bearer %s", c.token)).
+func (c *Client) WithTimeout(timeout time.Duration) *Client {
+	c.timeout = timeout
+	return c
+}
+
+// WithStreaming streams completions, a response may take any time as long as chunks keep coming
+// within idleTimeout
+func (c *Client) WithStreaming(idleTimeout time.Duration) *Client {
+	c.idleTimeout = idleTimeout
+	return c
+}
+
+// WithModels replaces the default chain, on 5xx, 429 or a timeout the next model is tried
+func (c *Client) WithModels(models ...Model) *Client {
+	c.models = models
+	return c
+}
+
+// WithRoutes sends requests to the chain of the first matching route, see WithTraits
+func (c *Client) WithRoutes(routes ...Route) *Client {
+	c.routes = routes
+	return c
+}
+
+func (c *Client) newRequest(ctx context.Context, m Model) *resty.Request {
+	// the client token belongs to the client url, models of other providers bring their own
+	token := m.Token
+	if m.URL == "" && token == "" {
+		token = c.token
+	}
+	return c.client.R().SetContext(ctx).
+		SetHeader("Authorization", fmt.Sprintf("Bearer %s", token)).
 		SetHeaders(
 			map[string]string{
 				"Content-Type": "application/json",
@@ -48,6 +93,62 @@ type AIReviewResult struct {
 	Verdict  string    `json:"verdict,omitempty"` // "APPROVED", "REQUEST_CHANGES", "COMMENT"
 	Summary  string    `json:"summary"`
 	Comments []Comment `json:"comments"`
+	// Model name of the model which answered
+	Model string `json:"-"`
+}
+
+type AIDescriptionResult struct {
+	Title      string   `json:"title"`
+	Summary    string   `json:"summary"`
+	Motivation string   `json:"motivation"`
+	Changes    []string `json:"changes"`
+	Risks      string   `json:"risks"`
+	Testing    string   `json:"testing"`
+	// Model name of the model which answered
+	Model string `json:"-"`
+}
+
+type AIChangelogResult struct {
+	Category string `json:"category"` // "feature", "fix", "breaking", "other"
+	Entry    string `json:"entry"`
+}
+
+type AISecurityResult struct {
+	Summary  string            `json:"summary"`
+	Findings []SecurityFinding `json:"findings"`
+	// Model name of the model which answered
+	Model string `json:"-"`
+}
+
+type SecurityFinding struct {
+	File        string `json:"file"`
+	NewPosition int    `json:"new_position"`
+	OldPosition int    `json:"old_position"`
+	CWE         string `json:"cwe"`
+	Severity    string `json:"severity"` // "low", "medium", "high", "critical"
+	// Exploitability how the issue can be exploited and what it takes
+	Exploitability string `json:"exploitability"`
+	Message        string `json:"message"`
+}
+
+type AIVerificationResult struct {
+	Verdicts []CommentVerdict `json:"verdicts"`
+}
+
+type CommentVerdict struct {
+	// ID of the comment in the request
+	ID    int  `json:"id"`
+	Valid bool `json:"valid"`
+	// Confidence from 0 to 1 that the comment is right
+	Confidence float64 `json:"confidence"`
+	Reason     string  `json:"reason"`
+}
+
+type AIConventionResult struct {
+	General   bool     `json:"general"`
+	Rule      string   `json:"rule"`
+	Paths     []string `json:"paths"`
+	Languages []string `json:"languages"`
 }
 
 type ModelResponse struct {
@@ -62,6 +163,8 @@ type ModelResponse struct {
 			Content string `json:"content"` // <- здесь JSON как строка
 			// Остальные поля можно пропустить, если не нужны
 		} `json:"message"`
+		// FinishReason "length" if the answer was cut by max_tokens
+		FinishReason string `json:"finish_reason"`
 	} `json:"choices"`
 }
 
@@ -70,100 +173,361 @@ type Comment struct {
 	NewPosition int    `json:"new_position"`
 	OldPosition int    `json:"old_position"`
 	Message     string `json:"message"`
+	// Category rule the comment is based on, feedback is aggregated by it
+	Category string `json:"category"`
+	Severity string `json:"severity"` // "low", "medium", "high", "critical"
 }
 
-func (c *Client) Send(message string) (AIReviewResult, error) {
-	r, err := c.newRequest().
-		SetBody(
-			map[string]any{
-				"model":             "Qwen/Qwen3-Coder-480B-A35B-Instruct",
-				"max_tokens":        2000,
-				"temperature":       .1,
-				"top_p":             .8,
-				"frequency_penalty": .5,
-				"presence_penalty":  0,
-				"messages": []map[string]string{
-					{
-						"role":    "system",
-						"content": c.systemPrompt,
-					},
-					{
-						"role":    "user",
-						"content": message,
-					},
-				},
-				"response_format": map[string]any{
-					"type": "json_schema",
-					"json_schema": map[string]any{
-						"name":   "ai_review_result",
-						"strict": true,
-						"schema": map[string]any{
-							"type": "object",
-							"properties": map[string]any{
-								"verdict": map[string]any{
-									"type": "string",
-									"enum": []string{"APPROVED", "REQUEST_CHANGES", "COMMENT"},
-									"description": "Ставишь APPROVED если в целом все ок, нет критичных проблем. Ставишь" +
-										" REQUEST CHANGES если критичные проблемы есть. Ставишь COMMENT, если не определился ",
-								},
-								"summary": map[string]any{
-									"type": "string",
-								},
-								"comments": map[string]any{
-									"type": "array",
-									"items": map[string]any{
-										"type": "object",
-										"properties": map[string]any{
-											"file": map[string]any{
-												"type": "string",
-											},
-											"new_position": map[string]any{
-												"type":        "integer",
-												"description": "номер строки в diff после изменений, если комментируем новую строку",
-											},
-											"old_position": map[string]any{
-												"type":        "integer",
-												"description": "номер строки в diff до изменений, если комментируем удалённую строку",
-											},
-											"message": map[string]any{
-												"type": "string",
-											},
-										},
-										"required": []string{"file", "new_position", "old_position", "message"},
-									},
-								},
-							},
-							"required": []string{"summary", "comments", "verdict"},
-						},
-					},
-				},
-			},
-		).
-		Post(c.baseUrl)
+// HighSeverity the finding is worth acting on without a pull request to discuss it in
+func HighSeverity(severity string) bool {
+	return severity == "high" || severity == "critical"
+}
+
+func (c *Client) Send(ctx context.Context, systemPrompt string, message string) (AIReviewResult, error) {
+	return c.SendWithProgress(ctx, systemPrompt, message, nil)
+}
+
+// SendWithProgress calls progress with the review parsed so far while the answer is streamed, at most
+// every progressParseInterval. It's never called without streaming
+func (c *Client) SendWithProgress(
+	ctx context.Context,
+	systemPrompt string,
+	message string,
+	progress func(partial AIReviewResult),
+) (AIReviewResult, error) {
+	var onContent func(string)
+	if progress != nil {
+		var parsedAt time.Time
+		onContent = func(content string) {
+			// the whole answer is parsed again on every chunk, a chunk is a few tokens
+			if time.Since(parsedAt) < progressParseInterval {
+				return
+			}
+			parsedAt = time.Now()
+			var partial AIReviewResult
+			if err := json.Unmarshal([]byte(normalize(content)), &partial); err == nil {
+				progress(partial)
+			}
+		}
+	}
+
+	var review AIReviewResult
+	model, err := c.complete(ctx, systemPrompt, message, reviewFormat, &review, onContent)
 	if err != nil {
-		return AIReviewResult{}, fmt.Errorf("send request: %w", err)
+		return AIReviewResult{}, err
 	}
-	if r.StatusCode() != http.StatusOK {
-		return AIReviewResult{}, fmt.Errorf(
-			"send request: %w",
-			fmt.Errorf("unexpected status code: %v", r.StatusCode()),
+	review.Model = model
+	return review, nil
+}
+
+// Describe generates a pull request description
+func (c *Client) Describe(ctx context.Context, systemPrompt string, message string) (AIDescriptionResult, error) {
+	var description AIDescriptionResult
+	model, err := c.complete(ctx, systemPrompt, message, descriptionFormat, &description, nil)
+	if err != nil {
+		return AIDescriptionResult{}, err
+	}
+	description.Model = model
+	return description, nil
+}
+
+// Changelog categorizes a merged pull request and writes its changelog entry
+func (c *Client) Changelog(ctx context.Context, systemPrompt string, message string) (AIChangelogResult, error) {
+	var entry AIChangelogResult
+	if _, err := c.complete(ctx, systemPrompt, message, changelogFormat, &entry, nil); err != nil {
+		return AIChangelogResult{}, err
+	}
+	return entry, nil
+}
+
+// SecurityReview looks for vulnerabilities only
+func (c *Client) SecurityReview(ctx context.Context, systemPrompt string, message string) (AISecurityResult, error) {
+	t := traitsFrom(ctx)
+	t.Security = true
+	ctx = WithTraits(ctx, t)
+
+	var review AISecurityResult
+	model, err := c.complete(ctx, systemPrompt, message, securityFormat, &review, nil)
+	if err != nil {
+		return AISecurityResult{}, err
+	}
+	review.Model = model
+	return review, nil
+}
+
+// Verify asks the model to confirm or reject review comments
+func (c *Client) Verify(ctx context.Context, systemPrompt string, message string) (AIVerificationResult, error) {
+	t := traitsFrom(ctx)
+	t.Verification = true
+	ctx = WithTraits(ctx, t)
+
+	var res AIVerificationResult
+	if _, err := c.complete(ctx, systemPrompt, message, verificationFormat, &res, nil); err != nil {
+		return AIVerificationResult{}, err
+	}
+	return res, nil
+}
+
+// ExtractConvention turns an accepted review comment into a repository rule if it generalizes
+func (c *Client) ExtractConvention(ctx context.Context, systemPrompt string, message string) (AIConventionResult, error) {
+	var res AIConventionResult
+	if _, err := c.complete(ctx, systemPrompt, message, conventionFormat, &res, nil); err != nil {
+		return AIConventionResult{}, err
+	}
+	return res, nil
+}
+
+const (
+	// maxReasks corrective requests sent when the answer doesn't match the schema
+	maxReasks = 1
+	// maxContinuations requests sent to finish an answer cut by max_tokens
+	maxContinuations = 2
+
+	finishReasonLength = "length"
+
+	continuePrompt = "Ответ оборвался. Продолжи его ровно с того места, где он прервался, без повторов и пояснений."
+	reaskPrompt    = "Ответ не соответствует JSON схеме: %s. Верни только исправленный JSON документ по схеме, " +
+		"без markdown и пояснений."
+)
+
+type message struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// complete sends a chat completion and decodes the answer constrained by responseFormat into out.
+// An answer not matching the schema even after normalization is re-asked once with the errors found.
+// onContent gets the raw answer received so far when streaming. The name of the model which answered
+// is returned.
+func (c *Client) complete(
+	ctx context.Context,
+	systemPrompt string,
+	userMessage string,
+	responseFormat map[string]any,
+	out any,
+	onContent func(string),
+) (string, error) {
+	messages := []message{
+		{Role: "system", Content: systemPrompt},
+		{Role: "user", Content: userMessage},
+	}
+	schema := schemaOf(responseFormat)
+
+	for attempt := 0; ; attempt++ {
+		content, model, err := c.fallback(ctx, messages, responseFormat, onContent)
+		if err != nil {
+			return "", err
+		}
+
+		err = decode(content, schema, out)
+		if err == nil {
+			return model, nil
+		}
+		if attempt == maxReasks {
+			return "", err
+		}
+
+		c.logger.Warn("invalid ai response, re-asking", zap.Error(err))
+		messages = append(messages,
+			message{Role: "assistant", Content: content},
+			message{Role: "user", Content: fmt.Sprintf(reaskPrompt, err)},
 		)
 	}
+}
 
-	var resp ModelResponse
-	if err := json.Unmarshal(r.Bytes(), &resp); err != nil {
-		return AIReviewResult{}, fmt.Errorf("unmarshal response: %w", err)
+// fallback tries the chain of ctx model by model until one answers, only 5xx, 429 and timeouts
+// move on to the next model
+func (c *Client) fallback(
+	ctx context.Context,
+	messages []message,
+	responseFormat map[string]any,
+	onContent func(string),
+) (string, string, error) {
+	route, chain := c.chain(ctx)
+	var errs []error
+	for i, m := range chain {
+		start := time.Now()
+		content, err := c.chat(ctx, m, messages, responseFormat, onContent)
+		requestDuration.WithLabelValues(m.Name).Observe(time.Since(start).Seconds())
+		capture.FromContext(ctx).AddExchange(newExchange(m, messages, responseFormat, content, err, time.Since(start)))
+		if err == nil {
+			requestsTotal.WithLabelValues(m.Name, route, "success").Inc()
+			return content, m.Name, nil
+		}
+
+		errs = append(errs, fmt.Errorf("model %s: %w", m.Name, err))
+		if !retryable(ctx, err) || i == len(chain)-1 {
+			requestsTotal.WithLabelValues(m.Name, route, "error").Inc()
+			break
+		}
+		requestsTotal.WithLabelValues(m.Name, route, "fallback").Inc()
+		c.logger.Warn("ai model failed, falling back",
+			zap.String("model", m.Name),
+			zap.String("next", chain[i+1].Name),
+			zap.Error(err),
+		)
 	}
+	return "", "", errors.Join(errs...)
+}
 
-	if len(resp.Choices) > 0 {
-		contentStr := resp.Choices[0].Message.Content
+func newExchange(
+	m Model,
+	messages []message,
+	responseFormat map[string]any,
+	content string,
+	err error,
+	d time.Duration,
+) capture.Exchange {
+	e := capture.Exchange{Model: m.Name, Response: content, Duration: d}
+	if schema, ok := responseFormat["json_schema"].(map[string]any); ok {
+		e.Format, _ = schema["name"].(string)
+	}
+	for _, msg := range messages {
+		e.Messages = append(e.Messages, capture.Message{Role: msg.Role, Content: msg.Content})
+	}
+	if err != nil {
+		e.Error = err.Error()
+	}
+	return e
+}
 
-		var review AIReviewResult
-		if err := json.Unmarshal([]byte(contentStr), &review); err != nil {
-			return AIReviewResult{}, fmt.Errorf("unmarshal response: %w", err)
+// retryable reports whether another model may succeed where this one failed
+func retryable(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	var se *statusError
+	if errors.As(err, &se) {
+		return se.code >= http.StatusInternalServerError || se.code == http.StatusTooManyRequests
+	}
+	var ne net.Error
+	return errors.Is(err, errIdleTimeout) || errors.Is(err, context.DeadlineExceeded) ||
+		(errors.As(err, &ne) && ne.Timeout())
+}
+
+type statusError struct {
+	code int
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("unexpected status code: %v", e.code)
+}
+
+// chat returns the answer content. Answers cut by max_tokens are continued and concatenated, continuations
+// are sent without responseFormat: a constrained decoder would start a new document instead of finishing
+// the cut one.
+func (c *Client) chat(
+	ctx context.Context,
+	m Model,
+	messages []message,
+	responseFormat map[string]any,
+	onContent func(string),
+) (string, error) {
+	base, format := messages, responseFormat
+	var content strings.Builder
+	for i := 0; ; i++ {
+		var (
+			part         string
+			finishReason string
+			err          error
+		)
+		if c.idleTimeout > 0 {
+			prefix := content.String()
+			part, finishReason, err = c.stream(ctx, m, messages, format, func(received string) {
+				if onContent != nil {
+					onContent(prefix + received)
+				}
+			})
+		} else {
+			part, finishReason, err = c.send(ctx, m, messages, format)
+		}
+		if err != nil {
+			return "", err
 		}
-		return review, nil
+		content.WriteString(part)
+		if finishReason != finishReasonLength {
+			return content.String(), nil
+		}
+		if i == maxContinuations {
+			c.logger.Warn("ai response is still truncated, repairing what was received")
+			return content.String(), nil
+		}
+
+		c.logger.Info("ai response truncated by max_tokens, continuing")
+		messages = append(slices.Clone(base),
+			message{Role: "assistant", Content: content.String()},
+			message{Role: "user", Content: continuePrompt},
+		)
+		format = nil
+	}
+}
+
+func newCompletionBody(m Model, messages []message, responseFormat map[string]any) map[string]any {
+	body := map[string]any{
+		"model":    m.ID,
+		"messages": messages,
+	}
+	if responseFormat != nil {
+		body["response_format"] = responseFormat
+	}
+	if m.MaxTokens > 0 {
+		body["max_tokens"] = m.MaxTokens
+	}
+	for name, v := range map[string]*float64{
+		"temperature":       m.Temperature,
+		"top_p":             m.TopP,
+		"frequency_penalty": m.FrequencyPenalty,
+		"presence_penalty":  m.PresencePenalty,
+	} {
+		if v != nil {
+			body[name] = *v
+		}
+	}
+	return body
+}
+
+func (c *Client) url(m Model) string {
+	if m.URL != "" {
+		return m.URL
+	}
+	return c.baseUrl
+}
+
+func (c *Client) send(ctx context.Context, m Model, messages []message, responseFormat map[string]any) (string, string, error) {
+	r, err := c.newRequest(ctx, m).
+		SetTimeout(c.timeout).
+		SetBody(newCompletionBody(m, messages, responseFormat)).
+		Post(c.url(m))
+	if err != nil {
+		return "", "", fmt.Errorf("send request: %w", err)
+	}
+	if r.StatusCode() != http.StatusOK {
+		return "", "", fmt.Errorf("send request: %w", &statusError{code: r.StatusCode()})
 	}
 
-	return AIReviewResult{}, fmt.Errorf("empty response")
+	var resp ModelResponse
+	if err := json.Unmarshal(r.Bytes(), &resp); err != nil {
+		return "", "", fmt.Errorf("unmarshal response: %w", err)
+	}
+	if len(resp.Choices) == 0 {
+		return "", "", fmt.Errorf("empty response")
+	}
+	return resp.Choices[0].Message.Content, resp.Choices[0].FinishReason, nil
+}
+
+// decode normalizes content, validates it against schema and unmarshals it into out
+func decode(content string, schema map[string]any, out any) error {
+	doc := normalize(content)
+
+	var v any
+	if err := json.Unmarshal([]byte(doc), &v); err != nil {
+		return fmt.Errorf("unmarshal response: %w", err)
+	}
+	if errs := validate(schema, v, "$"); len(errs) > 0 {
+		return &ValidationError{Errors: errs}
+	}
+	if err := json.Unmarshal([]byte(doc), out); err != nil {
+		return fmt.Errorf("unmarshal response: %w", err)
+	}
+	return nil
 }
@@ -0,0 +1,44 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go.uber.org/zap"
+	"resty.dev/v3"
+)
+
+func TestClientContinuesTruncatedAnswer(t *testing.T) {
+	parts := []string{`{"verdict": "COMMENT", "summary": "to`, `o long", "comments": []}`}
+	var formats []bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		_, ok := body["response_format"]
+		formats = append(formats, ok)
+
+		finish := "stop"
+		if len(formats) == 1 {
+			finish = finishReasonLength
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"choices": []map[string]any{{
+			"message":       map[string]any{"content": parts[len(formats)-1]},
+			"finish_reason": finish,
+		}}})
+	}))
+	defer srv.Close()
+
+	res, err := NewClient(resty.New(), zap.NewNop(), srv.URL, "token").Send(context.Background(), "system", "diff")
+	if err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	if res.Summary != "too long" {
+		t.Errorf("unexpected summary: %q", res.Summary)
+	}
+	if len(formats) != 2 || !formats[0] || formats[1] {
+		t.Errorf("response_format must be sent with the first request only: %v", formats)
+	}
+}
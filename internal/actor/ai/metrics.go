@@ -0,0 +1,29 @@
+package ai
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	requestsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "go-snob",
+			Name:      "ai_requests_total",
+			Help:      "Completion requests by model, route and result: success, fallback or error",
+		},
+		[]string{"model", "route", "result"},
+	)
+	requestDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: "go-snob",
+			Name:      "ai_request_duration_seconds",
+			Help:      "Completion request duration by model",
+		},
+		[]string{"model"},
+	)
+)
+
+func init() {
+	// clients may be created several times, the metrics are shared
+	prometheus.MustRegister(requestsTotal, requestDuration)
+}
@@ -0,0 +1,110 @@
+package ai
+
+import (
+	"context"
+	"path"
+)
+
+// Model a chat completion model and the sampling parameters sent with it, unset parameters are left to
+// the provider
+type Model struct {
+	// Name identifies the model in footers, logs and metrics
+	Name string
+	// ID model id sent to the provider
+	ID string
+	// URL and Token of the provider, the client ones if URL is empty. The client token is never sent
+	// to another URL
+	URL   string
+	Token string
+
+	MaxTokens        int
+	Temperature      *float64
+	TopP             *float64
+	FrequencyPenalty *float64
+	PresencePenalty  *float64
+}
+
+// DefaultModel is used unless models are configured
+var DefaultModel = Model{
+	Name:             "qwen3-coder",
+	ID:               "Qwen/Qwen3-Coder-480B-A35B-Instruct",
+	MaxTokens:        2000,
+	Temperature:      float(.1),
+	TopP:             float(.8),
+	FrequencyPenalty: float(.5),
+	PresencePenalty:  float(0),
+}
+
+func float(v float64) *float64 {
+	return &v
+}
+
+// Traits of a request routes are matched against
+type Traits struct {
+	// DiffLines added and deleted lines
+	DiffLines int
+	// Paths changed files
+	Paths []string
+	// Security the request is a security pass
+	Security bool
+	// Verification the request verifies review comments
+	Verification bool
+}
+
+type traitsKey struct{}
+
+// WithTraits attaches traits to requests made with ctx
+func WithTraits(ctx context.Context, t Traits) context.Context {
+	return context.WithValue(ctx, traitsKey{}, t)
+}
+
+func traitsFrom(ctx context.Context) Traits {
+	t, _ := ctx.Value(traitsKey{}).(Traits)
+	return t
+}
+
+// Route sends matching requests to its Models, they're tried in order. Unset conditions match anything.
+type Route struct {
+	Name string
+	// MinDiffLines and MaxDiffLines bound the diff size, zero MaxDiffLines is unlimited
+	MinDiffLines int
+	MaxDiffLines int
+	// Paths path.Match patterns, the route matches if any changed file matches any of them
+	Paths []string
+	// Security matches security passes only
+	Security bool
+	// Verification matches comment verification only
+	Verification bool
+	Models       []Model
+}
+
+func (r Route) matches(t Traits) bool {
+	if t.DiffLines < r.MinDiffLines || (r.MaxDiffLines > 0 && t.DiffLines > r.MaxDiffLines) {
+		return false
+	}
+	if r.Security && !t.Security || r.Verification && !t.Verification {
+		return false
+	}
+	if len(r.Paths) == 0 {
+		return true
+	}
+	for _, p := range t.Paths {
+		for _, pattern := range r.Paths {
+			if ok, _ := path.Match(pattern, p); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// chain returns the models a request made with ctx is tried with: the first matching route or the default chain
+func (c *Client) chain(ctx context.Context) (string, []Model) {
+	t := traitsFrom(ctx)
+	for _, r := range c.routes {
+		if r.matches(t) {
+			return r.Name, r.Models
+		}
+	}
+	return "default", c.models
+}
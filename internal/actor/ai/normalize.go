@@ -0,0 +1,136 @@
+package ai
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	thinkRe = regexp.MustCompile(`(?is)<(think|thinking|reasoning)>.*?</(?:think|thinking|reasoning)>`)
+	fenceRe = regexp.MustCompile("(?s)```[a-zA-Z]*[ \t]*\n?(.*?)(?:```|$)")
+)
+
+// normalize extracts the JSON document from a model answer: reasoning sections, markdown fences and
+// prose around the document are dropped, common defects are repaired
+func normalize(content string) string {
+	content = thinkRe.ReplaceAllString(content, "")
+	// some servers strip the opening tag and leave the rest of the reasoning
+	if _, after, ok := strings.Cut(content, "</think>"); ok {
+		content = after
+	}
+	if m := fenceRe.FindStringSubmatch(content); m != nil && strings.Contains(m[1], "{") {
+		content = m[1]
+	}
+	return repair(extractObject(content))
+}
+
+// extractObject returns text from the first '{' up to the brace closing it, or up to the end if the
+// document is truncated
+func extractObject(s string) string {
+	start := strings.IndexByte(s, '{')
+	if start < 0 {
+		return strings.TrimSpace(s)
+	}
+
+	depth, inString, escaped := 0, false, false
+	for i := start; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case escaped:
+			escaped = false
+		case inString && c == '\\':
+			escaped = true
+		case c == '"':
+			inString = !inString
+		case inString:
+		case c == '{' || c == '[':
+			depth++
+		case c == '}' || c == ']':
+			depth--
+			if depth == 0 {
+				return s[start : i+1]
+			}
+		}
+	}
+	return s[start:]
+}
+
+// repair escapes control characters inside strings, drops trailing commas and closes whatever a
+// truncated document left open
+func repair(s string) string {
+	var (
+		b        strings.Builder
+		stack    []byte
+		inString bool
+		escaped  bool
+	)
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if inString {
+			switch {
+			case escaped:
+				escaped = false
+			case c == '\\':
+				escaped = true
+			case c == '"':
+				inString = false
+			case c == '\n':
+				b.WriteString(`\n`)
+				continue
+			case c == '\r':
+				b.WriteString(`\r`)
+				continue
+			case c == '\t':
+				b.WriteString(`\t`)
+				continue
+			}
+			b.WriteByte(c)
+			continue
+		}
+
+		switch c {
+		case '"':
+			inString = true
+		case '{':
+			stack = append(stack, '}')
+		case '[':
+			stack = append(stack, ']')
+		case '}', ']':
+			if len(stack) > 0 {
+				stack = stack[:len(stack)-1]
+			}
+		case ',':
+			if next := nextSignificant(s, i+1); next == '}' || next == ']' || next == 0 {
+				continue
+			}
+		}
+		b.WriteByte(c)
+	}
+
+	if inString {
+		if escaped {
+			b.WriteByte('\\')
+		}
+		b.WriteByte('"')
+	}
+	res := strings.TrimRight(b.String(), " \t\r\n,")
+	if strings.HasSuffix(res, ":") {
+		res += "null"
+	}
+	for i := len(stack) - 1; i >= 0; i-- {
+		res += string(stack[i])
+	}
+	return res
+}
+
+// nextSignificant returns the first non-whitespace byte of s starting at i, 0 at the end
+func nextSignificant(s string, i int) byte {
+	for ; i < len(s); i++ {
+		switch s[i] {
+		case ' ', '\t', '\r', '\n':
+		default:
+			return s[i]
+		}
+	}
+	return 0
+}
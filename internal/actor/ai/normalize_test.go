@@ -0,0 +1,54 @@
+package ai
+
+import "testing"
+
+func TestExtractObject(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{name: "prose around", in: `Here it is: {"a": 1} hope it helps`, want: `{"a": 1}`},
+		{name: "nested", in: `{"a": {"b": [1, {"c": 2}]}} tail`, want: `{"a": {"b": [1, {"c": 2}]}}`},
+		{name: "braces in strings", in: `{"a": "} ]", "b": "\"}"} x`, want: `{"a": "} ]", "b": "\"}"}`},
+		{name: "truncated", in: `ok {"a": [1, 2`, want: `{"a": [1, 2`},
+		{name: "no object", in: "  nothing here\n", want: "nothing here"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := extractObject(tt.in); got != tt.want {
+				t.Errorf("extractObject(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRepair(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{name: "valid", in: `{"a": [1, 2]}`, want: `{"a": [1, 2]}`},
+		{name: "trailing commas", in: `{"a": [1, 2,], "b": 3,}`, want: `{"a": [1, 2], "b": 3}`},
+		{name: "control characters", in: "{\"a\": \"x\ny\tz\"}", want: `{"a": "x\ny\tz"}`},
+		{name: "cut in string", in: `{"a": [{"b": "te`, want: `{"a": [{"b": "te"}]}`},
+		{name: "cut in escape", in: `{"a": "x\`, want: `{"a": "x\\"}`},
+		{name: "cut after key", in: `{"a": 1, "b":`, want: `{"a": 1, "b":null}`},
+		{name: "cut after comma", in: `{"a": [1, `, want: `{"a": [1]}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := repair(tt.in); got != tt.want {
+				t.Errorf("repair(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	in := "<think>{\"draft\": true}</think>\n```json\n{\"verdict\": \"COMMENT\", \"comments\": [],}\n```\nDone."
+	if got, want := normalize(in), `{"verdict": "COMMENT", "comments": []}`; got != want {
+		t.Errorf("normalize = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,251 @@
+package ai
+
+// reviewFormat response_format of review requests
+var reviewFormat = map[string]any{
+	"type": "json_schema",
+	"json_schema": map[string]any{
+		"name":   "ai_review_result",
+		"strict": true,
+		"schema": map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"verdict": map[string]any{
+					"type": "string",
+					"enum": []string{"APPROVED", "REQUEST_CHANGES", "COMMENT"},
+					"description": "Ставишь APPROVED если в целом все ок, нет критичных проблем. Ставишь" +
+						" REQUEST CHANGES если критичные проблемы есть. Ставишь COMMENT, если не определился ",
+				},
+				"summary": map[string]any{
+					"type": "string",
+				},
+				"comments": map[string]any{
+					"type": "array",
+					"items": map[string]any{
+						"type": "object",
+						"properties": map[string]any{
+							"file": map[string]any{
+								"type": "string",
+							},
+							"new_position": map[string]any{
+								"type":        "integer",
+								"description": "номер строки в diff после изменений, если комментируем новую строку",
+							},
+							"old_position": map[string]any{
+								"type":        "integer",
+								"description": "номер строки в diff до изменений, если комментируем удалённую строку",
+							},
+							"message": map[string]any{
+								"type": "string",
+							},
+							"category": map[string]any{
+								"type": "string",
+								"description": "короткая категория правила, по которому сделано замечание, в kebab-case:" +
+									" error-handling, naming, concurrency, performance, security, tests и т.п.",
+							},
+							"severity": map[string]any{
+								"type": "string",
+								"enum": []string{"low", "medium", "high", "critical"},
+								"description": "high или critical для ошибок, уязвимостей и потери данных, low для стиля и" +
+									" мелочей, medium для остального",
+							},
+						},
+						"required": []string{"file", "new_position", "old_position", "message", "category", "severity"},
+					},
+				},
+			},
+			"required": []string{"summary", "comments", "verdict"},
+		},
+	},
+}
+
+// descriptionFormat response_format of pull request description requests
+var descriptionFormat = map[string]any{
+	"type": "json_schema",
+	"json_schema": map[string]any{
+		"name":   "ai_description_result",
+		"strict": true,
+		"schema": map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"title": map[string]any{
+					"type":        "string",
+					"description": "короткий заголовок pull request'а в повелительном наклонении",
+				},
+				"summary": map[string]any{
+					"type":        "string",
+					"description": "что делает pull request, 1-3 предложения",
+				},
+				"motivation": map[string]any{
+					"type":        "string",
+					"description": "зачем нужно изменение, пусто если из diff и коммитов это не ясно",
+				},
+				"changes": map[string]any{
+					"type":        "array",
+					"items":       map[string]any{"type": "string"},
+					"description": "список изменений, по одному на пункт",
+				},
+				"risks": map[string]any{
+					"type":        "string",
+					"description": "что может сломаться, пусто если рисков нет",
+				},
+				"testing": map[string]any{
+					"type":        "string",
+					"description": "как проверить изменение",
+				},
+			},
+			"required": []string{"title", "summary", "motivation", "changes", "risks", "testing"},
+		},
+	},
+}
+
+// changelogFormat response_format of changelog entry requests
+var changelogFormat = map[string]any{
+	"type": "json_schema",
+	"json_schema": map[string]any{
+		"name":   "ai_changelog_result",
+		"strict": true,
+		"schema": map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"category": map[string]any{
+					"type": "string",
+					"enum": []string{"feature", "fix", "breaking", "other"},
+					"description": "breaking если изменение ломает обратную совместимость, feature для новой" +
+						" функциональности, fix для исправлений, other для всего остального",
+				},
+				"entry": map[string]any{
+					"type":        "string",
+					"description": "одна строка для пользователей продукта, без номера pull request'а",
+				},
+			},
+			"required": []string{"category", "entry"},
+		},
+	},
+}
+
+// securityFormat response_format of security review requests
+var securityFormat = map[string]any{
+	"type": "json_schema",
+	"json_schema": map[string]any{
+		"name":   "ai_security_result",
+		"strict": true,
+		"schema": map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"summary": map[string]any{
+					"type": "string",
+				},
+				"findings": map[string]any{
+					"type": "array",
+					"items": map[string]any{
+						"type": "object",
+						"properties": map[string]any{
+							"file": map[string]any{
+								"type": "string",
+							},
+							"new_position": map[string]any{
+								"type":        "integer",
+								"description": "номер строки в diff после изменений, если комментируем новую строку",
+							},
+							"old_position": map[string]any{
+								"type":        "integer",
+								"description": "номер строки в diff до изменений, если комментируем удалённую строку",
+							},
+							"cwe": map[string]any{
+								"type":        "string",
+								"description": "идентификатор CWE, например CWE-89",
+							},
+							"severity": map[string]any{
+								"type": "string",
+								"enum": []string{"low", "medium", "high", "critical"},
+							},
+							"exploitability": map[string]any{
+								"type":        "string",
+								"description": "как уязвимость может быть использована и какие условия для этого нужны",
+							},
+							"message": map[string]any{
+								"type": "string",
+							},
+						},
+						"required": []string{
+							"file", "new_position", "old_position", "cwe", "severity", "exploitability", "message",
+						},
+					},
+				},
+			},
+			"required": []string{"summary", "findings"},
+		},
+	},
+}
+
+// verificationFormat response_format of comment verification requests
+var verificationFormat = map[string]any{
+	"type": "json_schema",
+	"json_schema": map[string]any{
+		"name":   "ai_verification_result",
+		"strict": true,
+		"schema": map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"verdicts": map[string]any{
+					"type": "array",
+					"items": map[string]any{
+						"type": "object",
+						"properties": map[string]any{
+							"id": map[string]any{
+								"type":        "integer",
+								"description": "id проверяемого комментария",
+							},
+							"valid": map[string]any{
+								"type":        "boolean",
+								"description": "true если замечание верно и относится к показанному hunk",
+							},
+							"confidence": map[string]any{
+								"type":        "number",
+								"description": "уверенность в том, что замечание верно, от 0 до 1",
+							},
+							"reason": map[string]any{
+								"type": "string",
+							},
+						},
+						"required": []string{"id", "valid", "confidence", "reason"},
+					},
+				},
+			},
+			"required": []string{"verdicts"},
+		},
+	},
+}
+
+// conventionFormat response_format of convention extraction requests
+var conventionFormat = map[string]any{
+	"type": "json_schema",
+	"json_schema": map[string]any{
+		"name":   "ai_convention_result",
+		"strict": true,
+		"schema": map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"general": map[string]any{
+					"type":        "boolean",
+					"description": "true если из замечания следует общее правило для репозитория, а не частный случай",
+				},
+				"rule": map[string]any{
+					"type":        "string",
+					"description": "правило одним предложением в повелительном наклонении, пусто если general false",
+				},
+				"paths": map[string]any{
+					"type":        "array",
+					"items":       map[string]any{"type": "string"},
+					"description": "glob шаблоны файлов, к которым относится правило, пусто если ко всем",
+				},
+				"languages": map[string]any{
+					"type":        "array",
+					"items":       map[string]any{"type": "string"},
+					"description": "языки в нижнем регистре (go, python, typescript), пусто если к любым",
+				},
+			},
+			"required": []string{"general", "rule", "paths", "languages"},
+		},
+	},
+}
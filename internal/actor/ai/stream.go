@@ -0,0 +1,102 @@
+package ai
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+var errIdleTimeout = errors.New("no data within idle timeout")
+
+type streamChunk struct {
+	Choices []struct {
+		Delta struct {
+			Content string `json:"content"`
+		} `json:"delta"`
+		FinishReason string `json:"finish_reason"`
+	} `json:"choices"`
+}
+
+// stream sends a completion with server-sent events. The request is cancelled when no event arrives
+// within the idle timeout, there's no limit on the total time. onContent gets the content received so far.
+func (c *Client) stream(
+	ctx context.Context,
+	m Model,
+	messages []message,
+	responseFormat map[string]any,
+	onContent func(string),
+) (string, string, error) {
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+	idle := time.AfterFunc(c.idleTimeout, func() { cancel(errIdleTimeout) })
+	defer idle.Stop()
+
+	body := newCompletionBody(m, messages, responseFormat)
+	body["stream"] = true
+	r, err := c.newRequest(ctx, m).
+		SetHeader("Accept", "text/event-stream").
+		SetDoNotParseResponse(true).
+		SetBody(body).
+		Post(c.url(m))
+	if err != nil {
+		return "", "", fmt.Errorf("send request: %w", streamErr(ctx, err))
+	}
+	defer r.Body.Close()
+	if r.StatusCode() != http.StatusOK {
+		return "", "", fmt.Errorf("send request: %w", &statusError{code: r.StatusCode()})
+	}
+
+	var (
+		content      strings.Builder
+		finishReason string
+	)
+	scanner := bufio.NewScanner(r.Body)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		idle.Reset(c.idleTimeout)
+
+		data, ok := strings.CutPrefix(scanner.Text(), "data:")
+		if !ok {
+			continue
+		}
+		data = strings.TrimSpace(data)
+		if data == "[DONE]" {
+			break
+		}
+
+		var chunk streamChunk
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			return "", "", fmt.Errorf("unmarshal stream chunk: %w", err)
+		}
+		if len(chunk.Choices) == 0 {
+			continue
+		}
+		if fr := chunk.Choices[0].FinishReason; fr != "" {
+			finishReason = fr
+		}
+		if delta := chunk.Choices[0].Delta.Content; delta != "" {
+			content.WriteString(delta)
+			onContent(content.String())
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", "", fmt.Errorf("read stream: %w", streamErr(ctx, err))
+	}
+	if content.Len() == 0 {
+		return "", "", fmt.Errorf("empty response")
+	}
+	return content.String(), finishReason, nil
+}
+
+// streamErr reports the idle timeout instead of the bare context cancellation it caused
+func streamErr(ctx context.Context, err error) error {
+	if cause := context.Cause(ctx); errors.Is(cause, errIdleTimeout) {
+		return cause
+	}
+	return err
+}
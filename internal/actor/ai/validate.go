@@ -0,0 +1,90 @@
+package ai
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+)
+
+// ValidationError lists where a model answer deviates from the requested schema
+type ValidationError struct {
+	Errors []string
+}
+
+func (e *ValidationError) Error() string {
+	return "response doesn't match schema: " + strings.Join(e.Errors, "; ")
+}
+
+// schemaOf returns the JSON schema of a json_schema response format
+func schemaOf(format map[string]any) map[string]any {
+	js, _ := format["json_schema"].(map[string]any)
+	s, _ := js["schema"].(map[string]any)
+	return s
+}
+
+// validate checks a decoded JSON value against the subset of JSON schema the response formats use:
+// type, properties, required, items and enum
+func validate(schema map[string]any, v any, path string) []string {
+	if schema == nil {
+		return nil
+	}
+
+	switch schema["type"] {
+	case "object":
+		obj, ok := v.(map[string]any)
+		if !ok {
+			return []string{fmt.Sprintf("%s: expected object", path)}
+		}
+		var errs []string
+		required, _ := schema["required"].([]string)
+		for _, name := range required {
+			if _, ok := obj[name]; !ok {
+				errs = append(errs, fmt.Sprintf("%s: missing required property %q", path, name))
+			}
+		}
+		props, _ := schema["properties"].(map[string]any)
+		for name, ps := range props {
+			pv, ok := obj[name]
+			if !ok {
+				continue
+			}
+			sub, _ := ps.(map[string]any)
+			errs = append(errs, validate(sub, pv, path+"."+name)...)
+		}
+		return errs
+	case "array":
+		arr, ok := v.([]any)
+		if !ok {
+			return []string{fmt.Sprintf("%s: expected array", path)}
+		}
+		items, _ := schema["items"].(map[string]any)
+		var errs []string
+		for i, item := range arr {
+			errs = append(errs, validate(items, item, fmt.Sprintf("%s[%d]", path, i))...)
+		}
+		return errs
+	case "string":
+		s, ok := v.(string)
+		if !ok {
+			return []string{fmt.Sprintf("%s: expected string", path)}
+		}
+		if enum, ok := schema["enum"].([]string); ok && !slices.Contains(enum, s) {
+			return []string{fmt.Sprintf("%s: %q is not one of %s", path, s, strings.Join(enum, ", "))}
+		}
+	case "integer":
+		n, ok := v.(float64)
+		if !ok || n != math.Trunc(n) {
+			return []string{fmt.Sprintf("%s: expected integer", path)}
+		}
+	case "number":
+		if _, ok := v.(float64); !ok {
+			return []string{fmt.Sprintf("%s: expected number", path)}
+		}
+	case "boolean":
+		if _, ok := v.(bool); !ok {
+			return []string{fmt.Sprintf("%s: expected boolean", path)}
+		}
+	}
+	return nil
+}
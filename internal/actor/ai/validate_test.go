@@ -0,0 +1,68 @@
+package ai
+
+import (
+	"encoding/json"
+	"slices"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	schema := schemaOf(reviewFormat)
+	tests := []struct {
+		name string
+		doc  string
+		want []string
+	}{
+		{
+			name: "valid",
+			doc:  `{"verdict": "APPROVED", "summary": "ok", "comments": []}`,
+		},
+		{
+			name: "missing required",
+			doc:  `{"verdict": "APPROVED"}`,
+			want: []string{`$: missing required property "summary"`, `$: missing required property "comments"`},
+		},
+		{
+			name: "enum",
+			doc:  `{"verdict": "LGTM", "summary": "ok", "comments": []}`,
+			want: []string{`$.verdict: "LGTM" is not one of APPROVED, REQUEST_CHANGES, COMMENT`},
+		},
+		{
+			name: "items",
+			doc: `{"verdict": "COMMENT", "summary": "ok", "comments": [` +
+				`{"file": "a.go", "new_position": 1.5, "old_position": 0, "message": "m", "category": "c", "severity": "low"},` +
+				`{"file": 1, "new_position": 1, "old_position": 0, "message": "m"}]}`,
+			want: []string{
+				`$.comments[0].new_position: expected integer`,
+				`$.comments[1]: missing required property "category"`,
+				`$.comments[1]: missing required property "severity"`,
+				`$.comments[1].file: expected string`,
+			},
+		},
+		{
+			name: "wrong types",
+			doc:  `{"verdict": "COMMENT", "summary": ["ok"], "comments": {}}`,
+			want: []string{`$.comments: expected array`, `$.summary: expected string`},
+		},
+		{
+			name: "not an object",
+			doc:  `[]`,
+			want: []string{`$: expected object`},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var v any
+			if err := json.Unmarshal([]byte(tt.doc), &v); err != nil {
+				t.Fatal(err)
+			}
+			// properties are a map, their order isn't stable
+			got := validate(schema, v, "$")
+			slices.Sort(got)
+			slices.Sort(tt.want)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("validate = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
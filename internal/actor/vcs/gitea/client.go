@@ -1,8 +1,12 @@
 package gitea
 
 import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
 	"fmt"
-	"go-snob/internal/actor/ai"
+	"go-snob/internal/actor/vcs"
+	"net/http"
 	"strconv"
 	"time"
 
@@ -11,63 +15,157 @@ import (
 
 const baseTimeout = 10 * time.Second
 
+var (
+	_ vcs.Client            = (*Client)(nil)
+	_ vcs.MetadataProvider  = (*Client)(nil)
+	_ vcs.PullRequestEditor = (*Client)(nil)
+	_ vcs.ReleaseManager    = (*Client)(nil)
+	_ vcs.FileWriter        = (*Client)(nil)
+	_ vcs.CommitReader      = (*Client)(nil)
+	_ vcs.IssueCreator      = (*Client)(nil)
+	_ vcs.CommentEditor     = (*Client)(nil)
+)
+
 type Client struct {
 	baseUrl string
 	token   string
+	timeout time.Duration
 	client  *resty.Client
 }
 
+// NewClient token may be empty if authentication is already set up on the resty client
 func NewClient(client *resty.Client, baseUrl string, token string) *Client {
-	return &Client{token: token, baseUrl: baseUrl, client: client}
+	return &Client{token: token, baseUrl: baseUrl, timeout: baseTimeout, client: client}
+}
+
+func (c *Client) WithTimeout(timeout time.Duration) *Client {
+	c.timeout = timeout
+	return c
 }
 
-func (c *Client) newRequest() *resty.Request {
-	return c.client.R().SetTimeout(baseTimeout).
-		SetHeader("Authorization", fmt.Sprintf("token %s", c.token)).
+func (c *Client) newRequest(ctx context.Context) *resty.Request {
+	r := c.client.R().SetContext(ctx).SetTimeout(c.timeout).
 		SetHeaders(
 			map[string]string{
 				"Content-Type": "application/json",
 				"Accept":       "application/json",
 			},
 		)
+	if c.token != "" {
+		r.SetHeader("Authorization", fmt.Sprintf("token %s", c.token))
+	}
+	return r
+}
+
+func checkResponse(r *resty.Response, err error) error {
+	if err != nil {
+		return err
+	}
+	if !r.IsSuccess() {
+		return fmt.Errorf("unexpected status code: %v", r.StatusCode())
+	}
+	return nil
+}
+
+func (c *Client) CreateComment(ctx context.Context, owner string, repo string, index int, body string) error {
+	_, err := c.postComment(ctx, owner, repo, index, body)
+	return err
 }
 
-func (c *Client) AddComment(owner string, repo string, issueID int) (*resty.Response, error) {
-	return c.newRequest().
+type comment struct {
+	ID int64 `json:"id"`
+}
+
+func (c *Client) CreateCommentWithID(ctx context.Context, owner string, repo string, index int, body string) (int64, error) {
+	r, err := c.postComment(ctx, owner, repo, index, body)
+	if err != nil {
+		return 0, err
+	}
+
+	var cm comment
+	if err := json.Unmarshal(r.Bytes(), &cm); err != nil {
+		return 0, fmt.Errorf("unmarshal comment: %w", err)
+	}
+	return cm.ID, nil
+}
+
+func (c *Client) postComment(ctx context.Context, owner string, repo string, index int, body string) (*resty.Response, error) {
+	r, err := c.newRequest(ctx).
 		SetBody(
 			map[string]any{
-				"body": "Тестовый комментарий",
+				"body": body,
 			},
 		).
-		SetPathParams(map[string]string{"owner": owner, "repo": repo, "index": strconv.Itoa(issueID)}).
+		SetPathParams(map[string]string{"owner": owner, "repo": repo, "index": strconv.Itoa(index)}).
 		Post(c.baseUrl + "/repos/{owner}/{repo}/issues/{index}/comments")
+	if err := checkResponse(r, err); err != nil {
+		return nil, fmt.Errorf("create comment: %w", err)
+	}
+	return r, nil
 }
 
-func (c *Client) CreateReview(
-	owner string,
-	repo string,
-	issueID int,
-	commitSHA string,
-	review ai.AIReviewResult,
-) (*resty.Response, error) {
+func (c *Client) EditComment(ctx context.Context, owner string, repo string, _ int, id int64, body string) error {
+	r, err := c.newRequest(ctx).
+		SetBody(map[string]any{"body": body}).
+		SetPathParams(map[string]string{"owner": owner, "repo": repo, "id": strconv.FormatInt(id, 10)}).
+		Patch(c.baseUrl + "/repos/{owner}/{repo}/issues/comments/{id}")
+	if err := checkResponse(r, err); err != nil {
+		return fmt.Errorf("edit comment: %w", err)
+	}
+	return nil
+}
+
+func (c *Client) DeleteComment(ctx context.Context, owner string, repo string, _ int, id int64) error {
+	r, err := c.newRequest(ctx).
+		SetPathParams(map[string]string{"owner": owner, "repo": repo, "id": strconv.FormatInt(id, 10)}).
+		Delete(c.baseUrl + "/repos/{owner}/{repo}/issues/comments/{id}")
+	if err := checkResponse(r, err); err != nil {
+		return fmt.Errorf("delete comment: %w", err)
+	}
+	return nil
+}
+
+func (c *Client) CreateReview(ctx context.Context, owner string, repo string, index int, review vcs.Review) error {
+	_, err := c.postReview(ctx, owner, repo, index, review)
+	return err
+}
+
+type pullReview struct {
+	ID int64 `json:"id"`
+}
+
+func (c *Client) CreateReviewWithID(ctx context.Context, owner string, repo string, index int, review vcs.Review) (int64, error) {
+	r, err := c.postReview(ctx, owner, repo, index, review)
+	if err != nil {
+		return 0, err
+	}
+
+	var pr pullReview
+	if err := json.Unmarshal(r.Bytes(), &pr); err != nil {
+		return 0, fmt.Errorf("unmarshal review: %w", err)
+	}
+	return pr.ID, nil
+}
+
+func (c *Client) postReview(ctx context.Context, owner string, repo string, index int, review vcs.Review) (*resty.Response, error) {
 	var comments []map[string]any
 	for _, com := range review.Comments {
 		comments = append(
 			comments, map[string]any{
-				"body":         com.Message,
-				"path":         com.File,
-				"new_position": com.NewPosition,
-				"old_position": com.OldPosition,
+				"body":         com.Body,
+				"path":         com.Path,
+				"new_position": com.NewLine,
+				"old_position": com.OldLine,
 			},
 		)
 	}
 
-	return c.newRequest().
+	r, err := c.newRequest(ctx).
 		SetBody(
 			map[string]any{
-				"body":      review.Summary,
-				"event":     review.Verdict,
-				"commit_id": commitSHA,
+				"body":      review.Body,
+				"event":     string(review.Verdict),
+				"commit_id": review.CommitSHA,
 				"comments":  comments,
 			},
 		).
@@ -75,17 +173,492 @@ func (c *Client) CreateReview(
 			map[string]string{
 				"owner": owner,
 				"repo":  repo,
-				"index": strconv.Itoa(issueID),
+				"index": strconv.Itoa(index),
 			},
 		).
 		Post(c.baseUrl + "/repos/{owner}/{repo}/pulls/{index}/reviews")
+	if err := checkResponse(r, err); err != nil {
+		return nil, fmt.Errorf("create review: %w", err)
+	}
+	return r, nil
+}
+
+func (c *Client) CreateStatus(ctx context.Context, owner string, repo string, sha string, status vcs.Status) error {
+	r, err := c.newRequest(ctx).
+		SetBody(
+			map[string]any{
+				"state":       string(status.State),
+				"context":     status.Context,
+				"description": status.Description,
+				"target_url":  status.TargetURL,
+			},
+		).
+		SetPathParams(map[string]string{"owner": owner, "repo": repo, "sha": sha}).
+		Post(c.baseUrl + "/repos/{owner}/{repo}/statuses/{sha}")
+	if err := checkResponse(r, err); err != nil {
+		return fmt.Errorf("create status: %w", err)
+	}
+	return nil
 }
 
-func (c *Client) GetDiff(owner string, repo string, issueID int) (*resty.Response, error) {
-	return c.newRequest().
+func (c *Client) GetDiff(ctx context.Context, owner string, repo string, index int) (string, error) {
+	r, err := c.newRequest(ctx).
 		SetPathParams(
 			map[string]string{
-				"owner": owner, "repo": repo, "index": strconv.Itoa(issueID), "diffType": "diff",
+				"owner": owner, "repo": repo, "index": strconv.Itoa(index), "diffType": "diff",
 			},
 		).Get(c.baseUrl + "/repos/{owner}/{repo}/pulls/{index}.diff")
+	if err := checkResponse(r, err); err != nil {
+		return "", fmt.Errorf("get diff: %w", err)
+	}
+	return string(r.Bytes()), nil
+}
+
+func (c *Client) GetFile(ctx context.Context, owner string, repo string, ref string, path string) ([]byte, error) {
+	r, err := c.newRequest(ctx).
+		SetPathParams(map[string]string{"owner": owner, "repo": repo}).
+		SetRawPathParam("filepath", path).
+		SetQueryParam("ref", ref).
+		Get(c.baseUrl + "/repos/{owner}/{repo}/raw/{filepath}")
+	if err := checkResponse(r, err); err != nil {
+		return nil, fmt.Errorf("get file: %w", err)
+	}
+	return r.Bytes(), nil
+}
+
+type commit struct {
+	SHA    string `json:"sha"`
+	Commit struct {
+		Message string `json:"message"`
+		Author  struct {
+			Name string `json:"name"`
+		} `json:"author"`
+	} `json:"commit"`
+	Author *struct {
+		Login string `json:"login"`
+	} `json:"author"`
+	Parents []struct {
+		SHA string `json:"sha"`
+	} `json:"parents"`
+}
+
+// toVCS author is the forge user if the commit email is linked to one
+func (cm commit) toVCS() vcs.Commit {
+	res := vcs.Commit{SHA: cm.SHA, Message: cm.Commit.Message, Author: cm.Commit.Author.Name}
+	if cm.Author != nil && cm.Author.Login != "" {
+		res.Author = cm.Author.Login
+	}
+	for _, p := range cm.Parents {
+		res.Parents = append(res.Parents, p.SHA)
+	}
+	return res
+}
+
+func (c *Client) ListCommits(ctx context.Context, owner string, repo string, index int) ([]vcs.Commit, error) {
+	r, err := c.newRequest(ctx).
+		SetPathParams(map[string]string{"owner": owner, "repo": repo, "index": strconv.Itoa(index)}).
+		Get(c.baseUrl + "/repos/{owner}/{repo}/pulls/{index}/commits")
+	if err := checkResponse(r, err); err != nil {
+		return nil, fmt.Errorf("list commits: %w", err)
+	}
+
+	var commits []commit
+	if err := json.Unmarshal(r.Bytes(), &commits); err != nil {
+		return nil, fmt.Errorf("unmarshal commits: %w", err)
+	}
+
+	res := make([]vcs.Commit, 0, len(commits))
+	for _, cm := range commits {
+		res = append(res, cm.toVCS())
+	}
+	return res, nil
+}
+
+func (c *Client) GetLanguages(ctx context.Context, owner string, repo string) (map[string]int64, error) {
+	r, err := c.newRequest(ctx).
+		SetPathParams(map[string]string{"owner": owner, "repo": repo}).
+		Get(c.baseUrl + "/repos/{owner}/{repo}/languages")
+	if err := checkResponse(r, err); err != nil {
+		return nil, fmt.Errorf("get languages: %w", err)
+	}
+
+	var langs map[string]int64
+	if err := json.Unmarshal(r.Bytes(), &langs); err != nil {
+		return nil, fmt.Errorf("unmarshal languages: %w", err)
+	}
+	return langs, nil
+}
+
+type user struct {
+	Login string `json:"login"`
+}
+
+func (c *Client) CurrentUser(ctx context.Context) (vcs.User, error) {
+	r, err := c.newRequest(ctx).Get(c.baseUrl + "/user")
+	if err := checkResponse(r, err); err != nil {
+		return vcs.User{}, fmt.Errorf("get current user: %w", err)
+	}
+
+	var u user
+	if err := json.Unmarshal(r.Bytes(), &u); err != nil {
+		return vcs.User{}, fmt.Errorf("unmarshal user: %w", err)
+	}
+	if u.Login == "" {
+		return vcs.User{}, fmt.Errorf("get current user: empty login")
+	}
+	return vcs.User{Login: u.Login}, nil
+}
+
+func (c *Client) EditPullRequest(ctx context.Context, owner string, repo string, index int, edit vcs.PullRequestEdit) error {
+	body := map[string]any{}
+	if edit.Title != nil {
+		body["title"] = *edit.Title
+	}
+	if edit.Body != nil {
+		body["body"] = *edit.Body
+	}
+
+	r, err := c.newRequest(ctx).
+		SetBody(body).
+		SetPathParams(map[string]string{"owner": owner, "repo": repo, "index": strconv.Itoa(index)}).
+		Patch(c.baseUrl + "/repos/{owner}/{repo}/pulls/{index}")
+	if err := checkResponse(r, err); err != nil {
+		return fmt.Errorf("edit pull request: %w", err)
+	}
+	return nil
+}
+
+type release struct {
+	ID      int64  `json:"id"`
+	TagName string `json:"tag_name"`
+	Name    string `json:"name"`
+	Body    string `json:"body"`
+	Target  string `json:"target_commitish"`
+	Draft   bool   `json:"draft"`
+}
+
+func (c *Client) DraftRelease(ctx context.Context, owner string, repo string) (vcs.Release, bool, error) {
+	r, err := c.newRequest(ctx).
+		SetPathParams(map[string]string{"owner": owner, "repo": repo}).
+		SetQueryParam("draft", "true").
+		Get(c.baseUrl + "/repos/{owner}/{repo}/releases")
+	if err := checkResponse(r, err); err != nil {
+		return vcs.Release{}, false, fmt.Errorf("list releases: %w", err)
+	}
+
+	var releases []release
+	if err := json.Unmarshal(r.Bytes(), &releases); err != nil {
+		return vcs.Release{}, false, fmt.Errorf("unmarshal releases: %w", err)
+	}
+	// releases are listed newest first
+	for _, rel := range releases {
+		if rel.Draft {
+			return vcs.Release{
+				ID:      rel.ID,
+				TagName: rel.TagName,
+				Name:    rel.Name,
+				Body:    rel.Body,
+				Target:  rel.Target,
+				Draft:   rel.Draft,
+			}, true, nil
+		}
+	}
+	return vcs.Release{}, false, nil
+}
+
+func (c *Client) CreateRelease(ctx context.Context, owner string, repo string, rel vcs.Release) error {
+	r, err := c.newRequest(ctx).
+		SetBody(
+			map[string]any{
+				"tag_name":         rel.TagName,
+				"name":             rel.Name,
+				"body":             rel.Body,
+				"target_commitish": rel.Target,
+				"draft":            rel.Draft,
+			},
+		).
+		SetPathParams(map[string]string{"owner": owner, "repo": repo}).
+		Post(c.baseUrl + "/repos/{owner}/{repo}/releases")
+	if err := checkResponse(r, err); err != nil {
+		return fmt.Errorf("create release: %w", err)
+	}
+	return nil
+}
+
+func (c *Client) EditReleaseBody(ctx context.Context, owner string, repo string, id int64, body string) error {
+	r, err := c.newRequest(ctx).
+		SetBody(map[string]any{"body": body}).
+		SetPathParams(map[string]string{"owner": owner, "repo": repo, "id": strconv.FormatInt(id, 10)}).
+		Patch(c.baseUrl + "/repos/{owner}/{repo}/releases/{id}")
+	if err := checkResponse(r, err); err != nil {
+		return fmt.Errorf("edit release: %w", err)
+	}
+	return nil
+}
+
+func (c *Client) EnsureBranch(ctx context.Context, owner string, repo string, branch string, base string) error {
+	r, err := c.newRequest(ctx).
+		SetPathParams(map[string]string{"owner": owner, "repo": repo}).
+		SetRawPathParam("branch", branch).
+		Get(c.baseUrl + "/repos/{owner}/{repo}/branches/{branch}")
+	if err != nil {
+		return fmt.Errorf("get branch: %w", err)
+	}
+	if r.IsSuccess() {
+		return nil
+	}
+	if r.StatusCode() != http.StatusNotFound {
+		return fmt.Errorf("get branch: unexpected status code: %v", r.StatusCode())
+	}
+
+	r, err = c.newRequest(ctx).
+		SetBody(map[string]any{"new_branch_name": branch, "old_branch_name": base}).
+		SetPathParams(map[string]string{"owner": owner, "repo": repo}).
+		Post(c.baseUrl + "/repos/{owner}/{repo}/branches")
+	if err := checkResponse(r, err); err != nil {
+		return fmt.Errorf("create branch: %w", err)
+	}
+	return nil
+}
+
+type contents struct {
+	SHA     string `json:"sha"`
+	Content string `json:"content"`
+}
+
+func (c *Client) GetFileContent(ctx context.Context, owner string, repo string, ref string, path string) (vcs.FileContent, bool, error) {
+	r, err := c.newRequest(ctx).
+		SetPathParams(map[string]string{"owner": owner, "repo": repo}).
+		SetRawPathParam("filepath", path).
+		SetQueryParam("ref", ref).
+		Get(c.baseUrl + "/repos/{owner}/{repo}/contents/{filepath}")
+	if err == nil && r.StatusCode() == http.StatusNotFound {
+		return vcs.FileContent{}, false, nil
+	}
+	if err := checkResponse(r, err); err != nil {
+		return vcs.FileContent{}, false, fmt.Errorf("get contents: %w", err)
+	}
+
+	var cs contents
+	if err := json.Unmarshal(r.Bytes(), &cs); err != nil {
+		return vcs.FileContent{}, false, fmt.Errorf("unmarshal contents: %w", err)
+	}
+	content, err := base64.StdEncoding.DecodeString(cs.Content)
+	if err != nil {
+		return vcs.FileContent{}, false, fmt.Errorf("decode contents: %w", err)
+	}
+	return vcs.FileContent{Content: content, SHA: cs.SHA}, true, nil
+}
+
+func (c *Client) PutFile(
+	ctx context.Context,
+	owner string,
+	repo string,
+	branch string,
+	path string,
+	message string,
+	content []byte,
+	sha string,
+) error {
+	body := map[string]any{
+		"branch":  branch,
+		"message": message,
+		"content": base64.StdEncoding.EncodeToString(content),
+	}
+	req := c.newRequest(ctx).
+		SetPathParams(map[string]string{"owner": owner, "repo": repo}).
+		SetRawPathParam("filepath", path)
+
+	var (
+		r   *resty.Response
+		err error
+	)
+	if sha == "" {
+		r, err = req.SetBody(body).Post(c.baseUrl + "/repos/{owner}/{repo}/contents/{filepath}")
+	} else {
+		body["sha"] = sha
+		r, err = req.SetBody(body).Put(c.baseUrl + "/repos/{owner}/{repo}/contents/{filepath}")
+	}
+	if err := checkResponse(r, err); err != nil {
+		return fmt.Errorf("put file: %w", err)
+	}
+	return nil
+}
+
+func (c *Client) GetCommit(ctx context.Context, owner string, repo string, sha string) (vcs.Commit, error) {
+	r, err := c.newRequest(ctx).
+		SetPathParams(map[string]string{"owner": owner, "repo": repo, "sha": sha}).
+		Get(c.baseUrl + "/repos/{owner}/{repo}/git/commits/{sha}")
+	if err := checkResponse(r, err); err != nil {
+		return vcs.Commit{}, fmt.Errorf("get commit: %w", err)
+	}
+
+	var cm commit
+	if err := json.Unmarshal(r.Bytes(), &cm); err != nil {
+		return vcs.Commit{}, fmt.Errorf("unmarshal commit: %w", err)
+	}
+	return cm.toVCS(), nil
+}
+
+func (c *Client) GetCommitDiff(ctx context.Context, owner string, repo string, sha string) (string, error) {
+	r, err := c.newRequest(ctx).
+		SetPathParams(map[string]string{"owner": owner, "repo": repo, "sha": sha}).
+		Get(c.baseUrl + "/repos/{owner}/{repo}/git/commits/{sha}.diff")
+	if err := checkResponse(r, err); err != nil {
+		return "", fmt.Errorf("get commit diff: %w", err)
+	}
+	return string(r.Bytes()), nil
+}
+
+func (c *Client) CommitPullRequest(ctx context.Context, owner string, repo string, sha string) (int, bool, error) {
+	r, err := c.newRequest(ctx).
+		SetPathParams(map[string]string{"owner": owner, "repo": repo, "sha": sha}).
+		Get(c.baseUrl + "/repos/{owner}/{repo}/commits/{sha}/pull")
+	if err == nil && r.StatusCode() == http.StatusNotFound {
+		return 0, false, nil
+	}
+	if err := checkResponse(r, err); err != nil {
+		return 0, false, fmt.Errorf("get commit pull request: %w", err)
+	}
+
+	var pr struct {
+		Number int `json:"number"`
+	}
+	if err := json.Unmarshal(r.Bytes(), &pr); err != nil {
+		return 0, false, fmt.Errorf("unmarshal pull request: %w", err)
+	}
+	return pr.Number, true, nil
+}
+
+func (c *Client) CreateIssue(ctx context.Context, owner string, repo string, title string, body string) error {
+	r, err := c.newRequest(ctx).
+		SetBody(map[string]any{"title": title, "body": body}).
+		SetPathParams(map[string]string{"owner": owner, "repo": repo}).
+		Post(c.baseUrl + "/repos/{owner}/{repo}/issues")
+	if err := checkResponse(r, err); err != nil {
+		return fmt.Errorf("create issue: %w", err)
+	}
+	return nil
+}
+
+type reviewComment struct {
+	ID               int64  `json:"id"`
+	Path             string `json:"path"`
+	Body             string `json:"body"`
+	Position         int    `json:"position"`
+	OriginalPosition int    `json:"original_position"`
+	CommitID         string `json:"commit_id"`
+	Resolver         *user  `json:"resolver"`
+}
+
+func (c *Client) ListReviewComments(
+	ctx context.Context,
+	owner string,
+	repo string,
+	index int,
+	reviewID int64,
+) ([]vcs.PostedComment, error) {
+	r, err := c.newRequest(ctx).
+		SetPathParams(map[string]string{
+			"owner": owner,
+			"repo":  repo,
+			"index": strconv.Itoa(index),
+			"id":    strconv.FormatInt(reviewID, 10),
+		}).
+		Get(c.baseUrl + "/repos/{owner}/{repo}/pulls/{index}/reviews/{id}/comments")
+	if err := checkResponse(r, err); err != nil {
+		return nil, fmt.Errorf("list review comments: %w", err)
+	}
+
+	var comments []reviewComment
+	if err := json.Unmarshal(r.Bytes(), &comments); err != nil {
+		return nil, fmt.Errorf("unmarshal review comments: %w", err)
+	}
+	posted := make([]vcs.PostedComment, 0, len(comments))
+	for _, cm := range comments {
+		p := vcs.PostedComment{
+			ID:        cm.ID,
+			Path:      cm.Path,
+			Line:      cm.Position,
+			Body:      cm.Body,
+			Resolved:  cm.Resolver != nil,
+			CommitSHA: cm.CommitID,
+		}
+		if p.Line == 0 {
+			p.OldLine = cm.OriginalPosition
+		}
+		posted = append(posted, p)
+	}
+	return posted, nil
+}
+
+type reaction struct {
+	User    user   `json:"user"`
+	Content string `json:"content"`
+}
+
+func (c *Client) ListCommentReactions(ctx context.Context, owner string, repo string, commentID int64) ([]vcs.Reaction, error) {
+	r, err := c.newRequest(ctx).
+		SetPathParams(map[string]string{"owner": owner, "repo": repo, "id": strconv.FormatInt(commentID, 10)}).
+		Get(c.baseUrl + "/repos/{owner}/{repo}/issues/comments/{id}/reactions")
+	if err := checkResponse(r, err); err != nil {
+		return nil, fmt.Errorf("list comment reactions: %w", err)
+	}
+
+	var reactions []reaction
+	// no reactions is answered with an empty body
+	if len(r.Bytes()) > 0 {
+		if err := json.Unmarshal(r.Bytes(), &reactions); err != nil {
+			return nil, fmt.Errorf("unmarshal reactions: %w", err)
+		}
+	}
+	res := make([]vcs.Reaction, 0, len(reactions))
+	for _, re := range reactions {
+		res = append(res, vcs.Reaction{User: re.User.Login, Content: re.Content})
+	}
+	return res, nil
+}
+
+type pullRequestHead struct {
+	Head struct {
+		SHA string `json:"sha"`
+	} `json:"head"`
+}
+
+func (c *Client) PullRequestHead(ctx context.Context, owner string, repo string, index int) (string, error) {
+	r, err := c.newRequest(ctx).
+		SetPathParams(map[string]string{"owner": owner, "repo": repo, "index": strconv.Itoa(index)}).
+		Get(c.baseUrl + "/repos/{owner}/{repo}/pulls/{index}")
+	if err := checkResponse(r, err); err != nil {
+		return "", fmt.Errorf("get pull request: %w", err)
+	}
+
+	var pr pullRequestHead
+	if err := json.Unmarshal(r.Bytes(), &pr); err != nil {
+		return "", fmt.Errorf("unmarshal pull request: %w", err)
+	}
+	return pr.Head.SHA, nil
+}
+
+type repoPermission struct {
+	Permission string `json:"permission"`
+}
+
+func (c *Client) CanWrite(ctx context.Context, owner string, repo string, login string) (bool, error) {
+	r, err := c.newRequest(ctx).
+		SetPathParams(map[string]string{"owner": owner, "repo": repo, "user": login}).
+		Get(c.baseUrl + "/repos/{owner}/{repo}/collaborators/{user}/permission")
+	if err := checkResponse(r, err); err != nil {
+		return false, fmt.Errorf("get collaborator permission: %w", err)
+	}
+
+	var p repoPermission
+	if err := json.Unmarshal(r.Bytes(), &p); err != nil {
+		return false, fmt.Errorf("unmarshal permission: %w", err)
+	}
+	switch p.Permission {
+	case "write", "admin", "owner":
+		return true, nil
+	}
+	return false, nil
 }
@@ -0,0 +1,132 @@
+package gitea
+
+import (
+	"go-snob/internal/actor/vcs"
+	"go-snob/pkg/giteawebhook"
+	"strings"
+)
+
+// NewEvent converts pull request deliveries, false is returned for other events
+func NewEvent(d giteawebhook.Delivery) (vcs.Event, bool) {
+	p, ok := d.Payload.(*giteawebhook.PullRequestPayload)
+	if !ok {
+		return vcs.Event{}, false
+	}
+
+	var reviewer string
+	if p.RequestedReviewer != nil {
+		reviewer = p.RequestedReviewer.Login
+	}
+
+	var previousTitle string
+	if p.Changes != nil && p.Changes.Title != nil {
+		previousTitle = p.Changes.Title.From
+	}
+
+	return vcs.Event{
+		Forge:      vcs.ForgeGitea,
+		DeliveryID: d.ID,
+		ReceivedAt: d.ReceivedAt,
+		Action:     vcs.Action(p.Action),
+		Repository: vcs.Repository{
+			Owner:   p.Repository.Owner.Login,
+			Name:    p.Repository.Name,
+			HTMLURL: p.Repository.HTMLURL,
+		},
+		PullRequest: vcs.PullRequest{
+			Number:      p.PullRequest.Number,
+			HTMLURL:     p.PullRequest.HTMLURL,
+			Title:       p.PullRequest.Title,
+			Description: p.PullRequest.Body,
+			Author:      p.PullRequest.User.Login,
+			Labels:      labels(p.PullRequest.Labels),
+			HeadRef:     p.PullRequest.Head.Ref,
+			HeadSHA:     p.PullRequest.Head.SHA,
+			BaseRef:     p.PullRequest.Base.Ref,
+			BaseSHA:     p.PullRequest.Base.SHA,
+			Draft:       p.PullRequest.Draft,
+			Merged:      p.PullRequest.Merged,
+
+			RequestedReviewers: logins(p.PullRequest.RequestedReviewers),
+		},
+		RequestedReviewer: reviewer,
+		PreviousTitle:     previousTitle,
+	}, true
+}
+
+// NewPushEvent converts branch push deliveries, false is returned for other events and tag pushes
+func NewPushEvent(d giteawebhook.Delivery) (vcs.PushEvent, bool) {
+	p, ok := d.Payload.(*giteawebhook.PushPayload)
+	if !ok {
+		return vcs.PushEvent{}, false
+	}
+	branch, ok := strings.CutPrefix(p.Ref, "refs/heads/")
+	if !ok {
+		return vcs.PushEvent{}, false
+	}
+
+	commits := make([]vcs.Commit, 0, len(p.Commits))
+	for _, c := range p.Commits {
+		author := c.Author.Username
+		if author == "" {
+			author = c.Author.Name
+		}
+		commits = append(commits, vcs.Commit{SHA: c.ID, Message: c.Message, Author: author})
+	}
+
+	return vcs.PushEvent{
+		Forge:      vcs.ForgeGitea,
+		DeliveryID: d.ID,
+		ReceivedAt: d.ReceivedAt,
+		Repository: vcs.Repository{
+			Owner:   p.Repository.Owner.Login,
+			Name:    p.Repository.Name,
+			HTMLURL: p.Repository.HTMLURL,
+		},
+		Branch:  branch,
+		Before:  p.Before,
+		After:   p.After,
+		Pusher:  p.Pusher.Login,
+		Commits: commits,
+	}, true
+}
+
+// NewCommentEvent converts created comment deliveries, false is returned for other events and actions
+func NewCommentEvent(d giteawebhook.Delivery) (vcs.CommentEvent, bool) {
+	p, ok := d.Payload.(*giteawebhook.IssueCommentPayload)
+	if !ok || p.Action != giteawebhook.ActionCreated {
+		return vcs.CommentEvent{}, false
+	}
+
+	return vcs.CommentEvent{
+		Forge:      vcs.ForgeGitea,
+		DeliveryID: d.ID,
+		ReceivedAt: d.ReceivedAt,
+		Repository: vcs.Repository{
+			Owner:   p.Repository.Owner.Login,
+			Name:    p.Repository.Name,
+			HTMLURL: p.Repository.HTMLURL,
+		},
+		Number:    p.Issue.Number,
+		IsPull:    p.IsPull || p.Issue.PullRequest != nil,
+		CommentID: p.Comment.ID,
+		Author:    p.Comment.User.Login,
+		Body:      p.Comment.Body,
+	}, true
+}
+
+func labels(ls []giteawebhook.Label) []string {
+	res := make([]string, 0, len(ls))
+	for _, l := range ls {
+		res = append(res, l.Name)
+	}
+	return res
+}
+
+func logins(us []giteawebhook.User) []string {
+	res := make([]string, 0, len(us))
+	for _, u := range us {
+		res = append(res, u.Login)
+	}
+	return res
+}
@@ -0,0 +1,543 @@
+package github
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"go-snob/internal/actor/vcs"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"resty.dev/v3"
+)
+
+const (
+	baseTimeout = 10 * time.Second
+
+	DefaultBaseURL = "https://api.github.com"
+	apiVersion     = "2022-11-28"
+)
+
+var (
+	_ vcs.Client            = (*Client)(nil)
+	_ vcs.MetadataProvider  = (*Client)(nil)
+	_ vcs.PullRequestEditor = (*Client)(nil)
+	_ vcs.CommitReader      = (*Client)(nil)
+	_ vcs.CommitCommenter   = (*Client)(nil)
+	_ vcs.CommentEditor     = (*Client)(nil)
+	_ vcs.ReleaseManager    = (*Client)(nil)
+	_ vcs.FileWriter        = (*Client)(nil)
+)
+
+var reviewEvents = map[vcs.Verdict]string{
+	vcs.VerdictApproved:       "APPROVE",
+	vcs.VerdictRequestChanges: "REQUEST_CHANGES",
+	vcs.VerdictComment:        "COMMENT",
+}
+
+type Client struct {
+	baseUrl string
+	token   string
+	client  *resty.Client
+}
+
+func NewClient(client *resty.Client, baseUrl string, token string) *Client {
+	return &Client{token: token, baseUrl: baseUrl, client: client}
+}
+
+func (c *Client) newRequest(ctx context.Context) *resty.Request {
+	return c.client.R().SetContext(ctx).SetTimeout(baseTimeout).
+		SetHeader("Authorization", fmt.Sprintf("Bearer %s", c.token)).
+		SetHeaders(
+			map[string]string{
+				"Content-Type":         "application/json",
+				"Accept":               "application/vnd.github+json",
+				"X-GitHub-Api-Version": apiVersion,
+			},
+		)
+}
+
+func checkResponse(r *resty.Response, err error) error {
+	if err != nil {
+		return err
+	}
+	if !r.IsSuccess() {
+		return fmt.Errorf("unexpected status code: %v", r.StatusCode())
+	}
+	return nil
+}
+
+func (c *Client) CreateComment(ctx context.Context, owner string, repo string, index int, body string) error {
+	_, err := c.postComment(ctx, owner, repo, index, body)
+	return err
+}
+
+type comment struct {
+	ID int64 `json:"id"`
+}
+
+func (c *Client) CreateCommentWithID(ctx context.Context, owner string, repo string, index int, body string) (int64, error) {
+	r, err := c.postComment(ctx, owner, repo, index, body)
+	if err != nil {
+		return 0, err
+	}
+
+	var cm comment
+	if err := json.Unmarshal(r.Bytes(), &cm); err != nil {
+		return 0, fmt.Errorf("unmarshal comment: %w", err)
+	}
+	return cm.ID, nil
+}
+
+func (c *Client) postComment(ctx context.Context, owner string, repo string, index int, body string) (*resty.Response, error) {
+	r, err := c.newRequest(ctx).
+		SetBody(map[string]any{"body": body}).
+		SetPathParams(map[string]string{"owner": owner, "repo": repo, "index": strconv.Itoa(index)}).
+		Post(c.baseUrl + "/repos/{owner}/{repo}/issues/{index}/comments")
+	if err := checkResponse(r, err); err != nil {
+		return nil, fmt.Errorf("create comment: %w", err)
+	}
+	return r, nil
+}
+
+func (c *Client) EditComment(ctx context.Context, owner string, repo string, _ int, id int64, body string) error {
+	r, err := c.newRequest(ctx).
+		SetBody(map[string]any{"body": body}).
+		SetPathParams(map[string]string{"owner": owner, "repo": repo, "id": strconv.FormatInt(id, 10)}).
+		Patch(c.baseUrl + "/repos/{owner}/{repo}/issues/comments/{id}")
+	if err := checkResponse(r, err); err != nil {
+		return fmt.Errorf("edit comment: %w", err)
+	}
+	return nil
+}
+
+func (c *Client) DeleteComment(ctx context.Context, owner string, repo string, _ int, id int64) error {
+	r, err := c.newRequest(ctx).
+		SetPathParams(map[string]string{"owner": owner, "repo": repo, "id": strconv.FormatInt(id, 10)}).
+		Delete(c.baseUrl + "/repos/{owner}/{repo}/issues/comments/{id}")
+	if err := checkResponse(r, err); err != nil {
+		return fmt.Errorf("delete comment: %w", err)
+	}
+	return nil
+}
+
+func (c *Client) CreateReview(ctx context.Context, owner string, repo string, index int, review vcs.Review) error {
+	event, ok := reviewEvents[review.Verdict]
+	if !ok {
+		return fmt.Errorf("create review: unknown verdict %q", review.Verdict)
+	}
+
+	comments := make([]map[string]any, 0, len(review.Comments))
+	for _, com := range review.Comments {
+		comment := map[string]any{
+			"body": com.Body,
+			"path": com.Path,
+		}
+		if com.NewLine > 0 {
+			comment["line"] = com.NewLine
+			comment["side"] = "RIGHT"
+		} else {
+			comment["line"] = com.OldLine
+			comment["side"] = "LEFT"
+		}
+		comments = append(comments, comment)
+	}
+
+	r, err := c.newRequest(ctx).
+		SetBody(
+			map[string]any{
+				"body":      review.Body,
+				"event":     event,
+				"commit_id": review.CommitSHA,
+				"comments":  comments,
+			},
+		).
+		SetPathParams(map[string]string{"owner": owner, "repo": repo, "index": strconv.Itoa(index)}).
+		Post(c.baseUrl + "/repos/{owner}/{repo}/pulls/{index}/reviews")
+	if err := checkResponse(r, err); err != nil {
+		return fmt.Errorf("create review: %w", err)
+	}
+	return nil
+}
+
+func (c *Client) CreateStatus(ctx context.Context, owner string, repo string, sha string, status vcs.Status) error {
+	r, err := c.newRequest(ctx).
+		SetBody(
+			map[string]any{
+				"state":       string(status.State),
+				"context":     status.Context,
+				"description": status.Description,
+				"target_url":  status.TargetURL,
+			},
+		).
+		SetPathParams(map[string]string{"owner": owner, "repo": repo, "sha": sha}).
+		Post(c.baseUrl + "/repos/{owner}/{repo}/statuses/{sha}")
+	if err := checkResponse(r, err); err != nil {
+		return fmt.Errorf("create status: %w", err)
+	}
+	return nil
+}
+
+func (c *Client) GetDiff(ctx context.Context, owner string, repo string, index int) (string, error) {
+	r, err := c.newRequest(ctx).
+		SetHeader("Accept", "application/vnd.github.diff").
+		SetPathParams(map[string]string{"owner": owner, "repo": repo, "index": strconv.Itoa(index)}).
+		Get(c.baseUrl + "/repos/{owner}/{repo}/pulls/{index}")
+	if err := checkResponse(r, err); err != nil {
+		return "", fmt.Errorf("get diff: %w", err)
+	}
+	return string(r.Bytes()), nil
+}
+
+func (c *Client) GetFile(ctx context.Context, owner string, repo string, ref string, path string) ([]byte, error) {
+	r, err := c.newRequest(ctx).
+		SetHeader("Accept", "application/vnd.github.raw+json").
+		SetPathParams(map[string]string{"owner": owner, "repo": repo}).
+		SetRawPathParam("path", path).
+		SetQueryParam("ref", ref).
+		Get(c.baseUrl + "/repos/{owner}/{repo}/contents/{path}")
+	if err := checkResponse(r, err); err != nil {
+		return nil, fmt.Errorf("get file: %w", err)
+	}
+	return r.Bytes(), nil
+}
+
+type commit struct {
+	SHA    string `json:"sha"`
+	Commit struct {
+		Message string `json:"message"`
+		Author  struct {
+			Name string `json:"name"`
+		} `json:"author"`
+	} `json:"commit"`
+	Author *struct {
+		Login string `json:"login"`
+	} `json:"author"`
+	Parents []struct {
+		SHA string `json:"sha"`
+	} `json:"parents"`
+}
+
+func (c *Client) ListCommits(ctx context.Context, owner string, repo string, index int) ([]vcs.Commit, error) {
+	r, err := c.newRequest(ctx).
+		SetPathParams(map[string]string{"owner": owner, "repo": repo, "index": strconv.Itoa(index)}).
+		Get(c.baseUrl + "/repos/{owner}/{repo}/pulls/{index}/commits")
+	if err := checkResponse(r, err); err != nil {
+		return nil, fmt.Errorf("list commits: %w", err)
+	}
+
+	var commits []commit
+	if err := json.Unmarshal(r.Bytes(), &commits); err != nil {
+		return nil, fmt.Errorf("unmarshal commits: %w", err)
+	}
+
+	res := make([]vcs.Commit, 0, len(commits))
+	for _, cm := range commits {
+		res = append(res, vcs.Commit{SHA: cm.SHA, Message: cm.Commit.Message})
+	}
+	return res, nil
+}
+
+func (c *Client) GetLanguages(ctx context.Context, owner string, repo string) (map[string]int64, error) {
+	r, err := c.newRequest(ctx).
+		SetPathParams(map[string]string{"owner": owner, "repo": repo}).
+		Get(c.baseUrl + "/repos/{owner}/{repo}/languages")
+	if err := checkResponse(r, err); err != nil {
+		return nil, fmt.Errorf("get languages: %w", err)
+	}
+
+	var langs map[string]int64
+	if err := json.Unmarshal(r.Bytes(), &langs); err != nil {
+		return nil, fmt.Errorf("unmarshal languages: %w", err)
+	}
+	return langs, nil
+}
+
+type user struct {
+	Login string `json:"login"`
+}
+
+func (c *Client) CurrentUser(ctx context.Context) (vcs.User, error) {
+	r, err := c.newRequest(ctx).Get(c.baseUrl + "/user")
+	if err := checkResponse(r, err); err != nil {
+		return vcs.User{}, fmt.Errorf("get current user: %w", err)
+	}
+
+	var u user
+	if err := json.Unmarshal(r.Bytes(), &u); err != nil {
+		return vcs.User{}, fmt.Errorf("unmarshal user: %w", err)
+	}
+	if u.Login == "" {
+		return vcs.User{}, fmt.Errorf("get current user: empty login")
+	}
+	return vcs.User{Login: u.Login}, nil
+}
+
+func (c *Client) EditPullRequest(ctx context.Context, owner string, repo string, index int, edit vcs.PullRequestEdit) error {
+	body := map[string]any{}
+	if edit.Title != nil {
+		body["title"] = *edit.Title
+	}
+	if edit.Body != nil {
+		body["body"] = *edit.Body
+	}
+
+	r, err := c.newRequest(ctx).
+		SetBody(body).
+		SetPathParams(map[string]string{"owner": owner, "repo": repo, "index": strconv.Itoa(index)}).
+		Patch(c.baseUrl + "/repos/{owner}/{repo}/pulls/{index}")
+	if err := checkResponse(r, err); err != nil {
+		return fmt.Errorf("edit pull request: %w", err)
+	}
+	return nil
+}
+
+func (c *Client) CreateCommitComment(ctx context.Context, owner string, repo string, sha string, com vcs.ReviewComment) error {
+	body := map[string]any{"body": com.Body}
+	if com.Path != "" && com.NewLine > 0 {
+		body["path"] = com.Path
+		body["line"] = com.NewLine
+	}
+
+	r, err := c.newRequest(ctx).
+		SetBody(body).
+		SetPathParams(map[string]string{"owner": owner, "repo": repo, "sha": sha}).
+		Post(c.baseUrl + "/repos/{owner}/{repo}/commits/{sha}/comments")
+	if err := checkResponse(r, err); err != nil {
+		return fmt.Errorf("create commit comment: %w", err)
+	}
+	return nil
+}
+
+func (c *Client) GetCommit(ctx context.Context, owner string, repo string, sha string) (vcs.Commit, error) {
+	r, err := c.newRequest(ctx).
+		SetPathParams(map[string]string{"owner": owner, "repo": repo, "sha": sha}).
+		Get(c.baseUrl + "/repos/{owner}/{repo}/commits/{sha}")
+	if err := checkResponse(r, err); err != nil {
+		return vcs.Commit{}, fmt.Errorf("get commit: %w", err)
+	}
+
+	var cm commit
+	if err := json.Unmarshal(r.Bytes(), &cm); err != nil {
+		return vcs.Commit{}, fmt.Errorf("unmarshal commit: %w", err)
+	}
+	// author is null when the commit email isn't linked to an account
+	author := cm.Commit.Author.Name
+	if cm.Author != nil && cm.Author.Login != "" {
+		author = cm.Author.Login
+	}
+	parents := make([]string, 0, len(cm.Parents))
+	for _, p := range cm.Parents {
+		parents = append(parents, p.SHA)
+	}
+	return vcs.Commit{SHA: cm.SHA, Message: cm.Commit.Message, Author: author, Parents: parents}, nil
+}
+
+func (c *Client) GetCommitDiff(ctx context.Context, owner string, repo string, sha string) (string, error) {
+	r, err := c.newRequest(ctx).
+		SetHeader("Accept", "application/vnd.github.diff").
+		SetPathParams(map[string]string{"owner": owner, "repo": repo, "sha": sha}).
+		Get(c.baseUrl + "/repos/{owner}/{repo}/commits/{sha}")
+	if err := checkResponse(r, err); err != nil {
+		return "", fmt.Errorf("get commit diff: %w", err)
+	}
+	return string(r.Bytes()), nil
+}
+
+func (c *Client) CommitPullRequest(ctx context.Context, owner string, repo string, sha string) (int, bool, error) {
+	r, err := c.newRequest(ctx).
+		SetPathParams(map[string]string{"owner": owner, "repo": repo, "sha": sha}).
+		Get(c.baseUrl + "/repos/{owner}/{repo}/commits/{sha}/pulls")
+	if err := checkResponse(r, err); err != nil {
+		return 0, false, fmt.Errorf("get commit pull requests: %w", err)
+	}
+
+	var prs []struct {
+		Number   int     `json:"number"`
+		MergedAt *string `json:"merged_at"`
+	}
+	if err := json.Unmarshal(r.Bytes(), &prs); err != nil {
+		return 0, false, fmt.Errorf("unmarshal pull requests: %w", err)
+	}
+	// open pull requests list the commit as well, only a merged one reviewed it
+	for _, pr := range prs {
+		if pr.MergedAt != nil {
+			return pr.Number, true, nil
+		}
+	}
+	return 0, false, nil
+}
+
+type release struct {
+	ID      int64  `json:"id"`
+	TagName string `json:"tag_name"`
+	Name    string `json:"name"`
+	Body    string `json:"body"`
+	Target  string `json:"target_commitish"`
+	Draft   bool   `json:"draft"`
+}
+
+func (c *Client) DraftRelease(ctx context.Context, owner string, repo string) (vcs.Release, bool, error) {
+	r, err := c.newRequest(ctx).
+		SetPathParams(map[string]string{"owner": owner, "repo": repo}).
+		Get(c.baseUrl + "/repos/{owner}/{repo}/releases")
+	if err := checkResponse(r, err); err != nil {
+		return vcs.Release{}, false, fmt.Errorf("list releases: %w", err)
+	}
+
+	var releases []release
+	if err := json.Unmarshal(r.Bytes(), &releases); err != nil {
+		return vcs.Release{}, false, fmt.Errorf("unmarshal releases: %w", err)
+	}
+	// releases are listed newest first, drafts only to users with push access
+	for _, rel := range releases {
+		if rel.Draft {
+			return vcs.Release{
+				ID:      rel.ID,
+				TagName: rel.TagName,
+				Name:    rel.Name,
+				Body:    rel.Body,
+				Target:  rel.Target,
+				Draft:   rel.Draft,
+			}, true, nil
+		}
+	}
+	return vcs.Release{}, false, nil
+}
+
+func (c *Client) CreateRelease(ctx context.Context, owner string, repo string, rel vcs.Release) error {
+	r, err := c.newRequest(ctx).
+		SetBody(
+			map[string]any{
+				"tag_name":         rel.TagName,
+				"name":             rel.Name,
+				"body":             rel.Body,
+				"target_commitish": rel.Target,
+				"draft":            rel.Draft,
+			},
+		).
+		SetPathParams(map[string]string{"owner": owner, "repo": repo}).
+		Post(c.baseUrl + "/repos/{owner}/{repo}/releases")
+	if err := checkResponse(r, err); err != nil {
+		return fmt.Errorf("create release: %w", err)
+	}
+	return nil
+}
+
+func (c *Client) EditReleaseBody(ctx context.Context, owner string, repo string, id int64, body string) error {
+	r, err := c.newRequest(ctx).
+		SetBody(map[string]any{"body": body}).
+		SetPathParams(map[string]string{"owner": owner, "repo": repo, "id": strconv.FormatInt(id, 10)}).
+		Patch(c.baseUrl + "/repos/{owner}/{repo}/releases/{id}")
+	if err := checkResponse(r, err); err != nil {
+		return fmt.Errorf("edit release: %w", err)
+	}
+	return nil
+}
+
+type ref struct {
+	Object struct {
+		SHA string `json:"sha"`
+	} `json:"object"`
+}
+
+// EnsureBranch GitHub creates branches as git refs, base is resolved to its head commit first
+func (c *Client) EnsureBranch(ctx context.Context, owner string, repo string, branch string, base string) error {
+	r, err := c.newRequest(ctx).
+		SetPathParams(map[string]string{"owner": owner, "repo": repo}).
+		SetRawPathParam("branch", branch).
+		Get(c.baseUrl + "/repos/{owner}/{repo}/git/ref/heads/{branch}")
+	if err != nil {
+		return fmt.Errorf("get branch: %w", err)
+	}
+	if r.IsSuccess() {
+		return nil
+	}
+	if r.StatusCode() != http.StatusNotFound {
+		return fmt.Errorf("get branch: unexpected status code: %v", r.StatusCode())
+	}
+
+	r, err = c.newRequest(ctx).
+		SetPathParams(map[string]string{"owner": owner, "repo": repo}).
+		SetRawPathParam("branch", base).
+		Get(c.baseUrl + "/repos/{owner}/{repo}/git/ref/heads/{branch}")
+	if err := checkResponse(r, err); err != nil {
+		return fmt.Errorf("get base branch: %w", err)
+	}
+	var baseRef ref
+	if err := json.Unmarshal(r.Bytes(), &baseRef); err != nil {
+		return fmt.Errorf("unmarshal base branch: %w", err)
+	}
+
+	r, err = c.newRequest(ctx).
+		SetBody(map[string]any{"ref": "refs/heads/" + branch, "sha": baseRef.Object.SHA}).
+		SetPathParams(map[string]string{"owner": owner, "repo": repo}).
+		Post(c.baseUrl + "/repos/{owner}/{repo}/git/refs")
+	if err := checkResponse(r, err); err != nil {
+		return fmt.Errorf("create branch: %w", err)
+	}
+	return nil
+}
+
+type contents struct {
+	SHA     string `json:"sha"`
+	Content string `json:"content"`
+}
+
+func (c *Client) GetFileContent(ctx context.Context, owner string, repo string, ref string, path string) (vcs.FileContent, bool, error) {
+	r, err := c.newRequest(ctx).
+		SetPathParams(map[string]string{"owner": owner, "repo": repo}).
+		SetRawPathParam("path", path).
+		SetQueryParam("ref", ref).
+		Get(c.baseUrl + "/repos/{owner}/{repo}/contents/{path}")
+	if err == nil && r.StatusCode() == http.StatusNotFound {
+		return vcs.FileContent{}, false, nil
+	}
+	if err := checkResponse(r, err); err != nil {
+		return vcs.FileContent{}, false, fmt.Errorf("get contents: %w", err)
+	}
+
+	var cs contents
+	if err := json.Unmarshal(r.Bytes(), &cs); err != nil {
+		return vcs.FileContent{}, false, fmt.Errorf("unmarshal contents: %w", err)
+	}
+	// GitHub wraps the encoded content at 60 characters
+	content, err := base64.StdEncoding.DecodeString(strings.ReplaceAll(cs.Content, "\n", ""))
+	if err != nil {
+		return vcs.FileContent{}, false, fmt.Errorf("decode contents: %w", err)
+	}
+	return vcs.FileContent{Content: content, SHA: cs.SHA}, true, nil
+}
+
+// PutFile GitHub creates and updates files with the same request, sha tells them apart
+func (c *Client) PutFile(
+	ctx context.Context,
+	owner string,
+	repo string,
+	branch string,
+	path string,
+	message string,
+	content []byte,
+	sha string,
+) error {
+	body := map[string]any{
+		"branch":  branch,
+		"message": message,
+		"content": base64.StdEncoding.EncodeToString(content),
+	}
+	if sha != "" {
+		body["sha"] = sha
+	}
+	r, err := c.newRequest(ctx).
+		SetBody(body).
+		SetPathParams(map[string]string{"owner": owner, "repo": repo}).
+		SetRawPathParam("path", path).
+		Put(c.baseUrl + "/repos/{owner}/{repo}/contents/{path}")
+	if err := checkResponse(r, err); err != nil {
+		return fmt.Errorf("put file: %w", err)
+	}
+	return nil
+}
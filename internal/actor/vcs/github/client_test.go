@@ -0,0 +1,189 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"go-snob/internal/actor/vcs"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"resty.dev/v3"
+)
+
+func TestClientGetDiff(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/repos/octo/hello/pulls/7" {
+			t.Errorf("unexpected path: %s", r.URL.Path)
+		}
+		if got := r.Header.Get("Accept"); got != "application/vnd.github.diff" {
+			t.Errorf("unexpected accept header: %s", got)
+		}
+		if got := r.Header.Get("Authorization"); got != "Bearer secret" {
+			t.Errorf("unexpected authorization header: %s", got)
+		}
+		_, _ = io.WriteString(w, "diff --git a/a.go b/a.go\n")
+	}))
+	defer srv.Close()
+
+	diff, err := NewClient(resty.New(), srv.URL, "secret").GetDiff(context.Background(), "octo", "hello", 7)
+	if err != nil {
+		t.Fatalf("GetDiff: %v", err)
+	}
+	if diff != "diff --git a/a.go b/a.go\n" {
+		t.Errorf("unexpected diff: %q", diff)
+	}
+}
+
+func TestClientCreateReview(t *testing.T) {
+	var got struct {
+		Body     string           `json:"body"`
+		Event    string           `json:"event"`
+		CommitID string           `json:"commit_id"`
+		Comments []map[string]any `json:"comments"`
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/repos/octo/hello/pulls/7/reviews" {
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+		}
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Errorf("decode body: %v", err)
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	err := NewClient(resty.New(), srv.URL, "secret").CreateReview(context.Background(), "octo", "hello", 7, vcs.Review{
+		CommitSHA: "abc",
+		Verdict:   vcs.VerdictApproved,
+		Body:      "lgtm",
+		Comments: []vcs.ReviewComment{
+			{Path: "a.go", NewLine: 3, Body: "added"},
+			{Path: "b.go", OldLine: 5, Body: "removed"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("CreateReview: %v", err)
+	}
+
+	if got.Event != "APPROVE" || got.Body != "lgtm" || got.CommitID != "abc" {
+		t.Errorf("unexpected review: %+v", got)
+	}
+	if len(got.Comments) != 2 {
+		t.Fatalf("unexpected comments count: %d", len(got.Comments))
+	}
+	if got.Comments[0]["side"] != "RIGHT" || got.Comments[0]["line"] != float64(3) {
+		t.Errorf("unexpected added line comment: %v", got.Comments[0])
+	}
+	if got.Comments[1]["side"] != "LEFT" || got.Comments[1]["line"] != float64(5) {
+		t.Errorf("unexpected removed line comment: %v", got.Comments[1])
+	}
+}
+
+func TestClientUnexpectedStatus(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+	}))
+	defer srv.Close()
+
+	err := NewClient(resty.New(), srv.URL, "secret").CreateComment(context.Background(), "octo", "hello", 7, "hi")
+	if err == nil {
+		t.Fatal("expected error on 422")
+	}
+}
+
+func TestClientChangelogFile(t *testing.T) {
+	var (
+		created map[string]any
+		put     map[string]any
+	)
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /repos/octo/hello/git/ref/heads/snob/changelog", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+	mux.HandleFunc("GET /repos/octo/hello/git/ref/heads/main", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, `{"object":{"sha":"base"}}`)
+	})
+	mux.HandleFunc("POST /repos/octo/hello/git/refs", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&created)
+		w.WriteHeader(http.StatusCreated)
+	})
+	mux.HandleFunc("GET /repos/octo/hello/contents/docs/CHANGELOG.md", func(w http.ResponseWriter, r *http.Request) {
+		// content is wrapped like GitHub does
+		_, _ = io.WriteString(w, `{"sha":"blob","content":"IyBDaGFu\nZ2Vsb2cK\n"}`)
+	})
+	mux.HandleFunc("PUT /repos/octo/hello/contents/docs/CHANGELOG.md", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&put)
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	ctx := context.Background()
+	c := NewClient(resty.New(), srv.URL, "secret")
+	if err := c.EnsureBranch(ctx, "octo", "hello", "snob/changelog", "main"); err != nil {
+		t.Fatalf("EnsureBranch: %v", err)
+	}
+	if created["ref"] != "refs/heads/snob/changelog" || created["sha"] != "base" {
+		t.Errorf("unexpected ref: %v", created)
+	}
+
+	file, ok, err := c.GetFileContent(ctx, "octo", "hello", "snob/changelog", "docs/CHANGELOG.md")
+	if err != nil || !ok {
+		t.Fatalf("GetFileContent: %v %v", ok, err)
+	}
+	if string(file.Content) != "# Changelog\n" || file.SHA != "blob" {
+		t.Errorf("unexpected file: %q %s", file.Content, file.SHA)
+	}
+
+	err = c.PutFile(ctx, "octo", "hello", "snob/changelog", "docs/CHANGELOG.md", "docs: add #1", []byte("x"), file.SHA)
+	if err != nil {
+		t.Fatalf("PutFile: %v", err)
+	}
+	if put["sha"] != "blob" || put["branch"] != "snob/changelog" || put["content"] != "eA==" {
+		t.Errorf("unexpected put: %v", put)
+	}
+}
+
+func TestClientCommitReader(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/repos/octo/hello/commits/abc" && r.Header.Get("Accept") == "application/vnd.github.diff":
+			_, _ = io.WriteString(w, "diff --git a/a.go b/a.go\n")
+		case r.URL.Path == "/repos/octo/hello/commits/abc":
+			_, _ = io.WriteString(w, `{"sha": "abc", "commit": {"message": "fix: a", "author": {"name": "Dev"}},`+
+				` "author": null, "parents": [{"sha": "p1"}]}`)
+		case r.URL.Path == "/repos/octo/hello/commits/abc/pulls":
+			_, _ = io.WriteString(w, `[{"number": 3, "merged_at": null}, {"number": 5, "merged_at": "2026-01-01T00:00:00Z"}]`)
+		default:
+			t.Errorf("unexpected request: %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+	c := NewClient(resty.New(), srv.URL, "secret")
+
+	commit, err := c.GetCommit(context.Background(), "octo", "hello", "abc")
+	if err != nil {
+		t.Fatalf("GetCommit: %v", err)
+	}
+	if commit.Message != "fix: a" || commit.Author != "Dev" || len(commit.Parents) != 1 || commit.Parents[0] != "p1" {
+		t.Errorf("unexpected commit: %+v", commit)
+	}
+
+	diff, err := c.GetCommitDiff(context.Background(), "octo", "hello", "abc")
+	if err != nil {
+		t.Fatalf("GetCommitDiff: %v", err)
+	}
+	if diff != "diff --git a/a.go b/a.go\n" {
+		t.Errorf("unexpected diff: %q", diff)
+	}
+
+	pr, ok, err := c.CommitPullRequest(context.Background(), "octo", "hello", "abc")
+	if err != nil {
+		t.Fatalf("CommitPullRequest: %v", err)
+	}
+	if !ok || pr != 5 {
+		t.Errorf("pull request = %d, %v, want the merged 5", pr, ok)
+	}
+}
@@ -0,0 +1,116 @@
+package github
+
+import (
+	"go-snob/internal/actor/vcs"
+	"go-snob/pkg/githubwebhook"
+	"strings"
+)
+
+var actions = map[githubwebhook.Action]vcs.Action{
+	githubwebhook.ActionOpened:               vcs.ActionOpened,
+	githubwebhook.ActionEdited:               vcs.ActionEdited,
+	githubwebhook.ActionSynchronize:          vcs.ActionSynchronized,
+	githubwebhook.ActionReadyForReview:       vcs.ActionReadyForReview,
+	githubwebhook.ActionClosed:               vcs.ActionClosed,
+	githubwebhook.ActionReviewRequested:      vcs.ActionReviewRequested,
+	githubwebhook.ActionReviewRequestRemoved: vcs.ActionReviewRequestRemoved,
+}
+
+func NewEvent(d githubwebhook.Delivery) vcs.Event {
+	p := d.Payload
+	var reviewer string
+	switch {
+	case p.RequestedReviewer != nil:
+		reviewer = p.RequestedReviewer.Login
+	case p.RequestedTeam != nil:
+		reviewer = p.RequestedTeam.Slug
+	}
+
+	var previousTitle string
+	if p.Changes != nil && p.Changes.Title != nil {
+		previousTitle = p.Changes.Title.From
+	}
+
+	return vcs.Event{
+		Forge:      vcs.ForgeGitHub,
+		DeliveryID: d.ID,
+		ReceivedAt: d.ReceivedAt,
+		Action:     actions[p.Action],
+		Repository: vcs.Repository{
+			Owner:   p.Repository.Owner.Login,
+			Name:    p.Repository.Name,
+			HTMLURL: p.Repository.HTMLURL,
+		},
+		PullRequest: vcs.PullRequest{
+			Number:      p.PullRequest.Number,
+			HTMLURL:     p.PullRequest.HTMLURL,
+			Title:       p.PullRequest.Title,
+			Description: p.PullRequest.Body,
+			Author:      p.PullRequest.User.Login,
+			Labels:      labels(p.PullRequest.Labels),
+			HeadRef:     p.PullRequest.Head.Ref,
+			HeadSHA:     p.PullRequest.Head.SHA,
+			BaseRef:     p.PullRequest.Base.Ref,
+			BaseSHA:     p.PullRequest.Base.SHA,
+			Draft:       p.PullRequest.Draft,
+			Merged:      p.PullRequest.Merged,
+
+			RequestedReviewers: reviewers(p.PullRequest),
+		},
+		RequestedReviewer: reviewer,
+		PreviousTitle:     previousTitle,
+	}
+}
+
+// NewPushEvent converts push deliveries to branches, false is returned for pull request deliveries and tag pushes
+func NewPushEvent(d githubwebhook.Delivery) (vcs.PushEvent, bool) {
+	p := d.Payload
+	branch, ok := strings.CutPrefix(p.Ref, "refs/heads/")
+	if !ok {
+		return vcs.PushEvent{}, false
+	}
+
+	commits := make([]vcs.Commit, 0, len(p.Commits))
+	for _, c := range p.Commits {
+		author := c.Author.Username
+		if author == "" {
+			author = c.Author.Name
+		}
+		commits = append(commits, vcs.Commit{SHA: c.ID, Message: c.Message, Author: author})
+	}
+
+	return vcs.PushEvent{
+		Forge:      vcs.ForgeGitHub,
+		DeliveryID: d.ID,
+		ReceivedAt: d.ReceivedAt,
+		Repository: vcs.Repository{
+			Owner:   p.Repository.Owner.Login,
+			Name:    p.Repository.Name,
+			HTMLURL: p.Repository.HTMLURL,
+		},
+		Branch:  branch,
+		Before:  p.Before,
+		After:   p.After,
+		Pusher:  p.Pusher.Name,
+		Commits: commits,
+	}, true
+}
+
+func labels(ls []githubwebhook.Label) []string {
+	res := make([]string, 0, len(ls))
+	for _, l := range ls {
+		res = append(res, l.Name)
+	}
+	return res
+}
+
+func reviewers(pr githubwebhook.PullRequest) []string {
+	res := make([]string, 0, len(pr.RequestedReviewers)+len(pr.RequestedTeams))
+	for _, u := range pr.RequestedReviewers {
+		res = append(res, u.Login)
+	}
+	for _, t := range pr.RequestedTeams {
+		res = append(res, t.Slug)
+	}
+	return res
+}
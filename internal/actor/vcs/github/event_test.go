@@ -0,0 +1,50 @@
+package github
+
+import (
+	"go-snob/pkg/githubwebhook"
+	"testing"
+	"time"
+)
+
+func TestNewEventKeepsReceivedAt(t *testing.T) {
+	receivedAt := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	e := NewEvent(githubwebhook.Delivery{
+		ID:         "d-1",
+		ReceivedAt: receivedAt,
+		Payload:    githubwebhook.Payload{Action: githubwebhook.ActionSynchronize},
+	})
+	if !e.ReceivedAt.Equal(receivedAt) || e.DeliveryID != "d-1" {
+		t.Errorf("event received at %v with delivery %q, want %v and d-1", e.ReceivedAt, e.DeliveryID, receivedAt)
+	}
+}
+
+func TestNewPushEvent(t *testing.T) {
+	receivedAt := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	e, ok := NewPushEvent(githubwebhook.Delivery{ReceivedAt: receivedAt, Payload: githubwebhook.Payload{
+		Ref:        "refs/heads/main",
+		After:      "abc",
+		Repository: githubwebhook.Repository{Name: "hello", Owner: githubwebhook.User{Login: "octo"}},
+		Pusher:     githubwebhook.CommitUser{Name: "dev"},
+		Commits: []githubwebhook.Commit{
+			{ID: "abc", Message: "fix: a", Author: githubwebhook.CommitUser{Name: "Dev", Username: "dev"}},
+		},
+	}})
+	if !ok {
+		t.Fatal("push to a branch wasn't converted")
+	}
+	if e.Branch != "main" || e.Repository.Owner != "octo" || len(e.Commits) != 1 || e.Commits[0].Author != "dev" {
+		t.Errorf("unexpected event: %+v", e)
+	}
+	if !e.ReceivedAt.Equal(receivedAt) {
+		t.Errorf("received at %v, want %v", e.ReceivedAt, receivedAt)
+	}
+
+	tag := githubwebhook.Delivery{Payload: githubwebhook.Payload{Ref: "refs/tags/v1.0.0"}}
+	if _, ok := NewPushEvent(tag); ok {
+		t.Error("tag push was converted")
+	}
+	pr := githubwebhook.Delivery{Payload: githubwebhook.Payload{Action: githubwebhook.ActionOpened}}
+	if _, ok := NewPushEvent(pr); ok {
+		t.Error("pull request delivery was converted")
+	}
+}
@@ -0,0 +1,541 @@
+package gitlab
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"go-snob/internal/actor/vcs"
+	"go-snob/internal/diff"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"resty.dev/v3"
+)
+
+const (
+	baseTimeout = 10 * time.Second
+
+	DefaultBaseURL = "https://gitlab.com/api/v4"
+)
+
+var (
+	_ vcs.Client            = (*Client)(nil)
+	_ vcs.MetadataProvider  = (*Client)(nil)
+	_ vcs.PullRequestEditor = (*Client)(nil)
+	_ vcs.CommentEditor     = (*Client)(nil)
+	_ vcs.FileWriter        = (*Client)(nil)
+)
+
+var statusStates = map[vcs.StatusState]string{
+	vcs.StatusPending: "pending",
+	vcs.StatusSuccess: "success",
+	vcs.StatusFailure: "failed",
+	vcs.StatusError:   "failed",
+}
+
+type Client struct {
+	baseUrl string
+	token   string
+	client  *resty.Client
+}
+
+func NewClient(client *resty.Client, baseUrl string, token string) *Client {
+	return &Client{token: token, baseUrl: baseUrl, client: client}
+}
+
+func (c *Client) newRequest(ctx context.Context) *resty.Request {
+	return c.client.R().SetContext(ctx).SetTimeout(baseTimeout).
+		SetHeader("PRIVATE-TOKEN", c.token).
+		SetHeaders(
+			map[string]string{
+				"Content-Type": "application/json",
+				"Accept":       "application/json",
+			},
+		)
+}
+
+func checkResponse(r *resty.Response, err error) error {
+	if err != nil {
+		return err
+	}
+	if !r.IsSuccess() {
+		return fmt.Errorf("unexpected status code: %v", r.StatusCode())
+	}
+	return nil
+}
+
+// projectParams GitLab addresses projects by their url-encoded "namespace/path"
+func projectParams(owner string, repo string, iid int) map[string]string {
+	return map[string]string{"id": owner + "/" + repo, "iid": strconv.Itoa(iid)}
+}
+
+type DiffRefs struct {
+	BaseSHA  string `json:"base_sha"`
+	HeadSHA  string `json:"head_sha"`
+	StartSHA string `json:"start_sha"`
+}
+
+type mergeRequest struct {
+	DiffRefs DiffRefs `json:"diff_refs"`
+}
+
+type change struct {
+	OldPath     string `json:"old_path"`
+	NewPath     string `json:"new_path"`
+	NewFile     bool   `json:"new_file"`
+	RenamedFile bool   `json:"renamed_file"`
+	DeletedFile bool   `json:"deleted_file"`
+	Diff        string `json:"diff"`
+}
+
+type mergeRequestChanges struct {
+	Changes []change `json:"changes"`
+}
+
+func (c *Client) CreateComment(ctx context.Context, owner string, repo string, index int, body string) error {
+	_, err := c.postComment(ctx, owner, repo, index, body)
+	return err
+}
+
+type note struct {
+	ID int64 `json:"id"`
+}
+
+func (c *Client) CreateCommentWithID(ctx context.Context, owner string, repo string, index int, body string) (int64, error) {
+	r, err := c.postComment(ctx, owner, repo, index, body)
+	if err != nil {
+		return 0, err
+	}
+
+	var n note
+	if err := json.Unmarshal(r.Bytes(), &n); err != nil {
+		return 0, fmt.Errorf("unmarshal note: %w", err)
+	}
+	return n.ID, nil
+}
+
+func (c *Client) postComment(ctx context.Context, owner string, repo string, index int, body string) (*resty.Response, error) {
+	r, err := c.newRequest(ctx).
+		SetBody(map[string]any{"body": body}).
+		SetPathParams(projectParams(owner, repo, index)).
+		Post(c.baseUrl + "/projects/{id}/merge_requests/{iid}/notes")
+	if err := checkResponse(r, err); err != nil {
+		return nil, fmt.Errorf("create note: %w", err)
+	}
+	return r, nil
+}
+
+func (c *Client) EditComment(ctx context.Context, owner string, repo string, index int, id int64, body string) error {
+	r, err := c.newRequest(ctx).
+		SetBody(map[string]any{"body": body}).
+		SetPathParams(projectParams(owner, repo, index)).
+		SetPathParam("note_id", strconv.FormatInt(id, 10)).
+		Put(c.baseUrl + "/projects/{id}/merge_requests/{iid}/notes/{note_id}")
+	if err := checkResponse(r, err); err != nil {
+		return fmt.Errorf("edit note: %w", err)
+	}
+	return nil
+}
+
+func (c *Client) DeleteComment(ctx context.Context, owner string, repo string, index int, id int64) error {
+	r, err := c.newRequest(ctx).
+		SetPathParams(projectParams(owner, repo, index)).
+		SetPathParam("note_id", strconv.FormatInt(id, 10)).
+		Delete(c.baseUrl + "/projects/{id}/merge_requests/{iid}/notes/{note_id}")
+	if err := checkResponse(r, err); err != nil {
+		return fmt.Errorf("delete note: %w", err)
+	}
+	return nil
+}
+
+func (c *Client) getDiffRefs(ctx context.Context, owner string, repo string, index int) (DiffRefs, error) {
+	r, err := c.newRequest(ctx).
+		SetPathParams(projectParams(owner, repo, index)).
+		Get(c.baseUrl + "/projects/{id}/merge_requests/{iid}")
+	if err := checkResponse(r, err); err != nil {
+		return DiffRefs{}, fmt.Errorf("get merge request: %w", err)
+	}
+
+	var mr mergeRequest
+	if err := json.Unmarshal(r.Bytes(), &mr); err != nil {
+		return DiffRefs{}, fmt.Errorf("unmarshal merge request: %w", err)
+	}
+	return mr.DiffRefs, nil
+}
+
+func (c *Client) createDiscussion(
+	ctx context.Context,
+	owner string,
+	repo string,
+	index int,
+	refs DiffRefs,
+	files []diff.File,
+	com vcs.ReviewComment,
+) error {
+	p := resolvePosition(files, com)
+	position := map[string]any{
+		"position_type": "text",
+		"base_sha":      refs.BaseSHA,
+		"head_sha":      refs.HeadSHA,
+		"start_sha":     refs.StartSHA,
+		"old_path":      p.oldPath,
+		"new_path":      p.newPath,
+	}
+	if p.newLine > 0 {
+		position["new_line"] = p.newLine
+	}
+	if p.oldLine > 0 {
+		position["old_line"] = p.oldLine
+	}
+
+	r, err := c.newRequest(ctx).
+		SetBody(map[string]any{"body": com.Body, "position": position}).
+		SetPathParams(projectParams(owner, repo, index)).
+		Post(c.baseUrl + "/projects/{id}/merge_requests/{iid}/discussions")
+	if err := checkResponse(r, err); err != nil {
+		return fmt.Errorf("create discussion: %w", err)
+	}
+	return nil
+}
+
+// linePosition paths and line numbers of a commented line on both sides of the change
+type linePosition struct {
+	oldPath string
+	newPath string
+	oldLine int
+	newLine int
+}
+
+// resolvePosition GitLab needs both paths of renamed files and both line numbers of context lines,
+// comments carry the path after the change and one line only
+func resolvePosition(files []diff.File, com vcs.ReviewComment) linePosition {
+	p := linePosition{oldPath: com.Path, newPath: com.Path, oldLine: com.OldLine, newLine: com.NewLine}
+	for _, f := range files {
+		if f.Path() != com.Path {
+			continue
+		}
+		if f.OldPath != "" {
+			p.oldPath = f.OldPath
+		}
+		if f.NewPath != "" {
+			p.newPath = f.NewPath
+		}
+		for _, h := range f.Hunks {
+			for _, l := range h.Lines {
+				matched := com.NewLine > 0 && l.NewLine == com.NewLine ||
+					com.NewLine == 0 && com.OldLine > 0 && l.OldLine == com.OldLine
+				if matched {
+					p.oldLine, p.newLine = l.OldLine, l.NewLine
+					return p
+				}
+			}
+		}
+		break
+	}
+	return p
+}
+
+// setApproval GitLab has no review verdicts, so APPROVED approves the MR and REQUEST_CHANGES revokes
+// a previous approval of the bot. Unapprove answers 404 if there was nothing to revoke.
+func (c *Client) setApproval(ctx context.Context, owner string, repo string, index int, verdict vcs.Verdict, sha string) error {
+	switch verdict {
+	case vcs.VerdictApproved:
+		r, err := c.newRequest(ctx).
+			SetBody(map[string]any{"sha": sha}).
+			SetPathParams(projectParams(owner, repo, index)).
+			Post(c.baseUrl + "/projects/{id}/merge_requests/{iid}/approve")
+		if err := checkResponse(r, err); err != nil {
+			return fmt.Errorf("approve: %w", err)
+		}
+	case vcs.VerdictRequestChanges:
+		r, err := c.newRequest(ctx).
+			SetPathParams(projectParams(owner, repo, index)).
+			Post(c.baseUrl + "/projects/{id}/merge_requests/{iid}/unapprove")
+		if err == nil && r.StatusCode() == http.StatusNotFound {
+			return nil
+		}
+		if err := checkResponse(r, err); err != nil {
+			return fmt.Errorf("unapprove: %w", err)
+		}
+	}
+	return nil
+}
+
+func (c *Client) CreateReview(ctx context.Context, owner string, repo string, index int, review vcs.Review) error {
+	refs, err := c.getDiffRefs(ctx, owner, repo, index)
+	if err != nil {
+		return fmt.Errorf("create review: %w", err)
+	}
+
+	var files []diff.File
+	if len(review.Comments) > 0 {
+		raw, err := c.GetDiff(ctx, owner, repo, index)
+		if err != nil {
+			return fmt.Errorf("create review: %w", err)
+		}
+		files = diff.Parse(raw)
+	}
+
+	for _, com := range review.Comments {
+		if err := c.createDiscussion(ctx, owner, repo, index, refs, files, com); err != nil {
+			return fmt.Errorf("create review: %w", err)
+		}
+	}
+
+	if review.Body != "" {
+		if err := c.CreateComment(ctx, owner, repo, index, review.Body); err != nil {
+			return fmt.Errorf("create review: %w", err)
+		}
+	}
+
+	sha := review.CommitSHA
+	if sha == "" {
+		sha = refs.HeadSHA
+	}
+	if err := c.setApproval(ctx, owner, repo, index, review.Verdict, sha); err != nil {
+		return fmt.Errorf("create review: %w", err)
+	}
+	return nil
+}
+
+func (c *Client) CreateStatus(ctx context.Context, owner string, repo string, sha string, status vcs.Status) error {
+	r, err := c.newRequest(ctx).
+		SetBody(
+			map[string]any{
+				"state":       statusStates[status.State],
+				"name":        status.Context,
+				"description": status.Description,
+				"target_url":  status.TargetURL,
+			},
+		).
+		SetPathParams(map[string]string{"id": owner + "/" + repo, "sha": sha}).
+		Post(c.baseUrl + "/projects/{id}/statuses/{sha}")
+	if err := checkResponse(r, err); err != nil {
+		return fmt.Errorf("create status: %w", err)
+	}
+	return nil
+}
+
+// GetDiff GitLab returns per file hunks without headers, so a git style diff is assembled from them
+func (c *Client) GetDiff(ctx context.Context, owner string, repo string, index int) (string, error) {
+	r, err := c.newRequest(ctx).
+		SetPathParams(projectParams(owner, repo, index)).
+		Get(c.baseUrl + "/projects/{id}/merge_requests/{iid}/changes")
+	if err := checkResponse(r, err); err != nil {
+		return "", fmt.Errorf("get diff: %w", err)
+	}
+
+	var changes mergeRequestChanges
+	if err := json.Unmarshal(r.Bytes(), &changes); err != nil {
+		return "", fmt.Errorf("unmarshal changes: %w", err)
+	}
+
+	var b strings.Builder
+	for _, ch := range changes.Changes {
+		oldPath, newPath := "a/"+ch.OldPath, "b/"+ch.NewPath
+		fmt.Fprintf(&b, "diff --git %s %s\n", oldPath, newPath)
+		switch {
+		case ch.NewFile:
+			oldPath = "/dev/null"
+			b.WriteString("new file mode 100644\n")
+		case ch.DeletedFile:
+			newPath = "/dev/null"
+			b.WriteString("deleted file mode 100644\n")
+		case ch.RenamedFile:
+			fmt.Fprintf(&b, "rename from %s\nrename to %s\n", ch.OldPath, ch.NewPath)
+		}
+		fmt.Fprintf(&b, "--- %s\n+++ %s\n", oldPath, newPath)
+		b.WriteString(ch.Diff)
+		if !strings.HasSuffix(ch.Diff, "\n") {
+			b.WriteString("\n")
+		}
+	}
+	return b.String(), nil
+}
+
+func (c *Client) GetFile(ctx context.Context, owner string, repo string, ref string, path string) ([]byte, error) {
+	r, err := c.newRequest(ctx).
+		SetPathParams(map[string]string{"id": owner + "/" + repo, "path": path}).
+		SetQueryParam("ref", ref).
+		Get(c.baseUrl + "/projects/{id}/repository/files/{path}/raw")
+	if err := checkResponse(r, err); err != nil {
+		return nil, fmt.Errorf("get file: %w", err)
+	}
+	return r.Bytes(), nil
+}
+
+type commit struct {
+	ID      string `json:"id"`
+	Message string `json:"message"`
+}
+
+func (c *Client) ListCommits(ctx context.Context, owner string, repo string, index int) ([]vcs.Commit, error) {
+	r, err := c.newRequest(ctx).
+		SetPathParams(projectParams(owner, repo, index)).
+		Get(c.baseUrl + "/projects/{id}/merge_requests/{iid}/commits")
+	if err := checkResponse(r, err); err != nil {
+		return nil, fmt.Errorf("list commits: %w", err)
+	}
+
+	var commits []commit
+	if err := json.Unmarshal(r.Bytes(), &commits); err != nil {
+		return nil, fmt.Errorf("unmarshal commits: %w", err)
+	}
+
+	res := make([]vcs.Commit, 0, len(commits))
+	for _, cm := range commits {
+		res = append(res, vcs.Commit{SHA: cm.ID, Message: cm.Message})
+	}
+	return res, nil
+}
+
+// GetLanguages GitLab reports percentages instead of bytes, they are scaled to keep the ordering
+func (c *Client) GetLanguages(ctx context.Context, owner string, repo string) (map[string]int64, error) {
+	r, err := c.newRequest(ctx).
+		SetPathParam("id", owner+"/"+repo).
+		Get(c.baseUrl + "/projects/{id}/languages")
+	if err := checkResponse(r, err); err != nil {
+		return nil, fmt.Errorf("get languages: %w", err)
+	}
+
+	var percents map[string]float64
+	if err := json.Unmarshal(r.Bytes(), &percents); err != nil {
+		return nil, fmt.Errorf("unmarshal languages: %w", err)
+	}
+
+	langs := make(map[string]int64, len(percents))
+	for l, p := range percents {
+		langs[l] = int64(p * 100)
+	}
+	return langs, nil
+}
+
+type user struct {
+	Login string `json:"username"`
+}
+
+func (c *Client) CurrentUser(ctx context.Context) (vcs.User, error) {
+	r, err := c.newRequest(ctx).Get(c.baseUrl + "/user")
+	if err := checkResponse(r, err); err != nil {
+		return vcs.User{}, fmt.Errorf("get current user: %w", err)
+	}
+
+	var u user
+	if err := json.Unmarshal(r.Bytes(), &u); err != nil {
+		return vcs.User{}, fmt.Errorf("unmarshal user: %w", err)
+	}
+	if u.Login == "" {
+		return vcs.User{}, fmt.Errorf("get current user: empty login")
+	}
+	return vcs.User{Login: u.Login}, nil
+}
+
+func (c *Client) EditPullRequest(ctx context.Context, owner string, repo string, index int, edit vcs.PullRequestEdit) error {
+	body := map[string]any{}
+	if edit.Title != nil {
+		body["title"] = *edit.Title
+	}
+	if edit.Body != nil {
+		body["description"] = *edit.Body
+	}
+
+	r, err := c.newRequest(ctx).
+		SetBody(body).
+		SetPathParams(projectParams(owner, repo, index)).
+		Put(c.baseUrl + "/projects/{id}/merge_requests/{iid}")
+	if err := checkResponse(r, err); err != nil {
+		return fmt.Errorf("edit merge request: %w", err)
+	}
+	return nil
+}
+
+func (c *Client) EnsureBranch(ctx context.Context, owner string, repo string, branch string, base string) error {
+	r, err := c.newRequest(ctx).
+		SetPathParams(map[string]string{"id": owner + "/" + repo, "branch": branch}).
+		Get(c.baseUrl + "/projects/{id}/repository/branches/{branch}")
+	if err != nil {
+		return fmt.Errorf("get branch: %w", err)
+	}
+	if r.IsSuccess() {
+		return nil
+	}
+	if r.StatusCode() != http.StatusNotFound {
+		return fmt.Errorf("get branch: unexpected status code: %v", r.StatusCode())
+	}
+
+	r, err = c.newRequest(ctx).
+		SetBody(map[string]any{"branch": branch, "ref": base}).
+		SetPathParams(map[string]string{"id": owner + "/" + repo}).
+		Post(c.baseUrl + "/projects/{id}/repository/branches")
+	if err := checkResponse(r, err); err != nil {
+		return fmt.Errorf("create branch: %w", err)
+	}
+	return nil
+}
+
+type file struct {
+	Content      string `json:"content"`
+	LastCommitID string `json:"last_commit_id"`
+}
+
+// GetFileContent the SHA of the content is the last commit of the file, GitLab checks it on update
+func (c *Client) GetFileContent(ctx context.Context, owner string, repo string, ref string, path string) (vcs.FileContent, bool, error) {
+	r, err := c.newRequest(ctx).
+		SetPathParams(map[string]string{"id": owner + "/" + repo, "path": path}).
+		SetQueryParam("ref", ref).
+		Get(c.baseUrl + "/projects/{id}/repository/files/{path}")
+	if err == nil && r.StatusCode() == http.StatusNotFound {
+		return vcs.FileContent{}, false, nil
+	}
+	if err := checkResponse(r, err); err != nil {
+		return vcs.FileContent{}, false, fmt.Errorf("get file: %w", err)
+	}
+
+	var f file
+	if err := json.Unmarshal(r.Bytes(), &f); err != nil {
+		return vcs.FileContent{}, false, fmt.Errorf("unmarshal file: %w", err)
+	}
+	content, err := base64.StdEncoding.DecodeString(f.Content)
+	if err != nil {
+		return vcs.FileContent{}, false, fmt.Errorf("decode file: %w", err)
+	}
+	return vcs.FileContent{Content: content, SHA: f.LastCommitID}, true, nil
+}
+
+func (c *Client) PutFile(
+	ctx context.Context,
+	owner string,
+	repo string,
+	branch string,
+	path string,
+	message string,
+	content []byte,
+	sha string,
+) error {
+	body := map[string]any{
+		"branch":         branch,
+		"commit_message": message,
+		"encoding":       "base64",
+		"content":        base64.StdEncoding.EncodeToString(content),
+	}
+	req := c.newRequest(ctx).
+		SetPathParams(map[string]string{"id": owner + "/" + repo, "path": path})
+
+	var (
+		r   *resty.Response
+		err error
+	)
+	if sha == "" {
+		r, err = req.SetBody(body).Post(c.baseUrl + "/projects/{id}/repository/files/{path}")
+	} else {
+		body["last_commit_id"] = sha
+		r, err = req.SetBody(body).Put(c.baseUrl + "/projects/{id}/repository/files/{path}")
+	}
+	if err := checkResponse(r, err); err != nil {
+		return fmt.Errorf("put file: %w", err)
+	}
+	return nil
+}
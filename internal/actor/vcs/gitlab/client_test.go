@@ -0,0 +1,153 @@
+package gitlab
+
+import (
+	"context"
+	"encoding/json"
+	"go-snob/internal/actor/vcs"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"resty.dev/v3"
+)
+
+func TestClientCreateReview(t *testing.T) {
+	var (
+		positions []map[string]any
+		notes     []string
+		approved  bool
+	)
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /projects/{id}/merge_requests/3", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.EscapedPath() != "/projects/group%2Fsub%2Fproj/merge_requests/3" {
+			t.Errorf("project id is not escaped: %s", r.URL.EscapedPath())
+		}
+		_, _ = io.WriteString(w, `{"diff_refs":{"base_sha":"base","head_sha":"head","start_sha":"start"}}`)
+	})
+	mux.HandleFunc("GET /projects/{id}/merge_requests/3/changes", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, `{"changes":[`+
+			`{"old_path":"a.go","new_path":"a.go","diff":"@@ -1,3 +1,4 @@\n x\n y\n+z\n w\n"},`+
+			`{"old_path":"old.go","new_path":"new.go","renamed_file":true,"diff":"@@ -5,2 +5,2 @@\n-a\n+b\n c\n"}]}`)
+	})
+	mux.HandleFunc("POST /projects/{id}/merge_requests/3/discussions", func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Position map[string]any `json:"position"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		positions = append(positions, body.Position)
+		w.WriteHeader(http.StatusCreated)
+	})
+	mux.HandleFunc("POST /projects/{id}/merge_requests/3/notes", func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Body string `json:"body"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		notes = append(notes, body.Body)
+		w.WriteHeader(http.StatusCreated)
+	})
+	mux.HandleFunc("POST /projects/{id}/merge_requests/3/approve", func(w http.ResponseWriter, r *http.Request) {
+		approved = true
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	err := NewClient(resty.New(), srv.URL, "secret").CreateReview(context.Background(), "group/sub", "proj", 3, vcs.Review{
+		Verdict: vcs.VerdictApproved,
+		Body:    "lgtm",
+		Comments: []vcs.ReviewComment{
+			{Path: "a.go", NewLine: 3, Body: "nit"},
+			{Path: "a.go", NewLine: 4, Body: "context"},
+			{Path: "new.go", OldLine: 5, Body: "removed"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("CreateReview: %v", err)
+	}
+
+	if len(positions) != 3 {
+		t.Fatalf("unexpected discussions count: %d", len(positions))
+	}
+	p := positions[0]
+	if p["base_sha"] != "base" || p["head_sha"] != "head" || p["start_sha"] != "start" || p["new_line"] != float64(3) {
+		t.Errorf("unexpected position: %v", p)
+	}
+	if _, ok := p["old_line"]; ok {
+		t.Errorf("old_line must be omitted for added lines: %v", p)
+	}
+	if p := positions[1]; p["old_line"] != float64(3) || p["new_line"] != float64(4) {
+		t.Errorf("context lines need both line numbers: %v", p)
+	}
+	if p := positions[2]; p["old_path"] != "old.go" || p["new_path"] != "new.go" || p["old_line"] != float64(5) {
+		t.Errorf("renamed file needs both paths: %v", p)
+	}
+	if _, ok := positions[2]["new_line"]; ok {
+		t.Errorf("new_line must be omitted for removed lines: %v", positions[2])
+	}
+	if len(notes) != 1 || notes[0] != "lgtm" {
+		t.Errorf("unexpected notes: %v", notes)
+	}
+	if !approved {
+		t.Error("merge request was not approved")
+	}
+}
+
+func TestClientGetDiff(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, `{"changes":[{"old_path":"a.go","new_path":"a.go","diff":"@@ -1 +1 @@\n-a\n+b\n"},{"old_path":"n.go","new_path":"n.go","new_file":true,"diff":"@@ -0,0 +1 @@\n+n"}]}`)
+	}))
+	defer srv.Close()
+
+	diff, err := NewClient(resty.New(), srv.URL, "secret").GetDiff(context.Background(), "group", "proj", 3)
+	if err != nil {
+		t.Fatalf("GetDiff: %v", err)
+	}
+
+	want := "diff --git a/a.go b/a.go\n--- a/a.go\n+++ b/a.go\n@@ -1 +1 @@\n-a\n+b\n" +
+		"diff --git a/n.go b/n.go\nnew file mode 100644\n--- /dev/null\n+++ b/n.go\n@@ -0,0 +1 @@\n+n\n"
+	if diff != want {
+		t.Errorf("unexpected diff:\n%s", diff)
+	}
+}
+
+func TestClientChangelogFile(t *testing.T) {
+	var put map[string]any
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /projects/{id}/repository/branches/{branch}", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.EscapedPath() != "/projects/group%2Fproj/repository/branches/snob%2Fchangelog" {
+			t.Errorf("branch is not escaped: %s", r.URL.EscapedPath())
+		}
+	})
+	mux.HandleFunc("GET /projects/{id}/repository/files/{path}", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.EscapedPath() != "/projects/group%2Fproj/repository/files/docs%2FCHANGELOG.md" {
+			t.Errorf("path is not escaped: %s", r.URL.EscapedPath())
+		}
+		_, _ = io.WriteString(w, `{"content":"IyBDaGFuZ2Vsb2cK","last_commit_id":"c1"}`)
+	})
+	mux.HandleFunc("PUT /projects/{id}/repository/files/{path}", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&put)
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	ctx := context.Background()
+	c := NewClient(resty.New(), srv.URL, "secret")
+	if err := c.EnsureBranch(ctx, "group", "proj", "snob/changelog", "main"); err != nil {
+		t.Fatalf("EnsureBranch: %v", err)
+	}
+	file, ok, err := c.GetFileContent(ctx, "group", "proj", "snob/changelog", "docs/CHANGELOG.md")
+	if err != nil || !ok {
+		t.Fatalf("GetFileContent: %v %v", ok, err)
+	}
+	if string(file.Content) != "# Changelog\n" || file.SHA != "c1" {
+		t.Errorf("unexpected file: %q %s", file.Content, file.SHA)
+	}
+
+	err = c.PutFile(ctx, "group", "proj", "snob/changelog", "docs/CHANGELOG.md", "docs: add #1", []byte("x"), file.SHA)
+	if err != nil {
+		t.Fatalf("PutFile: %v", err)
+	}
+	if put["last_commit_id"] != "c1" || put["encoding"] != "base64" || put["commit_message"] != "docs: add #1" {
+		t.Errorf("unexpected put: %v", put)
+	}
+}
@@ -0,0 +1,122 @@
+package gitlab
+
+import (
+	"go-snob/internal/actor/vcs"
+	"go-snob/pkg/gitlabwebhook"
+	"strings"
+)
+
+func NewEvent(d gitlabwebhook.Delivery) vcs.Event {
+	p := d.Payload
+	owner, name := p.Project.PathWithNamespace, p.Project.Name
+	if i := strings.LastIndex(p.Project.PathWithNamespace, "/"); i >= 0 {
+		owner, name = p.Project.PathWithNamespace[:i], p.Project.PathWithNamespace[i+1:]
+	}
+
+	var previousTitle string
+	if p.Changes.Title != nil {
+		previousTitle = p.Changes.Title.Previous
+	}
+
+	return vcs.Event{
+		Forge:      vcs.ForgeGitLab,
+		DeliveryID: d.ID,
+		ReceivedAt: d.ReceivedAt,
+		Action:     action(p),
+		Repository: vcs.Repository{
+			Owner:   owner,
+			Name:    name,
+			HTMLURL: p.Project.WebURL,
+		},
+		PullRequest: vcs.PullRequest{
+			Number:      p.ObjectAttributes.IID,
+			HTMLURL:     p.ObjectAttributes.URL,
+			Title:       p.ObjectAttributes.Title,
+			Description: p.ObjectAttributes.Description,
+			Labels:      labels(p.ObjectAttributes.Labels),
+			HeadRef:     p.ObjectAttributes.SourceBranch,
+			HeadSHA:     p.ObjectAttributes.LastCommit.ID,
+			BaseRef:     p.ObjectAttributes.TargetBranch,
+			Draft:       p.ObjectAttributes.Draft,
+			Merged:      p.ObjectAttributes.Action == gitlabwebhook.ActionMerge,
+
+			RequestedReviewers: usernames(p.Reviewers),
+		},
+		RequestedReviewer: changedReviewer(p),
+		PreviousTitle:     previousTitle,
+	}
+}
+
+// changedReviewer the first reviewer added or, if none, removed by the update
+func changedReviewer(p gitlabwebhook.Payload) string {
+	rc := p.Changes.Reviewers
+	if rc == nil {
+		return ""
+	}
+	if u, ok := missing(rc.Current, rc.Previous); ok {
+		return u
+	}
+	u, _ := missing(rc.Previous, rc.Current)
+	return u
+}
+
+// missing returns the first user of a absent in b
+func missing(a, b []gitlabwebhook.User) (string, bool) {
+	for _, ua := range a {
+		found := false
+		for _, ub := range b {
+			if ua.Username == ub.Username {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return ua.Username, true
+		}
+	}
+	return "", false
+}
+
+func usernames(us []gitlabwebhook.User) []string {
+	res := make([]string, 0, len(us))
+	for _, u := range us {
+		res = append(res, u.Username)
+	}
+	return res
+}
+
+func labels(ls []gitlabwebhook.Label) []string {
+	res := make([]string, 0, len(ls))
+	for _, l := range ls {
+		res = append(res, l.Title)
+	}
+	return res
+}
+
+// action GitLab reports reviewer, draft and title changes and new pushes as a generic "update". A reviewer
+// change wins over a push in the same update, a requested review covers the new head anyway
+func action(p gitlabwebhook.Payload) vcs.Action {
+	switch p.ObjectAttributes.Action {
+	case gitlabwebhook.ActionOpen, gitlabwebhook.ActionReopen:
+		return vcs.ActionOpened
+	case gitlabwebhook.ActionClose, gitlabwebhook.ActionMerge:
+		return vcs.ActionClosed
+	case gitlabwebhook.ActionUpdate:
+		if rc := p.Changes.Reviewers; rc != nil {
+			if _, added := missing(rc.Current, rc.Previous); added {
+				return vcs.ActionReviewRequested
+			}
+			return vcs.ActionReviewRequestRemoved
+		}
+		if p.ObjectAttributes.OldRev != "" {
+			return vcs.ActionSynchronized
+		}
+		if dc := p.Changes.Draft; dc != nil && dc.Previous && !dc.Current {
+			return vcs.ActionReadyForReview
+		}
+		if p.Changes.Title != nil {
+			return vcs.ActionEdited
+		}
+	}
+	return vcs.Action(p.ObjectAttributes.Action)
+}
@@ -0,0 +1,104 @@
+package gitlab
+
+import (
+	"go-snob/internal/actor/vcs"
+	"go-snob/pkg/gitlabwebhook"
+	"testing"
+	"time"
+)
+
+func TestNewEventKeepsReceivedAt(t *testing.T) {
+	receivedAt := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	e := NewEvent(gitlabwebhook.Delivery{
+		ID:         "d-1",
+		ReceivedAt: receivedAt,
+		Payload: gitlabwebhook.Payload{
+			Project: gitlabwebhook.Project{PathWithNamespace: "group/sub/hello"},
+		},
+	})
+	if !e.ReceivedAt.Equal(receivedAt) || e.DeliveryID != "d-1" {
+		t.Errorf("event received at %v with delivery %q, want %v and d-1", e.ReceivedAt, e.DeliveryID, receivedAt)
+	}
+	if e.Repository.Owner != "group/sub" || e.Repository.Name != "hello" {
+		t.Errorf("unexpected repository: %+v", e.Repository)
+	}
+}
+
+func TestAction(t *testing.T) {
+	snob := []gitlabwebhook.User{{Username: "snob"}}
+	alice := []gitlabwebhook.User{{Username: "alice"}}
+	both := []gitlabwebhook.User{{Username: "alice"}, {Username: "snob"}}
+	tests := []struct {
+		name       string
+		mr         gitlabwebhook.MergeRequest
+		changes    gitlabwebhook.Changes
+		want       vcs.Action
+		wantReview string
+	}{
+		{name: "open", mr: gitlabwebhook.MergeRequest{Action: gitlabwebhook.ActionOpen}, want: vcs.ActionOpened},
+		{name: "reopen", mr: gitlabwebhook.MergeRequest{Action: gitlabwebhook.ActionReopen}, want: vcs.ActionOpened},
+		{name: "close", mr: gitlabwebhook.MergeRequest{Action: gitlabwebhook.ActionClose}, want: vcs.ActionClosed},
+		{name: "merge", mr: gitlabwebhook.MergeRequest{Action: gitlabwebhook.ActionMerge}, want: vcs.ActionClosed},
+		{
+			name:       "reviewer added",
+			mr:         gitlabwebhook.MergeRequest{Action: gitlabwebhook.ActionUpdate},
+			changes:    gitlabwebhook.Changes{Reviewers: &gitlabwebhook.UsersChange{Previous: alice, Current: both}},
+			want:       vcs.ActionReviewRequested,
+			wantReview: "snob",
+		},
+		{
+			name:       "reviewer removed",
+			mr:         gitlabwebhook.MergeRequest{Action: gitlabwebhook.ActionUpdate},
+			changes:    gitlabwebhook.Changes{Reviewers: &gitlabwebhook.UsersChange{Previous: both, Current: alice}},
+			want:       vcs.ActionReviewRequestRemoved,
+			wantReview: "snob",
+		},
+		{
+			name:       "reviewer added with new commits",
+			mr:         gitlabwebhook.MergeRequest{Action: gitlabwebhook.ActionUpdate, OldRev: "abc"},
+			changes:    gitlabwebhook.Changes{Reviewers: &gitlabwebhook.UsersChange{Current: snob}},
+			want:       vcs.ActionReviewRequested,
+			wantReview: "snob",
+		},
+		{
+			name: "new commits",
+			mr:   gitlabwebhook.MergeRequest{Action: gitlabwebhook.ActionUpdate, OldRev: "abc"},
+			want: vcs.ActionSynchronized,
+		},
+		{
+			name:    "ready",
+			mr:      gitlabwebhook.MergeRequest{Action: gitlabwebhook.ActionUpdate},
+			changes: gitlabwebhook.Changes{Draft: &gitlabwebhook.BoolChange{Previous: true}},
+			want:    vcs.ActionReadyForReview,
+		},
+		{
+			name:    "back to draft",
+			mr:      gitlabwebhook.MergeRequest{Action: gitlabwebhook.ActionUpdate},
+			changes: gitlabwebhook.Changes{Draft: &gitlabwebhook.BoolChange{Current: true}},
+			want:    vcs.Action(gitlabwebhook.ActionUpdate),
+		},
+		{
+			name:    "title",
+			mr:      gitlabwebhook.MergeRequest{Action: gitlabwebhook.ActionUpdate},
+			changes: gitlabwebhook.Changes{Title: &gitlabwebhook.StringChange{Previous: "WIP: a", Current: "a"}},
+			want:    vcs.ActionEdited,
+		},
+		{
+			name: "approved",
+			mr:   gitlabwebhook.MergeRequest{Action: gitlabwebhook.ActionApproved},
+			want: vcs.Action(gitlabwebhook.ActionApproved),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := gitlabwebhook.Payload{ObjectAttributes: tt.mr, Changes: tt.changes}
+			e := NewEvent(gitlabwebhook.Delivery{Payload: p})
+			if e.Action != tt.want {
+				t.Errorf("action = %q, want %q", e.Action, tt.want)
+			}
+			if e.RequestedReviewer != tt.wantReview {
+				t.Errorf("requested reviewer = %q, want %q", e.RequestedReviewer, tt.wantReview)
+			}
+		})
+	}
+}
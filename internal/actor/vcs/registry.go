@@ -0,0 +1,117 @@
+package vcs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// Registry resolves the client for an event. A forge may be served by several instances, in which case
+// events are routed by the host of the repository html url.
+type Registry struct {
+	clients map[Forge]map[string]*account
+}
+
+// account a client with the identity the bot has on that instance
+type account struct {
+	client Client
+
+	mu   sync.Mutex
+	user User
+}
+
+func NewRegistry() *Registry {
+	return &Registry{clients: make(map[Forge]map[string]*account)}
+}
+
+// Register host may be empty to make client the fallback for the forge
+func (r *Registry) Register(forge Forge, host string, client Client) *Registry {
+	if r.clients[forge] == nil {
+		r.clients[forge] = make(map[string]*account)
+	}
+	r.clients[forge][strings.ToLower(host)] = &account{client: client}
+	return r
+}
+
+func (r *Registry) Resolve(e Event) (Client, error) {
+	return r.ResolveRepository(e.Forge, e.Repository)
+}
+
+// ResolveRepository resolves the client for events not tied to a pull request
+func (r *Registry) ResolveRepository(forge Forge, repo Repository) (Client, error) {
+	a, err := r.lookup(forge, repo)
+	if err != nil {
+		return nil, err
+	}
+	return a.client, nil
+}
+
+// Identity returns the bot user of the instance the event came from. It's fetched once and cached,
+// so an instance unavailable at startup is retried on the next event.
+func (r *Registry) Identity(ctx context.Context, e Event) (User, error) {
+	a, err := r.lookup(e.Forge, e.Repository)
+	if err != nil {
+		return User{}, err
+	}
+	return a.identity(ctx)
+}
+
+// Identify resolves identities of all registered clients
+func (r *Registry) Identify(ctx context.Context) error {
+	var errs []error
+	for forge, hosts := range r.clients {
+		for host, a := range hosts {
+			if _, err := a.identity(ctx); err != nil {
+				errs = append(errs, fmt.Errorf("%s %q: %w", forge, host, err))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (a *account) identity(ctx context.Context) (User, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.user.Login != "" {
+		return a.user, nil
+	}
+	u, err := a.client.CurrentUser(ctx)
+	if err != nil {
+		return User{}, err
+	}
+	a.user = u
+	return u, nil
+}
+
+func (r *Registry) lookup(forge Forge, repo Repository) (*account, error) {
+	hosts, ok := r.clients[forge]
+	if !ok {
+		return nil, fmt.Errorf("no client configured for forge %q", forge)
+	}
+
+	host := ""
+	if repo.HTMLURL != "" {
+		u, err := url.Parse(repo.HTMLURL)
+		if err != nil {
+			return nil, fmt.Errorf("parse repository url: %w", err)
+		}
+		host = strings.ToLower(u.Host)
+	}
+
+	if a, ok := hosts[host]; ok {
+		return a, nil
+	}
+	if a, ok := hosts[""]; ok {
+		return a, nil
+	}
+	if len(hosts) == 1 {
+		for _, a := range hosts {
+			return a, nil
+		}
+	}
+	return nil, fmt.Errorf("no %s client configured for host %q", forge, host)
+}
@@ -0,0 +1,264 @@
+package vcs
+
+import (
+	"context"
+	"time"
+)
+
+// Forge identifies the code hosting software an event came from.
+type Forge string
+
+const (
+	ForgeGitea  Forge = "gitea"
+	ForgeGitHub Forge = "github"
+	ForgeGitLab Forge = "gitlab"
+)
+
+type Action string
+
+const (
+	ActionOpened               Action = "opened"
+	ActionEdited               Action = "edited"
+	ActionSynchronized         Action = "synchronized"
+	ActionReadyForReview       Action = "ready_for_review"
+	ActionClosed               Action = "closed"
+	ActionReviewRequested      Action = "review_requested"
+	ActionReviewRequestRemoved Action = "review_request_removed"
+)
+
+type Repository struct {
+	Owner   string
+	Name    string
+	HTMLURL string
+}
+
+type PullRequest struct {
+	Number      int
+	HTMLURL     string
+	Title       string
+	Description string
+	Author      string
+	Labels      []string
+	HeadRef     string
+	HeadSHA     string
+	BaseRef     string
+	BaseSHA     string
+	Draft       bool
+	Merged      bool
+	// RequestedReviewers logins of users and teams whose review is currently requested
+	RequestedReviewers []string
+}
+
+// Event is a forge independent view of a pull request webhook.
+type Event struct {
+	Forge      Forge
+	DeliveryID string
+	// ReceivedAt time the webhook hit the server, orders events of the same pull request
+	ReceivedAt  time.Time
+	Action      Action
+	Repository  Repository
+	PullRequest PullRequest
+	// RequestedReviewer login of the user or team (review) request is about, empty if unknown
+	RequestedReviewer string
+	// PreviousTitle title before an edit, empty if it wasn't changed
+	PreviousTitle string
+}
+
+// PushEvent is a forge independent view of a branch push webhook.
+type PushEvent struct {
+	Forge      Forge
+	DeliveryID string
+	ReceivedAt time.Time
+	Repository Repository
+	Branch     string
+	Before     string
+	After      string
+	Pusher     string
+	Commits    []Commit
+}
+
+// CommentEvent a comment created on an issue or a pull request
+type CommentEvent struct {
+	Forge      Forge
+	DeliveryID string
+	ReceivedAt time.Time
+	Repository Repository
+	// Number of the issue or the pull request
+	Number    int
+	IsPull    bool
+	CommentID int64
+	Author    string
+	Body      string
+}
+
+type User struct {
+	Login string
+}
+
+type Verdict string
+
+const (
+	VerdictApproved       Verdict = "APPROVED"
+	VerdictRequestChanges Verdict = "REQUEST_CHANGES"
+	VerdictComment        Verdict = "COMMENT"
+)
+
+type ReviewComment struct {
+	Path string
+	// NewLine line number in the new file, 0 if the comment targets a removed line
+	NewLine int
+	// OldLine line number in the old file, 0 if the comment targets an added line
+	OldLine int
+	Body    string
+}
+
+type Review struct {
+	CommitSHA string
+	Verdict   Verdict
+	Body      string
+	Comments  []ReviewComment
+}
+
+type StatusState string
+
+const (
+	StatusPending StatusState = "pending"
+	StatusSuccess StatusState = "success"
+	StatusFailure StatusState = "failure"
+	StatusError   StatusState = "error"
+)
+
+type Status struct {
+	State       StatusState
+	Context     string
+	Description string
+	TargetURL   string
+}
+
+type Commit struct {
+	SHA     string
+	Message string
+	Author  string
+	// Parents are only filled by CommitReader
+	Parents []string
+}
+
+// PullRequestEdit fields left nil are not changed
+type PullRequestEdit struct {
+	Title *string
+	Body  *string
+}
+
+type Release struct {
+	ID      int64
+	TagName string
+	Name    string
+	Body    string
+	// Target branch or commit the tag is created from when the release is published
+	Target string
+	Draft  bool
+}
+
+type FileContent struct {
+	Content []byte
+	// SHA blob sha required to update the file
+	SHA string
+}
+
+// Client is implemented by every forge adapter the orchestrator can talk to.
+type Client interface {
+	CurrentUser(ctx context.Context) (User, error)
+	GetDiff(ctx context.Context, owner string, repo string, index int) (string, error)
+	CreateReview(ctx context.Context, owner string, repo string, index int, review Review) error
+	CreateComment(ctx context.Context, owner string, repo string, index int, body string) error
+	CreateStatus(ctx context.Context, owner string, repo string, sha string, status Status) error
+	GetFile(ctx context.Context, owner string, repo string, ref string, path string) ([]byte, error)
+}
+
+// MetadataProvider is implemented by clients able to give extra context for prompts
+type MetadataProvider interface {
+	ListCommits(ctx context.Context, owner string, repo string, index int) ([]Commit, error)
+	// GetLanguages returns repository languages with their size in bytes
+	GetLanguages(ctx context.Context, owner string, repo string) (map[string]int64, error)
+}
+
+// PullRequestEditor is implemented by clients able to update pull request title and description
+type PullRequestEditor interface {
+	EditPullRequest(ctx context.Context, owner string, repo string, index int, edit PullRequestEdit) error
+}
+
+// ReleaseManager is implemented by clients able to maintain draft releases
+type ReleaseManager interface {
+	// DraftRelease returns the latest draft release, false if there's none
+	DraftRelease(ctx context.Context, owner string, repo string) (Release, bool, error)
+	CreateRelease(ctx context.Context, owner string, repo string, release Release) error
+	EditReleaseBody(ctx context.Context, owner string, repo string, id int64, body string) error
+}
+
+// FileWriter is implemented by clients able to commit files to a branch
+type FileWriter interface {
+	// EnsureBranch creates branch from base unless it exists
+	EnsureBranch(ctx context.Context, owner string, repo string, branch string, base string) error
+	// GetFileContent returns false if the file doesn't exist on ref
+	GetFileContent(ctx context.Context, owner string, repo string, ref string, path string) (FileContent, bool, error)
+	// PutFile creates the file if sha is empty and updates it otherwise
+	PutFile(ctx context.Context, owner string, repo string, branch string, path string, message string, content []byte, sha string) error
+}
+
+// CommitReader is implemented by clients able to give what's needed to review a single commit
+type CommitReader interface {
+	GetCommit(ctx context.Context, owner string, repo string, sha string) (Commit, error)
+	GetCommitDiff(ctx context.Context, owner string, repo string, sha string) (string, error)
+	// CommitPullRequest returns the merged pull request the commit came with, false if it was pushed directly
+	CommitPullRequest(ctx context.Context, owner string, repo string, sha string) (int, bool, error)
+}
+
+// CommitCommenter is implemented by clients able to comment commits. A comment without Path is a general one.
+type CommitCommenter interface {
+	CreateCommitComment(ctx context.Context, owner string, repo string, sha string, comment ReviewComment) error
+}
+
+type IssueCreator interface {
+	CreateIssue(ctx context.Context, owner string, repo string, title string, body string) error
+}
+
+// CommentEditor is implemented by clients able to maintain a comment edited in place
+type CommentEditor interface {
+	CreateCommentWithID(ctx context.Context, owner string, repo string, index int, body string) (int64, error)
+	EditComment(ctx context.Context, owner string, repo string, index int, id int64, body string) error
+	DeleteComment(ctx context.Context, owner string, repo string, index int, id int64) error
+}
+
+// PostedComment a review comment as the forge keeps it
+type PostedComment struct {
+	ID   int64
+	Path string
+	// Line new line of the comment, OldLine the removed one it's on otherwise
+	Line     int
+	OldLine  int
+	Body     string
+	Resolved bool
+	// CommitSHA commit the comment was made on
+	CommitSHA string
+}
+
+// Reaction emoji reaction, Content is "+1", "-1", "laugh" and the like
+type Reaction struct {
+	User    string
+	Content string
+}
+
+// ReviewFeedback is implemented by clients able to tell how people reacted to posted reviews
+type ReviewFeedback interface {
+	CreateReviewWithID(ctx context.Context, owner string, repo string, index int, review Review) (int64, error)
+	ListReviewComments(ctx context.Context, owner string, repo string, index int, reviewID int64) ([]PostedComment, error)
+	ListCommentReactions(ctx context.Context, owner string, repo string, commentID int64) ([]Reaction, error)
+	// PullRequestHead returns the current head commit of the pull request
+	PullRequestHead(ctx context.Context, owner string, repo string, index int) (string, error)
+}
+
+// PermissionReader is implemented by clients able to tell maintainers apart
+type PermissionReader interface {
+	// CanWrite reports whether the user may push to the repository
+	CanWrite(ctx context.Context, owner string, repo string, login string) (bool, error)
+}
@@ -0,0 +1,55 @@
+package internal
+
+import (
+	"context"
+	"go-snob/internal/capture"
+	"go-snob/internal/config"
+	"go-snob/pkg/hotreload"
+
+	"go.uber.org/zap"
+)
+
+// WithSecrets values masked in capture bundles, the credentials of the deployment itself
+func (o *Orchestrator) WithSecrets(values ...string) *Orchestrator {
+	o.secrets = append(o.secrets, values...)
+	return o
+}
+
+// startCapture puts the bundle of the job into ctx when capture is on. The returned func writes it
+// unless the job ignored the event
+func (o *Orchestrator) startCapture(
+	ctx context.Context,
+	logger *zap.Logger,
+	kind capture.Kind,
+	event any,
+	cfg *hotreload.Snapshot[config.Config],
+) (context.Context, func()) {
+	c := cfg.Value.Capture
+	if c.Dir == "" {
+		return ctx, func() {}
+	}
+	b, err := capture.New(kind, event, cfg.Version)
+	if err != nil {
+		logger.Warn("failed to start capture", zap.Error(err))
+		return ctx, func() {}
+	}
+	b.SetWebhook(capture.WebhookFromContext(ctx))
+
+	return capture.WithBundle(ctx, b), func() {
+		if b.Empty() {
+			return
+		}
+		path, err := capture.Write(b, capture.Options{
+			Dir:        c.Dir,
+			MaxBundles: c.MaxBundles,
+			MaxAge:     c.MaxAge,
+			Redact:     c.RedactPatterns(),
+			Secrets:    o.secrets,
+		})
+		if err != nil {
+			logger.Warn("failed to write capture bundle", zap.Error(err))
+			return
+		}
+		logger.Debug("job captured", zap.String("path", path))
+	}
+}
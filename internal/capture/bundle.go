@@ -0,0 +1,151 @@
+package capture
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"go-snob/internal/actor/vcs"
+	"os"
+	"sync"
+	"time"
+)
+
+// Kind of the job a bundle was captured from
+type Kind string
+
+const (
+	KindPullRequest Kind = "pull_request"
+	KindPush        Kind = "push"
+)
+
+// Bundle what a job received, sent and got back. Everything a replay needs to run the job again
+// without the forge
+type Bundle struct {
+	ID            string    `json:"id"`
+	Kind          Kind      `json:"kind"`
+	StartedAt     time.Time `json:"started_at"`
+	FinishedAt    time.Time `json:"finished_at"`
+	ConfigVersion string    `json:"config_version"`
+	// Event vcs.Event or vcs.PushEvent the job was started with
+	Event json.RawMessage `json:"event"`
+	// Webhook raw body of the delivery
+	Webhook   json.RawMessage `json:"webhook,omitempty"`
+	Diff      string          `json:"diff,omitempty"`
+	Exchanges []Exchange      `json:"exchanges,omitempty"`
+	Review    *vcs.Review     `json:"review,omitempty"`
+
+	mu sync.Mutex
+}
+
+// Exchange one request to a model with the content it answered
+type Exchange struct {
+	// Format name of the response format, tells review requests from verification and others
+	Format   string        `json:"format"`
+	Model    string        `json:"model"`
+	Messages []Message     `json:"messages"`
+	Response string        `json:"response,omitempty"`
+	Error    string        `json:"error,omitempty"`
+	Duration time.Duration `json:"duration"`
+}
+
+type Message struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type bundleKey struct{}
+
+type webhookKey struct{}
+
+// New starts a bundle of the job started by event
+func New(kind Kind, event any, configVersion string) (*Bundle, error) {
+	raw, err := json.Marshal(event)
+	if err != nil {
+		return nil, fmt.Errorf("marshal event: %w", err)
+	}
+	now := time.Now()
+	return &Bundle{
+		ID:            now.UTC().Format("20060102T150405.000000000"),
+		Kind:          kind,
+		StartedAt:     now,
+		ConfigVersion: configVersion,
+		Event:         raw,
+	}, nil
+}
+
+// Load reads a bundle written by Write
+func Load(path string) (*Bundle, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read bundle: %w", err)
+	}
+	var bundle Bundle
+	if err := json.Unmarshal(b, &bundle); err != nil {
+		return nil, fmt.Errorf("unmarshal bundle: %w", err)
+	}
+	return &bundle, nil
+}
+
+func WithBundle(ctx context.Context, b *Bundle) context.Context {
+	return context.WithValue(ctx, bundleKey{}, b)
+}
+
+// FromContext the bundle of the job, nil if nothing is captured. Methods of a nil bundle do nothing
+func FromContext(ctx context.Context) *Bundle {
+	b, _ := ctx.Value(bundleKey{}).(*Bundle)
+	return b
+}
+
+// WithWebhook keeps the raw webhook body for the bundle of the job started from it
+func WithWebhook(ctx context.Context, body []byte) context.Context {
+	return context.WithValue(ctx, webhookKey{}, body)
+}
+
+// WebhookFromContext the raw webhook body kept by WithWebhook
+func WebhookFromContext(ctx context.Context) []byte {
+	b, _ := ctx.Value(webhookKey{}).([]byte)
+	return b
+}
+
+func (b *Bundle) SetWebhook(body []byte) {
+	if b == nil || !json.Valid(body) {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.Webhook = body
+}
+
+func (b *Bundle) SetDiff(diff string) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.Diff = diff
+}
+
+func (b *Bundle) AddExchange(e Exchange) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.Exchanges = append(b.Exchanges, e)
+}
+
+func (b *Bundle) SetReview(r vcs.Review) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.Review = &r
+}
+
+// Empty the job neither fetched a diff nor asked a model, e.g. an event the bot ignored
+func (b *Bundle) Empty() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.Diff == "" && len(b.Exchanges) == 0
+}
@@ -0,0 +1,104 @@
+package capture
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"slices"
+	"sync"
+)
+
+// RecordedLLM a local chat completions endpoint answering with the responses of a bundle. A request
+// gets the response recorded for the same messages, otherwise the next unused one of its format, so a
+// changed prompt still replays
+type RecordedLLM struct {
+	listener net.Listener
+	server   *http.Server
+
+	mu        sync.Mutex
+	exchanges []Exchange
+	used      []bool
+}
+
+// NewRecordedLLM starts the endpoint on a random local port. Failed exchanges are left out
+func NewRecordedLLM(exchanges []Exchange) (*RecordedLLM, error) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, fmt.Errorf("listen: %w", err)
+	}
+	r := &RecordedLLM{listener: l}
+	for _, e := range exchanges {
+		if e.Error == "" {
+			r.exchanges = append(r.exchanges, e)
+		}
+	}
+	r.used = make([]bool, len(r.exchanges))
+	r.server = &http.Server{Handler: r}
+	go func() {
+		// returns once closed
+		_ = r.server.Serve(l)
+	}()
+	return r, nil
+}
+
+// URL chat completions url of the endpoint
+func (r *RecordedLLM) URL() string {
+	return "http://" + r.listener.Addr().String() + "/v1/chat/completions"
+}
+
+func (r *RecordedLLM) Close() error {
+	return r.server.Close()
+}
+
+func (r *RecordedLLM) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	var body struct {
+		Messages       []Message `json:"messages"`
+		ResponseFormat struct {
+			JSONSchema struct {
+				Name string `json:"name"`
+			} `json:"json_schema"`
+		} `json:"response_format"`
+	}
+	if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	content, ok := r.take(body.ResponseFormat.JSONSchema.Name, body.Messages)
+	if !ok {
+		http.Error(w, "no recorded response left", http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]any{
+		"choices": []map[string]any{{
+			"message":       map[string]string{"role": "assistant", "content": content},
+			"finish_reason": "stop",
+		}},
+	})
+}
+
+func (r *RecordedLLM) take(format string, messages []Message) (string, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	next := -1
+	for i, e := range r.exchanges {
+		if r.used[i] || e.Format != format {
+			continue
+		}
+		if slices.Equal(e.Messages, messages) {
+			next = i
+			break
+		}
+		if next == -1 {
+			next = i
+		}
+	}
+	if next == -1 {
+		return "", false
+	}
+	r.used[next] = true
+	return r.exchanges[next].Response, true
+}
@@ -0,0 +1,143 @@
+package capture
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go-snob/internal/secrets"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	redacted = "[redacted]"
+	// filePrefix tells bundles from other files of the directory, only they are rotated
+	filePrefix = "bundle-"
+	// minSecretLen shorter secrets would mask unrelated text
+	minSecretLen = 8
+)
+
+// Options where bundles go and what is kept of them
+type Options struct {
+	Dir string
+	// MaxBundles newest bundles kept, all if 0
+	MaxBundles int
+	// MaxAge bundles older than it are removed, none if 0
+	MaxAge time.Duration
+	// Redact patterns masked in addition to the credentials the secret scanner knows
+	Redact []*regexp.Regexp
+	// Secrets values masked wherever they appear, e.g. tokens of the bot itself. Values shorter than
+	// minSecretLen are ignored
+	Secrets []string
+}
+
+// Newest the path of the latest bundle written to dir, empty if there is none
+func Newest(dir string) (string, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, filePrefix+"*.json"))
+	if err != nil || len(paths) == 0 {
+		return "", err
+	}
+	slices.Sort(paths)
+	return paths[len(paths)-1], nil
+}
+
+// Write redacts b, writes it to the directory and removes bundles beyond the limits. It returns the
+// path of the bundle
+func Write(b *Bundle, opts Options) (string, error) {
+	b.mu.Lock()
+	b.FinishedAt = time.Now()
+	raw, err := json.Marshal(b)
+	b.mu.Unlock()
+	if err != nil {
+		return "", fmt.Errorf("marshal bundle: %w", err)
+	}
+
+	// strings are redacted one by one, masking the encoded document could break its escapes
+	var doc any
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		return "", fmt.Errorf("unmarshal bundle: %w", err)
+	}
+	out, err := json.MarshalIndent(redactValue(doc, opts), "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("marshal bundle: %w", err)
+	}
+
+	if err := os.MkdirAll(opts.Dir, 0o750); err != nil {
+		return "", fmt.Errorf("create capture dir: %w", err)
+	}
+	path := filepath.Join(opts.Dir, filePrefix+b.ID+".json")
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, out, 0o600); err != nil {
+		return "", fmt.Errorf("write bundle: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return "", fmt.Errorf("rename bundle: %w", err)
+	}
+
+	if err := rotate(opts); err != nil {
+		return path, fmt.Errorf("rotate bundles: %w", err)
+	}
+	return path, nil
+}
+
+func redactValue(v any, opts Options) any {
+	switch v := v.(type) {
+	case string:
+		return redactString(v, opts)
+	case []any:
+		for i := range v {
+			v[i] = redactValue(v[i], opts)
+		}
+	case map[string]any:
+		for k := range v {
+			v[k] = redactValue(v[k], opts)
+		}
+	}
+	return v
+}
+
+func redactString(s string, opts Options) string {
+	for _, secret := range opts.Secrets {
+		if len(secret) >= minSecretLen {
+			s = strings.ReplaceAll(s, secret, redacted)
+		}
+	}
+	for _, re := range opts.Redact {
+		s = re.ReplaceAllString(s, redacted)
+	}
+	return secrets.Redact(s)
+}
+
+// rotate bundle names start with the capture time, so they sort oldest first
+func rotate(opts Options) error {
+	paths, err := filepath.Glob(filepath.Join(opts.Dir, filePrefix+"*.json"))
+	if err != nil {
+		return err
+	}
+	slices.Sort(paths)
+
+	var errs []error
+	if opts.MaxAge > 0 {
+		deadline := time.Now().Add(-opts.MaxAge)
+		kept := paths[:0]
+		for _, p := range paths {
+			info, err := os.Stat(p)
+			if err == nil && info.ModTime().Before(deadline) {
+				errs = append(errs, os.Remove(p))
+				continue
+			}
+			kept = append(kept, p)
+		}
+		paths = kept
+	}
+	if opts.MaxBundles > 0 && len(paths) > opts.MaxBundles {
+		for _, p := range paths[:len(paths)-opts.MaxBundles] {
+			errs = append(errs, os.Remove(p))
+		}
+	}
+	return errors.Join(errs...)
+}
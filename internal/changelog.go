@@ -0,0 +1,158 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"go-snob/internal/actor/ai"
+	"go-snob/internal/actor/vcs"
+	"go-snob/internal/capture"
+	"go-snob/internal/changelog"
+	"go-snob/internal/config"
+	"go-snob/internal/prompt"
+	"go-snob/pkg/hotreload"
+	"strings"
+	"sync"
+
+	"go.uber.org/zap"
+)
+
+// changelog drafts a changelog entry for a merged pull request of an opted in repository
+func (o *Orchestrator) changelog(
+	ctx context.Context,
+	logger *zap.Logger,
+	e vcs.Event,
+	cfg *hotreload.Snapshot[config.Config],
+) {
+	fullName := e.Repository.Owner + "/" + e.Repository.Name
+	rc := cfg.Value.Repos[fullName].Changelog
+	if !rc.Enabled {
+		logger.Debug("skipping changelog, repository didn't opt in")
+		return
+	}
+
+	client, err := o.clients.Resolve(e)
+	if err != nil {
+		logger.Error("failed to resolve vcs client", zap.Error(err))
+		return
+	}
+	// GitLab has no draft releases, the model isn't asked for an entry that can't be written
+	if !canWriteChangelog(client, rc.Target) {
+		logger.Warn("vcs client can't write the changelog target, skipping", zap.String("target", rc.Target))
+		return
+	}
+
+	diff, err := client.GetDiff(ctx, e.Repository.Owner, e.Repository.Name, e.PullRequest.Number)
+	if err != nil {
+		logFailure(ctx, logger, "failed to get diff", err)
+		return
+	}
+	capture.FromContext(ctx).SetDiff(diff)
+
+	data := newPromptData(ctx, logger, client, e, diff, cfg.Value)
+	ctx = ai.WithTraits(ctx, aiTraits(data.PR.Files))
+	systemPrompt, userPrompt, err := prompt.RenderMessages(
+		cfg.Value.Changelog.SystemPromptTemplate(),
+		cfg.Value.Changelog.UserPromptTemplate(),
+		data,
+	)
+	if err != nil {
+		logger.Error("failed to render changelog prompts", zap.Error(err))
+		return
+	}
+
+	logger.Info("starting ai changelog request..")
+	res, err := o.aiClient.Changelog(ctx, systemPrompt, userPrompt)
+	if err != nil {
+		logFailure(ctx, logger, "failed to generate changelog entry", err)
+		return
+	}
+	category := changelog.Category(res.Category)
+	entry := fmt.Sprintf("- %s ([#%d](%s))", strings.TrimSpace(res.Entry), e.PullRequest.Number, e.PullRequest.HTMLURL)
+
+	// entries are appended with read-modify-write, concurrent merges would overwrite each other
+	key := newJobKey(e)
+	key.Number = 0
+	mu, _ := o.changelogMu.LoadOrStore(key, &sync.Mutex{})
+	mu.(*sync.Mutex).Lock()
+	defer mu.(*sync.Mutex).Unlock()
+
+	switch rc.Target {
+	case config.ChangelogTargetRelease:
+		err = appendToRelease(ctx, client, e, rc, category, entry)
+	case config.ChangelogTargetFile:
+		err = appendToFile(ctx, client, e, rc, category, entry)
+	}
+	if err != nil {
+		logFailure(ctx, logger, "failed to append changelog entry", err)
+		return
+	}
+	logger.Info("appended changelog entry", zap.String("target", rc.Target), zap.String("category", res.Category))
+}
+
+func canWriteChangelog(client vcs.Client, target string) bool {
+	switch target {
+	case config.ChangelogTargetRelease:
+		_, ok := client.(vcs.ReleaseManager)
+		return ok
+	case config.ChangelogTargetFile:
+		_, ok := client.(vcs.FileWriter)
+		return ok
+	}
+	return false
+}
+
+func appendToRelease(
+	ctx context.Context,
+	client vcs.Client,
+	e vcs.Event,
+	rc config.RepoChangelogConfig,
+	category changelog.Category,
+	entry string,
+) error {
+	rm, ok := client.(vcs.ReleaseManager)
+	if !ok {
+		return fmt.Errorf("vcs client can't manage releases")
+	}
+
+	rel, ok, err := rm.DraftRelease(ctx, e.Repository.Owner, e.Repository.Name)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return rm.CreateRelease(ctx, e.Repository.Owner, e.Repository.Name, vcs.Release{
+			TagName: rc.ReleaseTag,
+			Name:    "Unreleased",
+			Body:    changelog.Insert("", category, entry),
+			Target:  e.PullRequest.BaseRef,
+			Draft:   true,
+		})
+	}
+	return rm.EditReleaseBody(ctx, e.Repository.Owner, e.Repository.Name, rel.ID, changelog.Insert(rel.Body, category, entry))
+}
+
+func appendToFile(
+	ctx context.Context,
+	client vcs.Client,
+	e vcs.Event,
+	rc config.RepoChangelogConfig,
+	category changelog.Category,
+	entry string,
+) error {
+	fw, ok := client.(vcs.FileWriter)
+	if !ok {
+		return fmt.Errorf("vcs client can't commit files")
+	}
+	owner, repo := e.Repository.Owner, e.Repository.Name
+
+	if err := fw.EnsureBranch(ctx, owner, repo, rc.Branch, e.PullRequest.BaseRef); err != nil {
+		return err
+	}
+	file, _, err := fw.GetFileContent(ctx, owner, repo, rc.Branch, rc.Path)
+	if err != nil {
+		return err
+	}
+
+	content := changelog.InsertUnreleased(string(file.Content), category, entry)
+	message := fmt.Sprintf("docs(changelog): add #%d", e.PullRequest.Number)
+	return fw.PutFile(ctx, owner, repo, rc.Branch, rc.Path, message, []byte(content), file.SHA)
+}
@@ -0,0 +1,132 @@
+package changelog
+
+import (
+	"slices"
+	"strings"
+)
+
+type Category string
+
+const (
+	CategoryBreaking Category = "breaking"
+	CategoryFeature  Category = "feature"
+	CategoryFix      Category = "fix"
+	CategoryOther    Category = "other"
+)
+
+// Categories in the order their sections appear
+var Categories = []Category{CategoryBreaking, CategoryFeature, CategoryFix, CategoryOther}
+
+var headings = map[Category]string{
+	CategoryBreaking: "### Breaking changes",
+	CategoryFeature:  "### Features",
+	CategoryFix:      "### Fixes",
+	CategoryOther:    "### Other",
+}
+
+const unreleasedHeading = "## Unreleased"
+
+// Insert appends entry to the section of the category in doc. A missing section is created in the
+// order of Categories, unknown categories go to "Other".
+func Insert(doc string, c Category, entry string) string {
+	return join(insert(split(doc), c, entry))
+}
+
+// InsertUnreleased appends entry to the "Unreleased" section of a CHANGELOG.md, the section is created
+// above the latest release if missing.
+func InsertUnreleased(doc string, c Category, entry string) string {
+	lines := split(doc)
+
+	u := slices.IndexFunc(lines, func(l string) bool { return strings.EqualFold(strings.TrimSpace(l), unreleasedHeading) })
+	if u < 0 {
+		u = slices.IndexFunc(lines, isRelease)
+		if u < 0 {
+			if len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) != "" {
+				lines = append(lines, "")
+			}
+			u = len(lines)
+		}
+		lines = slices.Insert(lines, u, unreleasedHeading, "")
+	}
+
+	end := len(lines)
+	if i := slices.IndexFunc(lines[u+1:], isRelease); i >= 0 {
+		end = u + 1 + i
+	}
+
+	section := insert(trimBlank(lines[u+1:end]), c, entry)
+	res := append(slices.Clone(lines[:u+1]), "")
+	res = append(res, section...)
+	if end < len(lines) {
+		res = append(res, "")
+		res = append(res, lines[end:]...)
+	}
+	return join(res)
+}
+
+func insert(lines []string, c Category, entry string) []string {
+	heading, ok := headings[c]
+	if !ok {
+		c, heading = CategoryOther, headings[CategoryOther]
+	}
+
+	if h := slices.Index(trimmed(lines), heading); h >= 0 {
+		end := h + 1
+		for i := h + 1; i < len(lines) && !strings.HasPrefix(strings.TrimSpace(lines[i]), "#"); i++ {
+			if strings.TrimSpace(lines[i]) != "" {
+				end = i + 1
+			}
+		}
+		return slices.Insert(lines, end, entry)
+	}
+
+	// the section goes before the first section of a later category
+	later := Categories[slices.Index(Categories, c)+1:]
+	for i, l := range trimmed(lines) {
+		for _, lc := range later {
+			if l == headings[lc] {
+				return slices.Insert(lines, i, heading, entry, "")
+			}
+		}
+	}
+
+	if len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) != "" {
+		lines = append(lines, "")
+	}
+	return append(lines, heading, entry)
+}
+
+func isRelease(l string) bool {
+	return strings.HasPrefix(l, "## ")
+}
+
+func trimmed(lines []string) []string {
+	res := make([]string, len(lines))
+	for i, l := range lines {
+		res[i] = strings.TrimSpace(l)
+	}
+	return res
+}
+
+// trimBlank drops leading and trailing blank lines
+func trimBlank(lines []string) []string {
+	for len(lines) > 0 && strings.TrimSpace(lines[0]) == "" {
+		lines = lines[1:]
+	}
+	for len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return slices.Clone(lines)
+}
+
+func split(doc string) []string {
+	doc = strings.TrimRight(doc, "\n")
+	if doc == "" {
+		return nil
+	}
+	return strings.Split(doc, "\n")
+}
+
+func join(lines []string) string {
+	return strings.Join(lines, "\n") + "\n"
+}
@@ -0,0 +1,97 @@
+package changelog
+
+import "testing"
+
+func TestInsert(t *testing.T) {
+	tests := []struct {
+		name string
+		doc  string
+		c    Category
+		want string
+	}{
+		{
+			name: "empty",
+			doc:  "",
+			c:    CategoryFix,
+			want: "### Fixes\n- entry\n",
+		},
+		{
+			name: "existing section",
+			doc:  "### Features\n- a\n\n### Fixes\n- b\n",
+			c:    CategoryFeature,
+			want: "### Features\n- a\n- entry\n\n### Fixes\n- b\n",
+		},
+		{
+			name: "section before a later one",
+			doc:  "### Fixes\n- b\n",
+			c:    CategoryBreaking,
+			want: "### Breaking changes\n- entry\n\n### Fixes\n- b\n",
+		},
+		{
+			name: "section at the end",
+			doc:  "### Features\n- a\n",
+			c:    CategoryOther,
+			want: "### Features\n- a\n\n### Other\n- entry\n",
+		},
+		{
+			name: "unknown category",
+			doc:  "",
+			c:    "chore",
+			want: "### Other\n- entry\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Insert(tt.doc, tt.c, "- entry"); got != tt.want {
+				t.Errorf("Insert =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestInsertUnreleased(t *testing.T) {
+	tests := []struct {
+		name string
+		doc  string
+		c    Category
+		want string
+	}{
+		{
+			name: "empty file",
+			doc:  "",
+			c:    CategoryFix,
+			want: "## Unreleased\n\n### Fixes\n- entry\n",
+		},
+		{
+			name: "existing unreleased section",
+			doc:  "# Changelog\n\n## Unreleased\n\n### Fixes\n- b\n\n## v1.0.0\n\n### Features\n- a\n",
+			c:    CategoryFix,
+			want: "# Changelog\n\n## Unreleased\n\n### Fixes\n- b\n- entry\n\n## v1.0.0\n\n### Features\n- a\n",
+		},
+		{
+			name: "new category in unreleased section",
+			doc:  "## Unreleased\n\n### Fixes\n- b\n\n## v1.0.0\n",
+			c:    CategoryFeature,
+			want: "## Unreleased\n\n### Features\n- entry\n\n### Fixes\n- b\n\n## v1.0.0\n",
+		},
+		{
+			name: "no unreleased section",
+			doc:  "# Changelog\n\n## v1.0.0\n\n### Features\n- a\n",
+			c:    CategoryFeature,
+			want: "# Changelog\n\n## Unreleased\n\n### Features\n- entry\n\n## v1.0.0\n\n### Features\n- a\n",
+		},
+		{
+			name: "no releases",
+			doc:  "# Changelog\n",
+			c:    CategoryOther,
+			want: "# Changelog\n\n## Unreleased\n\n### Other\n- entry\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := InsertUnreleased(tt.doc, tt.c, "- entry"); got != tt.want {
+				t.Errorf("InsertUnreleased =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
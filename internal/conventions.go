@@ -0,0 +1,200 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"go-snob/internal/actor/vcs"
+	"go-snob/internal/config"
+	"go-snob/internal/conventions"
+	"go-snob/internal/feedback"
+	"go-snob/internal/prompt"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+const rememberCommand = "/snob remember"
+
+// WithConventions puts rules of the repository into review prompts and lets maintainers add them
+func (o *Orchestrator) WithConventions(store *conventions.Store) *Orchestrator {
+	o.conventions = store
+	return o
+}
+
+// repoConventions rules of the repository applying to the changed files
+func (o *Orchestrator) repoConventions(repo vcs.Repository, files []prompt.File) []string {
+	if o.conventions == nil {
+		return nil
+	}
+	paths := make([]string, 0, len(files))
+	for _, f := range files {
+		paths = append(paths, f.Path)
+	}
+
+	var rules []string
+	for _, r := range o.conventions.Match(repo.Owner+"/"+repo.Name, paths) {
+		rules = append(rules, r.Text)
+	}
+	return rules
+}
+
+// CommentHandler handles "/snob remember [paths:glob,...] [lang:go,...] <rule>" comments of maintainers
+func (o *Orchestrator) CommentHandler(ctx context.Context, e vcs.CommentEvent) {
+	rule, ok := parseRemember(e.Body)
+	if !ok {
+		return
+	}
+	logger := o.logger.With(
+		zap.String("forge", string(e.Forge)),
+		zap.String("repo", e.Repository.Owner+"/"+e.Repository.Name),
+		zap.Int("issue", e.Number),
+		zap.String("author", e.Author),
+	)
+	if o.conventions == nil {
+		logger.Info("ignoring remember command, conventions store is not configured")
+		return
+	}
+
+	client, err := o.clients.ResolveRepository(e.Forge, e.Repository)
+	if err != nil {
+		logger.Error("failed to resolve vcs client", zap.Error(err))
+		return
+	}
+	reply := func(body string) {
+		if err := client.CreateComment(ctx, e.Repository.Owner, e.Repository.Name, e.Number, body); err != nil {
+			logger.Error("failed to reply to remember command", zap.Error(err))
+		}
+	}
+
+	pr, ok := client.(vcs.PermissionReader)
+	if !ok {
+		logger.Warn("vcs client can't check permissions, remember command ignored")
+		return
+	}
+	canWrite, err := pr.CanWrite(ctx, e.Repository.Owner, e.Repository.Name, e.Author)
+	if err != nil {
+		logger.Error("failed to check permission", zap.Error(err))
+		return
+	}
+	if !canWrite {
+		logger.Info("remember command of a non maintainer ignored")
+		reply(fmt.Sprintf("@%s only maintainers can add conventions.", e.Author))
+		return
+	}
+
+	rule.Repo = e.Repository.Owner + "/" + e.Repository.Name
+	rule.Source = conventions.SourceComment
+	rule.Author = e.Author
+	rule, err = o.conventions.Add(rule)
+	if err != nil {
+		logger.Error("failed to add convention", zap.Error(err))
+		reply(fmt.Sprintf("Couldn't remember the rule: %v", err))
+		return
+	}
+	logger.Info("convention added", zap.Int64("rule", rule.ID))
+	reply(fmt.Sprintf("📌 Remembered as rule #%d%s: %s", rule.ID, scopeOf(rule), rule.Text))
+}
+
+// parseRemember reads the rule of a remember command, scope options go before the text
+func parseRemember(body string) (conventions.Rule, bool) {
+	rest, ok := strings.CutPrefix(strings.TrimSpace(body), rememberCommand)
+	if !ok || rest != "" && rest[0] != ' ' && rest[0] != '\n' {
+		return conventions.Rule{}, false
+	}
+
+	var rule conventions.Rule
+	fields := strings.Fields(rest)
+	for len(fields) > 0 {
+		if v, ok := strings.CutPrefix(fields[0], "paths:"); ok {
+			rule.Paths = append(rule.Paths, strings.Split(v, ",")...)
+		} else if v, ok := strings.CutPrefix(fields[0], "lang:"); ok {
+			rule.Languages = append(rule.Languages, strings.Split(strings.ToLower(v), ",")...)
+		} else {
+			break
+		}
+		fields = fields[1:]
+	}
+	rule.Text = strings.Join(fields, " ")
+	return rule, true
+}
+
+func scopeOf(r conventions.Rule) string {
+	var scope []string
+	if len(r.Paths) > 0 {
+		scope = append(scope, "paths "+strings.Join(r.Paths, ", "))
+	}
+	if len(r.Languages) > 0 {
+		scope = append(scope, "languages "+strings.Join(r.Languages, ", "))
+	}
+	if len(scope) == 0 {
+		return ""
+	}
+	return " (" + strings.Join(scope, "; ") + ")"
+}
+
+// maintainerCheck reports whether a user may push to the repository, answers are cached for one collection
+func maintainerCheck(ctx context.Context, pr vcs.PermissionReader, owner string, repo string) func(login string) (bool, error) {
+	known := make(map[string]bool)
+	return func(login string) (bool, error) {
+		if ok, cached := known[login]; cached {
+			return ok, nil
+		}
+		ok, err := pr.CanWrite(ctx, owner, repo, login)
+		if err != nil {
+			return false, err
+		}
+		known[login] = ok
+		return ok, nil
+	}
+}
+
+// maintainerVotes up and down votes of users isMaintainer accepts
+func maintainerVotes(reactions []vcs.Reaction, isMaintainer func(login string) (bool, error)) (int, int, error) {
+	var up, down int
+	for _, re := range reactions {
+		if re.Content != "+1" && re.Content != "-1" {
+			continue
+		}
+		ok, err := isMaintainer(re.User)
+		if err != nil {
+			return 0, 0, err
+		}
+		if !ok {
+			continue
+		}
+		if re.Content == "+1" {
+			up++
+		} else {
+			down++
+		}
+	}
+	return up, down, nil
+}
+
+// extractConvention looks for a repository rule in an accepted comment. It reports whether the comment
+// was dealt with, failed attempts are retried on the next collection.
+func (o *Orchestrator) extractConvention(ctx context.Context, r feedback.Review, c feedback.Comment, cfg config.ConventionsConfig) bool {
+	userPrompt := fmt.Sprintf("File %s, line %d.\n\n%s", c.Path, c.Line, prompt.Fence("comment", c.Body))
+	res, err := o.aiClient.ExtractConvention(ctx, cfg.ExtractSystemPrompt, userPrompt)
+	if err != nil {
+		o.logger.Warn("failed to extract convention", zap.Int64("comment", c.ID), zap.Error(err))
+		return false
+	}
+	if !res.General || strings.TrimSpace(res.Rule) == "" {
+		return true
+	}
+
+	rule, err := o.conventions.Add(conventions.Rule{
+		Repo:      r.Owner + "/" + r.Repo,
+		Text:      res.Rule,
+		Paths:     res.Paths,
+		Languages: res.Languages,
+		Source:    conventions.SourceExtracted,
+	})
+	if err != nil {
+		o.logger.Warn("failed to add extracted convention", zap.Int64("comment", c.ID), zap.Error(err))
+		return true
+	}
+	o.logger.Info("convention extracted", zap.String("repo", rule.Repo), zap.Int64("rule", rule.ID))
+	return true
+}
@@ -0,0 +1,43 @@
+package conventions
+
+import (
+	"path"
+	"strings"
+)
+
+var languages = map[string]string{
+	".go":    "go",
+	".py":    "python",
+	".js":    "javascript",
+	".jsx":   "javascript",
+	".mjs":   "javascript",
+	".ts":    "typescript",
+	".tsx":   "typescript",
+	".java":  "java",
+	".kt":    "kotlin",
+	".rs":    "rust",
+	".c":     "c",
+	".h":     "c",
+	".cc":    "cpp",
+	".cpp":   "cpp",
+	".hpp":   "cpp",
+	".cs":    "csharp",
+	".rb":    "ruby",
+	".php":   "php",
+	".swift": "swift",
+	".sql":   "sql",
+	".sh":    "shell",
+	".yaml":  "yaml",
+	".yml":   "yaml",
+	".json":  "json",
+	".proto": "protobuf",
+	".md":    "markdown",
+}
+
+// LanguageOf returns the lowercase language of the file by its extension, empty if unknown
+func LanguageOf(file string) string {
+	if strings.EqualFold(path.Base(file), "Dockerfile") {
+		return "dockerfile"
+	}
+	return languages[strings.ToLower(path.Ext(file))]
+}
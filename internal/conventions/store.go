@@ -0,0 +1,202 @@
+package conventions
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	SourceComment   = "comment"
+	SourceAdmin     = "admin"
+	SourceExtracted = "extracted"
+)
+
+var ErrNotFound = errors.New("rule not found")
+
+// Rule a convention of one repository. Scoped rules only apply to diffs touching matching files.
+type Rule struct {
+	ID int64 `json:"id"`
+	// Repo "owner/name"
+	Repo string `json:"repo"`
+	Text string `json:"text"`
+	// Paths path.Match patterns, patterns without a slash are matched against file names
+	Paths []string `json:"paths,omitempty"`
+	// Languages lowercase language names like "go" or "typescript", see LanguageOf
+	Languages []string  `json:"languages,omitempty"`
+	Source    string    `json:"source"`
+	Author    string    `json:"author,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Validate checks what's editable
+func (r Rule) Validate() error {
+	var errs []error
+	if owner, name, ok := strings.Cut(r.Repo, "/"); !ok || owner == "" || name == "" {
+		errs = append(errs, fmt.Errorf("repo %q is not owner/name", r.Repo))
+	}
+	if strings.TrimSpace(r.Text) == "" {
+		errs = append(errs, errors.New("text is empty"))
+	}
+	for _, p := range r.Paths {
+		if _, err := path.Match(p, ""); err != nil {
+			errs = append(errs, fmt.Errorf("path %q: %w", p, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+type file struct {
+	NextID int64  `json:"next_id"`
+	Rules  []Rule `json:"rules"`
+}
+
+// Store keeps rules in a JSON file, rewritten as a whole on every change
+type Store struct {
+	path string
+
+	mu   sync.RWMutex
+	data file
+}
+
+func NewStore(path string) (*Store, error) {
+	s := &Store{path: path, data: file{NextID: 1}}
+	b, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read conventions store: %w", err)
+	}
+	if err := json.Unmarshal(b, &s.data); err != nil {
+		return nil, fmt.Errorf("unmarshal conventions store: %w", err)
+	}
+	return s, nil
+}
+
+// Add stores the rule with a new id, the same text for the same repository is stored once
+func (s *Store) Add(r Rule) (Rule, error) {
+	if err := r.Validate(); err != nil {
+		return Rule{}, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, cur := range s.data.Rules {
+		if cur.Repo == r.Repo && strings.EqualFold(cur.Text, r.Text) {
+			return cur, nil
+		}
+	}
+
+	r.ID = s.data.NextID
+	if r.CreatedAt.IsZero() {
+		r.CreatedAt = time.Now()
+	}
+	s.data.NextID++
+	s.data.Rules = append(s.data.Rules, r)
+	return r, s.save()
+}
+
+// Update replaces text and scope of the rule, its origin is kept
+func (s *Store) Update(r Rule) (Rule, error) {
+	if err := r.Validate(); err != nil {
+		return Rule{}, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	i := s.index(r.ID)
+	if i < 0 {
+		return Rule{}, ErrNotFound
+	}
+	cur := &s.data.Rules[i]
+	cur.Repo, cur.Text, cur.Paths, cur.Languages = r.Repo, r.Text, r.Paths, r.Languages
+	return *cur, s.save()
+}
+
+func (s *Store) Delete(id int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	i := s.index(id)
+	if i < 0 {
+		return ErrNotFound
+	}
+	s.data.Rules = slices.Delete(s.data.Rules, i, i+1)
+	return s.save()
+}
+
+// List returns rules of the repository, all rules if repo is empty
+func (s *Store) List(repo string) []Rule {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	rules := make([]Rule, 0)
+	for _, r := range s.data.Rules {
+		if repo == "" || r.Repo == repo {
+			rules = append(rules, r)
+		}
+	}
+	return rules
+}
+
+// Match returns rules of the repository applying to any of the changed paths
+func (s *Store) Match(repo string, paths []string) []Rule {
+	var matched []Rule
+	for _, r := range s.List(repo) {
+		if slices.ContainsFunc(paths, r.applies) {
+			matched = append(matched, r)
+		}
+	}
+	return matched
+}
+
+func (r Rule) applies(file string) bool {
+	if len(r.Languages) > 0 && !slices.Contains(r.Languages, LanguageOf(file)) {
+		return false
+	}
+	if len(r.Paths) == 0 {
+		return true
+	}
+	for _, pattern := range r.Paths {
+		name := file
+		if !strings.Contains(pattern, "/") {
+			name = path.Base(file)
+		}
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Store) index(id int64) int {
+	return slices.IndexFunc(s.data.Rules, func(r Rule) bool { return r.ID == id })
+}
+
+func (s *Store) save() error {
+	b, err := json.MarshalIndent(s.data, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal conventions store: %w", err)
+	}
+	// rules are written by hand too, a crash mid-write mustn't lose them
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".*")
+	if err != nil {
+		return fmt.Errorf("write conventions store: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(b); err != nil {
+		tmp.Close()
+		return fmt.Errorf("write conventions store: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("write conventions store: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return fmt.Errorf("write conventions store: %w", err)
+	}
+	return nil
+}
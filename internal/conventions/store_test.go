@@ -0,0 +1,78 @@
+package conventions
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestStoreAddDeduplicates(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "conventions.json")
+	s, err := NewStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	first, err := s.Add(Rule{Repo: "acme/shop", Text: "Wrap errors with %w", Source: SourceAdmin})
+	if err != nil {
+		t.Fatal(err)
+	}
+	again, err := s.Add(Rule{Repo: "acme/shop", Text: "wrap errors with %w", Source: SourceExtracted})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again.ID != first.ID || again.Source != SourceAdmin {
+		t.Errorf("same text was stored twice: %+v, %+v", first, again)
+	}
+	if _, err := s.Add(Rule{Repo: "shop", Text: "x"}); err == nil {
+		t.Error("repo without owner must be rejected")
+	}
+
+	reopened, err := NewStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rules := reopened.List("acme/shop"); len(rules) != 1 || rules[0].ID != first.ID {
+		t.Errorf("rules weren't persisted: %+v", rules)
+	}
+}
+
+func TestStoreMatch(t *testing.T) {
+	s, err := NewStore(filepath.Join(t.TempDir(), "conventions.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, r := range []Rule{
+		{Repo: "acme/shop", Text: "general"},
+		{Repo: "acme/shop", Text: "sql only", Paths: []string{"*.sql"}},
+		{Repo: "acme/shop", Text: "go only", Languages: []string{"go"}},
+		{Repo: "acme/other", Text: "other repo"},
+	} {
+		if _, err := s.Add(r); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		paths []string
+		want  []string
+	}{
+		{paths: []string{"db/schema.sql"}, want: []string{"general", "sql only"}},
+		{paths: []string{"cmd/main.go"}, want: []string{"general", "go only"}},
+		{paths: []string{"README.md"}, want: []string{"general"}},
+	}
+	for _, tt := range tests {
+		var got []string
+		for _, r := range s.Match("acme/shop", tt.paths) {
+			got = append(got, r.Text)
+		}
+		if len(got) != len(tt.want) {
+			t.Errorf("Match(%v) = %v, want %v", tt.paths, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("Match(%v) = %v, want %v", tt.paths, got, tt.want)
+				break
+			}
+		}
+	}
+}
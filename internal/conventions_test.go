@@ -0,0 +1,84 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"go-snob/internal/actor/vcs"
+	"go-snob/internal/conventions"
+	"reflect"
+	"testing"
+)
+
+func TestParseRemember(t *testing.T) {
+	tests := []struct {
+		body string
+		want conventions.Rule
+		ok   bool
+	}{
+		{body: "/snob remember wrap errors with %w", want: conventions.Rule{Text: "wrap errors with %w"}, ok: true},
+		{
+			body: "  /snob remember paths:*.sql,db/* lang:Go,SQL use placeholders\n",
+			want: conventions.Rule{
+				Text:      "use placeholders",
+				Paths:     []string{"*.sql", "db/*"},
+				Languages: []string{"go", "sql"},
+			},
+			ok: true,
+		},
+		{body: "/snob rememberme", ok: false},
+		{body: "please /snob remember this", ok: false},
+	}
+	for _, tt := range tests {
+		got, ok := parseRemember(tt.body)
+		if ok != tt.ok || ok && !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseRemember(%q) = %+v, %v, want %+v, %v", tt.body, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestMaintainerVotes(t *testing.T) {
+	maintainers := map[string]bool{"owner": true, "dev": true}
+	isMaintainer := func(login string) (bool, error) { return maintainers[login], nil }
+
+	reactions := []vcs.Reaction{
+		{User: "owner", Content: "+1"},
+		{User: "dev", Content: "-1"},
+		{User: "dev", Content: "laugh"},
+		{User: "drive-by", Content: "+1"},
+		{User: "drive-by-2", Content: "+1"},
+	}
+	up, down, err := maintainerVotes(reactions, isMaintainer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if up != 1 || down != 1 {
+		t.Errorf("got %d up, %d down, want 1 and 1: outsiders' votes must not count", up, down)
+	}
+
+	failing := func(string) (bool, error) { return false, errors.New("forge down") }
+	if _, _, err := maintainerVotes(reactions, failing); err == nil {
+		t.Error("permission errors must be reported, not counted as outsiders")
+	}
+}
+
+func TestMaintainerCheckCaches(t *testing.T) {
+	pr := &countingPermissions{}
+	check := maintainerCheck(t.Context(), pr, "acme", "shop")
+	for range 3 {
+		if ok, err := check("owner"); err != nil || !ok {
+			t.Fatalf("check = %v, %v", ok, err)
+		}
+	}
+	if pr.calls != 1 {
+		t.Errorf("CanWrite called %d times, want 1", pr.calls)
+	}
+}
+
+type countingPermissions struct {
+	calls int
+}
+
+func (p *countingPermissions) CanWrite(_ context.Context, _ string, _ string, login string) (bool, error) {
+	p.calls++
+	return login == "owner", nil
+}
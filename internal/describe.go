@@ -0,0 +1,108 @@
+package internal
+
+import (
+	"context"
+	"go-snob/internal/actor/ai"
+	"go-snob/internal/actor/vcs"
+	"go-snob/internal/capture"
+	"go-snob/internal/config"
+	"go-snob/internal/prompt"
+	"go-snob/pkg/hotreload"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+// describe generates a description for a pull request opened without one
+func (o *Orchestrator) describe(
+	ctx context.Context,
+	logger *zap.Logger,
+	e vcs.Event,
+	cfg *hotreload.Snapshot[config.Config],
+) {
+	dc := cfg.Value.Description
+	if strings.TrimSpace(e.PullRequest.Description) != "" {
+		logger.Debug("skipping description, pull request already has one")
+		return
+	}
+
+	client, err := o.clients.Resolve(e)
+	if err != nil {
+		logger.Error("failed to resolve vcs client", zap.Error(err))
+		return
+	}
+	editor, canEdit := client.(vcs.PullRequestEditor)
+	if dc.Mode == config.DescriptionModeEdit && !canEdit {
+		logger.Warn("vcs client can't edit pull requests, posting description as a comment")
+	}
+
+	diff, err := client.GetDiff(ctx, e.Repository.Owner, e.Repository.Name, e.PullRequest.Number)
+	if err != nil {
+		logFailure(ctx, logger, "failed to get diff", err)
+		return
+	}
+	capture.FromContext(ctx).SetDiff(diff)
+
+	data := newPromptData(ctx, logger, client, e, diff, cfg.Value)
+	ctx = ai.WithTraits(ctx, aiTraits(data.PR.Files))
+	systemPrompt, userPrompt, err := prompt.RenderMessages(
+		dc.SystemPromptTemplate(),
+		dc.UserPromptTemplate(),
+		data,
+	)
+	if err != nil {
+		logger.Error("failed to render description prompts", zap.Error(err))
+		return
+	}
+
+	logger.Info("starting ai description request..")
+	d, err := o.aiClient.Describe(ctx, systemPrompt, userPrompt)
+	if err != nil {
+		logFailure(ctx, logger, "failed to generate description", err)
+		return
+	}
+	body := renderDescription(d) + o.footer(cfg.Version, d.Model)
+
+	if dc.Mode == config.DescriptionModeEdit && canEdit {
+		edit := vcs.PullRequestEdit{Body: &body}
+		if dc.UpdateTitle && d.Title != "" {
+			edit.Title = &d.Title
+		}
+		err = editor.EditPullRequest(ctx, e.Repository.Owner, e.Repository.Name, e.PullRequest.Number, edit)
+	} else {
+		err = client.CreateComment(ctx, e.Repository.Owner, e.Repository.Name, e.PullRequest.Number, body)
+	}
+	if err != nil {
+		logFailure(ctx, logger, "failed to post description", err)
+		return
+	}
+	logger.Info("posted description", zap.String("mode", dc.Mode))
+}
+
+// renderDescription formats the description as markdown, empty sections are left out
+func renderDescription(d ai.AIDescriptionResult) string {
+	var b strings.Builder
+	section := func(title string, text string) {
+		text = strings.TrimSpace(text)
+		if text == "" {
+			return
+		}
+		if b.Len() > 0 {
+			b.WriteString("\n\n")
+		}
+		b.WriteString("## " + title + "\n\n" + text)
+	}
+
+	section("Summary", d.Summary)
+	section("Motivation", d.Motivation)
+	var changes strings.Builder
+	for _, c := range d.Changes {
+		if c = strings.TrimSpace(c); c != "" {
+			changes.WriteString("- " + c + "\n")
+		}
+	}
+	section("Changes", changes.String())
+	section("Risks", d.Risks)
+	section("Testing", d.Testing)
+	return b.String()
+}
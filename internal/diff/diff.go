@@ -0,0 +1,209 @@
+package diff
+
+import (
+	"strconv"
+	"strings"
+)
+
+type Status string
+
+const (
+	StatusAdded    Status = "added"
+	StatusDeleted  Status = "deleted"
+	StatusRenamed  Status = "renamed"
+	StatusModified Status = "modified"
+)
+
+type LineKind byte
+
+const (
+	LineContext LineKind = ' '
+	LineAdded   LineKind = '+'
+	LineRemoved LineKind = '-'
+)
+
+type Line struct {
+	Kind LineKind
+	// OldLine and NewLine are 0 when the line doesn't exist on the corresponding side
+	OldLine int
+	NewLine int
+	Content string
+}
+
+type Hunk struct {
+	Header   string
+	OldStart int
+	NewStart int
+	Lines    []Line
+}
+
+type File struct {
+	OldPath   string
+	NewPath   string
+	Status    Status
+	Binary    bool
+	Additions int
+	Deletions int
+	// Raw the file part of the unified diff including headers
+	Raw   string
+	Hunks []Hunk
+}
+
+// Path returns the path the file has after the change
+func (f File) Path() string {
+	if f.Status == StatusDeleted {
+		return f.OldPath
+	}
+	return f.NewPath
+}
+
+// Parse splits a git unified diff into files. It is lenient: anything it doesn't recognise is kept
+// in Raw only.
+func Parse(text string) []File {
+	var (
+		files []File
+		cur   *File
+		hunk  *Hunk
+		raw   strings.Builder
+		old   int
+		new   int
+		// oldLeft and newLeft lines of the current hunk yet to be read, according to its header
+		oldLeft int
+		newLeft int
+	)
+
+	flush := func() {
+		if cur == nil {
+			return
+		}
+		if hunk != nil {
+			cur.Hunks = append(cur.Hunks, *hunk)
+			hunk = nil
+		}
+		cur.Raw = raw.String()
+		raw.Reset()
+		files = append(files, *cur)
+		cur = nil
+	}
+
+	for _, l := range strings.SplitAfter(text, "\n") {
+		if l == "" {
+			continue
+		}
+		line := strings.TrimSuffix(l, "\n")
+
+		if strings.HasPrefix(line, "diff --git ") {
+			flush()
+			cur = &File{Status: StatusModified}
+			cur.OldPath, cur.NewPath = parseGitHeader(strings.TrimPrefix(line, "diff --git "))
+			raw.WriteString(l)
+			continue
+		}
+		if cur == nil {
+			continue
+		}
+		raw.WriteString(l)
+
+		if hunk == nil || oldLeft <= 0 && newLeft <= 0 {
+			switch {
+			case strings.HasPrefix(line, "@@"):
+				if hunk != nil {
+					cur.Hunks = append(cur.Hunks, *hunk)
+				}
+				hunk = &Hunk{Header: line}
+				hunk.OldStart, oldLeft, hunk.NewStart, newLeft = parseHunkHeader(line)
+				old, new = hunk.OldStart, hunk.NewStart
+			case strings.HasPrefix(line, "new file mode"):
+				cur.Status = StatusAdded
+			case strings.HasPrefix(line, "deleted file mode"):
+				cur.Status = StatusDeleted
+			case strings.HasPrefix(line, "rename from "):
+				cur.Status = StatusRenamed
+				cur.OldPath = strings.TrimPrefix(line, "rename from ")
+			case strings.HasPrefix(line, "rename to "):
+				cur.NewPath = strings.TrimPrefix(line, "rename to ")
+			case strings.HasPrefix(line, "Binary files "), strings.HasPrefix(line, "GIT binary patch"):
+				cur.Binary = true
+			case strings.HasPrefix(line, "--- "):
+				if p := trimPathPrefix(strings.TrimPrefix(line, "--- ")); p != "" {
+					cur.OldPath = p
+				}
+			case strings.HasPrefix(line, "+++ "):
+				if p := trimPathPrefix(strings.TrimPrefix(line, "+++ ")); p != "" {
+					cur.NewPath = p
+				}
+			}
+			continue
+		}
+
+		// some tools strip the leading space of blank context lines
+		if line == "" {
+			line = " "
+		}
+		switch LineKind(line[0]) {
+		case LineAdded:
+			hunk.Lines = append(hunk.Lines, Line{Kind: LineAdded, NewLine: new, Content: line[1:]})
+			cur.Additions++
+			new++
+			newLeft--
+		case LineRemoved:
+			hunk.Lines = append(hunk.Lines, Line{Kind: LineRemoved, OldLine: old, Content: line[1:]})
+			cur.Deletions++
+			old++
+			oldLeft--
+		case LineContext:
+			hunk.Lines = append(hunk.Lines, Line{Kind: LineContext, OldLine: old, NewLine: new, Content: line[1:]})
+			old++
+			new++
+			oldLeft--
+			newLeft--
+		}
+	}
+	flush()
+
+	return files
+}
+
+func parseGitHeader(s string) (string, string) {
+	// "a/path b/path", paths with spaces are ambiguous and fixed up by ---/+++ lines later
+	if i := strings.Index(s, " b/"); i >= 0 {
+		return strings.TrimPrefix(s[:i], "a/"), s[i+3:]
+	}
+	return s, s
+}
+
+func trimPathPrefix(p string) string {
+	if p == "/dev/null" {
+		return ""
+	}
+	if i := strings.IndexByte(p, '\t'); i >= 0 {
+		p = p[:i]
+	}
+	if strings.HasPrefix(p, "a/") || strings.HasPrefix(p, "b/") {
+		return p[2:]
+	}
+	return p
+}
+
+// parseHunkHeader "@@ -1,7 +1,8 @@ func x()" returns starts and lengths of both ranges
+func parseHunkHeader(h string) (int, int, int, int) {
+	fields := strings.Fields(h)
+	if len(fields) < 3 {
+		return 0, 0, 0, 0
+	}
+	oldStart, oldLen := parseRange(fields[1])
+	newStart, newLen := parseRange(fields[2])
+	return oldStart, oldLen, newStart, newLen
+}
+
+// parseRange length is 1 if omitted
+func parseRange(r string) (int, int) {
+	r = strings.TrimLeft(r, "-+")
+	length := 1
+	if i := strings.IndexByte(r, ','); i >= 0 {
+		length, _ = strconv.Atoi(r[i+1:])
+		r = r[:i]
+	}
+	start, _ := strconv.Atoi(r)
+	return start, length
+}
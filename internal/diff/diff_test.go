@@ -0,0 +1,161 @@
+package diff
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestParseFiles(t *testing.T) {
+	text := `diff --git a/old.go b/new.go
+similarity index 90%
+rename from old.go
+rename to new.go
+--- a/old.go
++++ b/new.go
+@@ -1,2 +1,2 @@
+ package a
+-var x = 1
++var y = 1
+diff --git a/gone.go b/gone.go
+deleted file mode 100644
+--- a/gone.go
++++ /dev/null
+@@ -1,2 +0,0 @@
+-package a
+-var z = 1
+diff --git a/added.go b/added.go
+new file mode 100644
+--- /dev/null
++++ b/added.go
+@@ -0,0 +1 @@
++package a
+diff --git a/logo.png b/logo.png
+Binary files a/logo.png and b/logo.png differ
+diff --git a/moved.go b/pkg/moved.go
+similarity index 100%
+rename from moved.go
+rename to pkg/moved.go
+`
+	type want struct {
+		path      string
+		oldPath   string
+		status    Status
+		binary    bool
+		additions int
+		deletions int
+	}
+	wants := []want{
+		{path: "new.go", oldPath: "old.go", status: StatusRenamed, additions: 1, deletions: 1},
+		{path: "gone.go", oldPath: "gone.go", status: StatusDeleted, deletions: 2},
+		{path: "added.go", oldPath: "added.go", status: StatusAdded, additions: 1},
+		{path: "logo.png", oldPath: "logo.png", status: StatusModified, binary: true},
+		{path: "pkg/moved.go", oldPath: "moved.go", status: StatusRenamed},
+	}
+
+	files := Parse(text)
+	if len(files) != len(wants) {
+		t.Fatalf("files = %d, want %d", len(files), len(wants))
+	}
+	for i, w := range wants {
+		f := files[i]
+		got := want{f.Path(), f.OldPath, f.Status, f.Binary, f.Additions, f.Deletions}
+		if got != w {
+			t.Errorf("file %d = %+v, want %+v", i, got, w)
+		}
+	}
+	if files[1].NewPath != "gone.go" {
+		t.Errorf("/dev/null replaced the path of a deleted file: %q", files[1].NewPath)
+	}
+	if len(files[4].Hunks) != 0 {
+		t.Errorf("pure rename has hunks: %+v", files[4].Hunks)
+	}
+	if files[3].Raw != "diff --git a/logo.png b/logo.png\nBinary files a/logo.png and b/logo.png differ\n" {
+		t.Errorf("unexpected raw: %q", files[3].Raw)
+	}
+}
+
+func TestParseLineNumbers(t *testing.T) {
+	text := `diff --git a/a.go b/a.go
+--- a/a.go
++++ b/a.go
+@@ -1,3 +1,4 @@ package a
+ import "fmt"
++import "os"
+
+ func a() {
+@@ -10,3 +11,2 @@ func b() {
+ 	x := 1
+-	y := 2
+-	z := 3
++	z := 2
+\ No newline at end of file
+`
+	files := Parse(text)
+	if len(files) != 1 {
+		t.Fatalf("files = %d, want 1", len(files))
+	}
+	f := files[0]
+	if f.Additions != 2 || f.Deletions != 2 {
+		t.Errorf("+%d -%d, want +2 -2", f.Additions, f.Deletions)
+	}
+	if len(f.Hunks) != 2 {
+		t.Fatalf("hunks = %d, want 2", len(f.Hunks))
+	}
+	if h := f.Hunks[1]; h.Header != "@@ -10,3 +11,2 @@ func b() {" || h.OldStart != 10 || h.NewStart != 11 {
+		t.Errorf("unexpected second hunk: %+v", h)
+	}
+
+	tests := []struct {
+		hunk  int
+		lines []Line
+	}{
+		{
+			hunk: 0,
+			lines: []Line{
+				{Kind: LineContext, OldLine: 1, NewLine: 1, Content: `import "fmt"`},
+				{Kind: LineAdded, NewLine: 2, Content: `import "os"`},
+				// the blank context line lost its leading space
+				{Kind: LineContext, OldLine: 2, NewLine: 3, Content: ""},
+				{Kind: LineContext, OldLine: 3, NewLine: 4, Content: "func a() {"},
+			},
+		},
+		{
+			hunk: 1,
+			lines: []Line{
+				{Kind: LineContext, OldLine: 10, NewLine: 11, Content: "\tx := 1"},
+				{Kind: LineRemoved, OldLine: 11, Content: "\ty := 2"},
+				{Kind: LineRemoved, OldLine: 12, Content: "\tz := 3"},
+				{Kind: LineAdded, NewLine: 12, Content: "\tz := 2"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		if got := f.Hunks[tt.hunk].Lines; !slices.Equal(got, tt.lines) {
+			t.Errorf("hunk %d lines:\n%+v\nwant\n%+v", tt.hunk, got, tt.lines)
+		}
+	}
+}
+
+func TestParseNoNewlineInsideHunk(t *testing.T) {
+	text := `diff --git a/a.txt b/a.txt
+--- a/a.txt
++++ b/a.txt
+@@ -1 +1,2 @@
+-last
+\ No newline at end of file
++last
++more
+`
+	f := Parse(text)[0]
+	want := []Line{
+		{Kind: LineRemoved, OldLine: 1, Content: "last"},
+		{Kind: LineAdded, NewLine: 1, Content: "last"},
+		{Kind: LineAdded, NewLine: 2, Content: "more"},
+	}
+	if got := f.Hunks[0].Lines; !slices.Equal(got, want) {
+		t.Errorf("lines = %+v, want %+v", got, want)
+	}
+	if f.Additions != 2 || f.Deletions != 1 {
+		t.Errorf("+%d -%d, want +2 -1", f.Additions, f.Deletions)
+	}
+}
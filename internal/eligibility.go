@@ -0,0 +1,46 @@
+package internal
+
+import (
+	"fmt"
+	"go-snob/internal/actor/vcs"
+	"go-snob/internal/config"
+	"strings"
+)
+
+// skipReason explains why the pull request isn't reviewed yet, it's empty if the pull request is eligible
+func skipReason(pr vcs.PullRequest, cfg config.EligibilityConfig) string {
+	if pr.Draft && !cfg.ReviewDrafts {
+		return "pull request is a draft"
+	}
+	if prefix, ok := wipPrefix(pr.Title, cfg.SkipTitlePrefixes); ok {
+		return fmt.Sprintf("title starts with %q", prefix)
+	}
+	for _, l := range pr.Labels {
+		for _, skip := range cfg.SkipLabels {
+			if strings.EqualFold(l, skip) {
+				return fmt.Sprintf("labeled %q", l)
+			}
+		}
+	}
+	return ""
+}
+
+func wipPrefix(title string, prefixes []string) (string, bool) {
+	title = strings.ToLower(strings.TrimSpace(title))
+	for _, p := range prefixes {
+		if p != "" && strings.HasPrefix(title, strings.ToLower(p)) {
+			return p, true
+		}
+	}
+	return "", false
+}
+
+// leftWIP reports whether an edit removed a work in progress prefix from the title
+func leftWIP(e vcs.Event, cfg config.EligibilityConfig) bool {
+	if e.PreviousTitle == "" {
+		return false
+	}
+	_, was := wipPrefix(e.PreviousTitle, cfg.SkipTitlePrefixes)
+	_, is := wipPrefix(e.PullRequest.Title, cfg.SkipTitlePrefixes)
+	return was && !is
+}
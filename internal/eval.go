@@ -0,0 +1,40 @@
+package internal
+
+import (
+	"context"
+	"go-snob/internal/actor/ai"
+	"go-snob/internal/config"
+	"go-snob/internal/prompt"
+	"strings"
+)
+
+// ReviewDiff reviews a diff without a forge the way a pull request with the title and the description
+// would be reviewed. Repository metadata, conventions and feedback are left out
+func (o *Orchestrator) ReviewDiff(
+	ctx context.Context,
+	repo string,
+	title string,
+	description string,
+	rawDiff string,
+	cfg config.Config,
+) (ai.AIReviewResult, error) {
+	owner, name, _ := strings.Cut(repo, "/")
+	data := prompt.Data{
+		PR: prompt.PullRequest{
+			Title:        title,
+			Description:  description,
+			LinkedIssues: prompt.LinkedIssues(title, description),
+			Files:        promptFiles(rawDiff),
+		},
+		Repo: prompt.Repository{
+			Owner:    owner,
+			Name:     name,
+			FullName: repo,
+		},
+		Diff: prompt.Fence("diff", rawDiff),
+		Vars: cfg.RepoVars(repo),
+	}
+	ctx = ai.WithTraits(ctx, aiTraits(data.PR.Files))
+
+	return o.reviewDiff(ctx, o.logger, rawDiff, data, cfg, nil)
+}
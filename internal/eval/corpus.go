@@ -0,0 +1,122 @@
+package eval
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// defaultLineTolerance lines a comment may be off an expected finding and still count as well placed
+const defaultLineTolerance = 3
+
+// Case a recorded change together with what a review of it must and must not say
+type Case struct {
+	Name        string `yaml:"name"`
+	Repo        string `yaml:"repo"`
+	Title       string `yaml:"title"`
+	Description string `yaml:"description"`
+	Diff        string `yaml:"diff"`
+	// DiffFile diff path relative to the case file, read when Diff is empty
+	DiffFile string `yaml:"diff_file"`
+	// Expected findings the review should have
+	Expected []Finding `yaml:"expected"`
+	// Forbidden findings the review must not have, e.g. known false positives
+	Forbidden []Finding `yaml:"forbidden"`
+	// Mock content the mock LLM answers review requests of the case with
+	Mock string `yaml:"mock"`
+}
+
+// Finding matches review comments on File whose message matches Match
+type Finding struct {
+	File string `yaml:"file"`
+	// Line new line of the finding, any line if 0
+	Line int `yaml:"line"`
+	// Tolerance lines a comment may be off Line, defaultLineTolerance if 0
+	Tolerance int `yaml:"tolerance"`
+	// Match case-insensitive regexp of the comment message, any message if empty
+	Match string `yaml:"match"`
+
+	re *regexp.Regexp
+}
+
+func (f *Finding) compile() error {
+	if f.File == "" {
+		return fmt.Errorf("file is required")
+	}
+	if f.Tolerance == 0 {
+		f.Tolerance = defaultLineTolerance
+	}
+	re, err := regexp.Compile("(?i)" + f.Match)
+	if err != nil {
+		return fmt.Errorf("compile match: %w", err)
+	}
+	f.re = re
+	return nil
+}
+
+// LoadCorpus reads every *.yaml case of dir, sorted by name
+func LoadCorpus(dir string) ([]Case, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.yaml"))
+	if err != nil {
+		return nil, fmt.Errorf("list cases: %w", err)
+	}
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("no cases in %s", dir)
+	}
+
+	cases := make([]Case, 0, len(paths))
+	for _, p := range paths {
+		c, err := loadCase(p)
+		if err != nil {
+			return nil, fmt.Errorf("load case %s: %w", filepath.Base(p), err)
+		}
+		cases = append(cases, c)
+	}
+	slices.SortFunc(cases, func(a, b Case) int { return strings.Compare(a.Name, b.Name) })
+	return cases, nil
+}
+
+func loadCase(path string) (Case, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return Case{}, fmt.Errorf("read: %w", err)
+	}
+	var c Case
+	dec := yaml.NewDecoder(bytes.NewReader(b))
+	dec.KnownFields(true)
+	if err := dec.Decode(&c); err != nil {
+		return Case{}, fmt.Errorf("unmarshal: %w", err)
+	}
+
+	if c.Name == "" {
+		c.Name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+	if c.Diff == "" && c.DiffFile != "" {
+		d, err := os.ReadFile(filepath.Join(filepath.Dir(path), c.DiffFile))
+		if err != nil {
+			return Case{}, fmt.Errorf("read diff: %w", err)
+		}
+		c.Diff = string(d)
+	}
+	if c.Diff == "" {
+		return Case{}, fmt.Errorf("diff or diff_file is required")
+	}
+
+	for i := range c.Expected {
+		if err := c.Expected[i].compile(); err != nil {
+			return Case{}, fmt.Errorf("expected[%d]: %w", i, err)
+		}
+	}
+	for i := range c.Forbidden {
+		if err := c.Forbidden[i].compile(); err != nil {
+			return Case{}, fmt.Errorf("forbidden[%d]: %w", i, err)
+		}
+	}
+	return c, nil
+}
@@ -0,0 +1,115 @@
+package eval
+
+import (
+	"context"
+	"go-snob/internal"
+	"go-snob/internal/actor/ai"
+	"go-snob/internal/config"
+	"os"
+	"testing"
+
+	"go.uber.org/zap"
+	"resty.dev/v3"
+)
+
+func TestScoreCase(t *testing.T) {
+	c := Case{
+		Name: "case",
+		Expected: []Finding{
+			{File: "a.go", Line: 10, Match: "nil map"},
+			{File: "b.go", Match: "leak"},
+		},
+		Forbidden: []Finding{{File: "a.go", Match: "naming"}},
+	}
+	for i := range c.Expected {
+		if err := c.Expected[i].compile(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := c.Forbidden[0].compile(); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		comments   []ai.Comment
+		wantFound  int
+		wantPlaced int
+		wantViol   int
+	}{
+		{name: "none"},
+		{
+			name: "all placed",
+			comments: []ai.Comment{
+				{File: "a.go", NewPosition: 11, Message: "Writing to a Nil Map panics"},
+				{File: "b.go", NewPosition: 3, Message: "goroutine leak"},
+			},
+			wantFound: 2, wantPlaced: 2,
+		},
+		{
+			name:      "misplaced",
+			comments:  []ai.Comment{{File: "a.go", NewPosition: 40, Message: "nil map"}},
+			wantFound: 1,
+		},
+		{
+			name: "placed preferred",
+			comments: []ai.Comment{
+				{File: "a.go", NewPosition: 40, Message: "nil map"},
+				{File: "a.go", NewPosition: 10, Message: "nil map"},
+			},
+			wantFound: 1, wantPlaced: 1,
+		},
+		{
+			name:     "forbidden and wrong file",
+			comments: []ai.Comment{{File: "c.go", NewPosition: 10, Message: "nil map"}, {File: "a.go", Message: "bad naming"}},
+			wantViol: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res := scoreCase(c, ai.AIReviewResult{Comments: tt.comments})
+			if res.Found != tt.wantFound || res.Placed != tt.wantPlaced || len(res.Violations) != tt.wantViol {
+				t.Errorf("found %d placed %d violations %d, want %d %d %d",
+					res.Found, res.Placed, len(res.Violations), tt.wantFound, tt.wantPlaced, tt.wantViol)
+			}
+			if len(res.Missed) != len(c.Expected)-tt.wantFound {
+				t.Errorf("missed %v", res.Missed)
+			}
+		})
+	}
+}
+
+func TestRunCorpus(t *testing.T) {
+	cases, err := LoadCorpus("../../ci/eval/corpus")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := os.ReadFile("../../ci/eval/config.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := config.Parse(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mock, err := NewMockLLM()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mock.Close()
+
+	logger := zap.NewNop()
+	orch := internal.NewOrchestrator(ai.NewClient(resty.New(), logger, mock.URL(), "token"), nil, nil, logger)
+	r := Run(context.Background(), cases, func(ctx context.Context, c Case) (ai.AIReviewResult, error) {
+		return orch.ReviewDiff(ctx, c.Repo, c.Title, c.Description, c.Diff, cfg)
+	}, mock)
+
+	s := r.Score
+	if s.Cases != len(cases) || s.Failed != 0 {
+		t.Fatalf("cases %d failed %d: %+v", s.Cases, s.Failed, r.Cases)
+	}
+	if s.Recall != 1 || s.Precision != 1 || s.PositionAccuracy != 1 || s.Violations != 0 {
+		t.Errorf("score %+v", s)
+	}
+}
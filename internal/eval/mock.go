@@ -0,0 +1,97 @@
+package eval
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+)
+
+const (
+	reviewSchema       = "ai_review_result"
+	verificationSchema = "ai_verification_result"
+
+	emptyReview = `{"verdict":"COMMENT","summary":"","comments":[]}`
+	// noVerdicts keeps every comment, the verifier did not judge any of them
+	noVerdicts = `{"verdicts":[]}`
+)
+
+// MockLLM a local chat completions endpoint answering review requests with the recorded Mock of the
+// case in use, which makes runs deterministic. Verification requests get no verdicts
+type MockLLM struct {
+	listener net.Listener
+	server   *http.Server
+
+	mu      sync.Mutex
+	current Case
+}
+
+// NewMockLLM starts the mock on a random local port
+func NewMockLLM() (*MockLLM, error) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, fmt.Errorf("listen: %w", err)
+	}
+	m := &MockLLM{listener: l}
+	m.server = &http.Server{Handler: m}
+	go func() {
+		// returns once closed
+		_ = m.server.Serve(l)
+	}()
+	return m, nil
+}
+
+// URL chat completions url of the mock
+func (m *MockLLM) URL() string {
+	return "http://" + m.listener.Addr().String() + "/v1/chat/completions"
+}
+
+// Use answers further requests with the recorded answers of c
+func (m *MockLLM) Use(c Case) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.current = c
+}
+
+func (m *MockLLM) Close() error {
+	return m.server.Close()
+}
+
+func (m *MockLLM) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		ResponseFormat struct {
+			JSONSchema struct {
+				Name string `json:"name"`
+			} `json:"json_schema"`
+		} `json:"response_format"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var content string
+	switch body.ResponseFormat.JSONSchema.Name {
+	case reviewSchema:
+		m.mu.Lock()
+		content = m.current.Mock
+		m.mu.Unlock()
+		if content == "" {
+			content = emptyReview
+		}
+	case verificationSchema:
+		content = noVerdicts
+	default:
+		http.Error(w, "unsupported response format", http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]any{
+		"choices": []map[string]any{{
+			"message":       map[string]string{"role": "assistant", "content": content},
+			"finish_reason": "stop",
+		}},
+	})
+}
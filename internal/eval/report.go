@@ -0,0 +1,122 @@
+package eval
+
+import (
+	"context"
+	"fmt"
+	"go-snob/internal/actor/ai"
+	"io"
+	"strings"
+)
+
+// Reviewer reviews the diff of a case with the config under evaluation
+type Reviewer func(ctx context.Context, c Case) (ai.AIReviewResult, error)
+
+// Report results of a corpus run with one config
+type Report struct {
+	Config        string       `json:"config"`
+	ConfigVersion string       `json:"config_version"`
+	Score         Score        `json:"score"`
+	Cases         []CaseResult `json:"cases"`
+}
+
+// Run reviews cases one by one. A failed review scores as all findings missed. mock is nil when a real
+// endpoint is used
+func Run(ctx context.Context, cases []Case, review Reviewer, mock *MockLLM) Report {
+	results := make([]CaseResult, 0, len(cases))
+	for _, c := range cases {
+		if mock != nil {
+			mock.Use(c)
+		}
+		r, err := review(ctx, c)
+		if err != nil {
+			res := CaseResult{Name: c.Name, Error: err.Error(), Expected: len(c.Expected)}
+			for _, f := range c.Expected {
+				res.Missed = append(res.Missed, f.String())
+			}
+			results = append(results, res)
+			continue
+		}
+		results = append(results, scoreCase(c, r))
+	}
+	return Report{Score: summarize(results), Cases: results}
+}
+
+// WriteReport writes r as markdown
+func WriteReport(w io.Writer, r Report) error {
+	s := r.Score
+	var b strings.Builder
+	fmt.Fprintf(&b, "## %s (config %s)\n\n", r.Config, r.ConfigVersion)
+	b.WriteString("| metric | value |\n|---|---|\n")
+	fmt.Fprintf(&b, "| recall | %.2f (%d/%d) |\n", s.Recall, s.Found, s.Expected)
+	fmt.Fprintf(&b, "| precision | %.2f (%d/%d) |\n", s.Precision, s.Found, s.Comments)
+	fmt.Fprintf(&b, "| position accuracy | %.2f (%d/%d) |\n", s.PositionAccuracy, s.Placed, s.Found)
+	fmt.Fprintf(&b, "| forbidden findings | %d |\n", s.Violations)
+	fmt.Fprintf(&b, "| failed cases | %d/%d |\n\n", s.Failed, s.Cases)
+
+	b.WriteString("| case | found | placed | comments | forbidden | notes |\n|---|---|---|---|---|---|\n")
+	for _, c := range r.Cases {
+		fmt.Fprintf(&b, "| %s | %d/%d | %d | %d | %d | %s |\n",
+			c.Name, c.Found, c.Expected, c.Placed, c.Comments, len(c.Violations), notes(c))
+	}
+	b.WriteString("\n")
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// WriteComparison writes metric deltas of candidate against base and the cases scored differently
+func WriteComparison(w io.Writer, base Report, candidate Report) error {
+	bs, cs := base.Score, candidate.Score
+	var b strings.Builder
+	fmt.Fprintf(&b, "## %s → %s\n\n", base.Config, candidate.Config)
+	b.WriteString("| metric | base | candidate | Δ |\n|---|---|---|---|\n")
+	writeDelta(&b, "recall", bs.Recall, cs.Recall)
+	writeDelta(&b, "precision", bs.Precision, cs.Precision)
+	writeDelta(&b, "position accuracy", bs.PositionAccuracy, cs.PositionAccuracy)
+	fmt.Fprintf(&b, "| forbidden findings | %d | %d | %+d |\n", bs.Violations, cs.Violations, cs.Violations-bs.Violations)
+	fmt.Fprintf(&b, "| failed cases | %d | %d | %+d |\n\n", bs.Failed, cs.Failed, cs.Failed-bs.Failed)
+
+	byName := make(map[string]CaseResult, len(base.Cases))
+	for _, c := range base.Cases {
+		byName[c.Name] = c
+	}
+	var changed []string
+	for _, c := range candidate.Cases {
+		prev, ok := byName[c.Name]
+		if ok && prev.Found == c.Found && prev.Placed == c.Placed && prev.Comments == c.Comments &&
+			len(prev.Violations) == len(c.Violations) && prev.Error == c.Error {
+			continue
+		}
+		changed = append(changed, fmt.Sprintf("| %s | %d/%d → %d/%d | %d → %d | %d → %d | %d → %d |\n",
+			c.Name, prev.Found, prev.Expected, c.Found, c.Expected, prev.Placed, c.Placed,
+			prev.Comments, c.Comments, len(prev.Violations), len(c.Violations)))
+	}
+	if len(changed) == 0 {
+		b.WriteString("No case scored differently.\n\n")
+	} else {
+		b.WriteString("| case | found | placed | comments | forbidden |\n|---|---|---|---|---|\n")
+		b.WriteString(strings.Join(changed, ""))
+		b.WriteString("\n")
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func writeDelta(b *strings.Builder, metric string, base, candidate float64) {
+	fmt.Fprintf(b, "| %s | %.2f | %.2f | %+.2f |\n", metric, base, candidate, candidate-base)
+}
+
+func notes(c CaseResult) string {
+	var parts []string
+	if c.Error != "" {
+		parts = append(parts, "error: "+c.Error)
+	}
+	if len(c.Missed) > 0 {
+		parts = append(parts, "missed: "+strings.Join(c.Missed, ", "))
+	}
+	if len(c.Violations) > 0 {
+		parts = append(parts, "forbidden: "+strings.Join(c.Violations, ", "))
+	}
+	return strings.ReplaceAll(strings.Join(parts, "; "), "|", `\|`)
+}
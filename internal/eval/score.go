@@ -0,0 +1,144 @@
+package eval
+
+import (
+	"fmt"
+	"go-snob/internal/actor/ai"
+)
+
+// CaseResult how the review of one case scored
+type CaseResult struct {
+	Name    string `json:"name"`
+	Model   string `json:"model,omitempty"`
+	Error   string `json:"error,omitempty"`
+	Verdict string `json:"verdict,omitempty"`
+
+	Comments int `json:"comments"`
+	Expected int `json:"expected"`
+	// Found expected findings matched by a comment, a comment matches one finding at most
+	Found int `json:"found"`
+	// Placed found findings whose comment is within the line tolerance
+	Placed int `json:"placed"`
+	// Missed expected findings no comment matched
+	Missed []string `json:"missed,omitempty"`
+	// Violations forbidden findings some comment matched
+	Violations []string `json:"violations,omitempty"`
+}
+
+// Score totals of a run. Ratios of nothing are 1: a case without expected findings has nothing to miss
+type Score struct {
+	Cases      int `json:"cases"`
+	Failed     int `json:"failed"`
+	Comments   int `json:"comments"`
+	Expected   int `json:"expected"`
+	Found      int `json:"found"`
+	Placed     int `json:"placed"`
+	Violations int `json:"violations"`
+
+	// Recall found of expected findings
+	Recall float64 `json:"recall"`
+	// Precision comments matching an expected finding of all comments
+	Precision float64 `json:"precision"`
+	// PositionAccuracy placed of found findings
+	PositionAccuracy float64 `json:"position_accuracy"`
+}
+
+func (f Finding) String() string {
+	s := f.File
+	if f.Line > 0 {
+		s += fmt.Sprintf(":%d", f.Line)
+	}
+	if f.Match != "" {
+		s += fmt.Sprintf(" /%s/", f.Match)
+	}
+	return s
+}
+
+func (f Finding) matches(c ai.Comment) bool {
+	return c.File == f.File && f.re.MatchString(c.Message)
+}
+
+func (f Finding) placed(c ai.Comment) bool {
+	if f.Line == 0 {
+		return true
+	}
+	line := c.NewPosition
+	if line == 0 {
+		line = c.OldPosition
+	}
+	return abs(line-f.Line) <= f.Tolerance
+}
+
+// scoreCase matches comments to expected findings one to one, well placed comments first
+func scoreCase(c Case, r ai.AIReviewResult) CaseResult {
+	res := CaseResult{
+		Name:     c.Name,
+		Model:    r.Model,
+		Verdict:  r.Verdict,
+		Comments: len(r.Comments),
+		Expected: len(c.Expected),
+	}
+
+	used := make([]bool, len(r.Comments))
+	for _, f := range c.Expected {
+		best := -1
+		for i, comment := range r.Comments {
+			if used[i] || !f.matches(comment) {
+				continue
+			}
+			if best == -1 || f.placed(comment) && !f.placed(r.Comments[best]) {
+				best = i
+			}
+		}
+		if best == -1 {
+			res.Missed = append(res.Missed, f.String())
+			continue
+		}
+		used[best] = true
+		res.Found++
+		if f.placed(r.Comments[best]) {
+			res.Placed++
+		}
+	}
+
+	for _, f := range c.Forbidden {
+		for _, comment := range r.Comments {
+			if f.matches(comment) && f.placed(comment) {
+				res.Violations = append(res.Violations, f.String())
+				break
+			}
+		}
+	}
+	return res
+}
+
+func summarize(results []CaseResult) Score {
+	s := Score{Cases: len(results)}
+	for _, r := range results {
+		if r.Error != "" {
+			s.Failed++
+		}
+		s.Comments += r.Comments
+		s.Expected += r.Expected
+		s.Found += r.Found
+		s.Placed += r.Placed
+		s.Violations += len(r.Violations)
+	}
+	s.Recall = ratio(s.Found, s.Expected)
+	s.Precision = ratio(s.Found, s.Comments)
+	s.PositionAccuracy = ratio(s.Placed, s.Found)
+	return s
+}
+
+func ratio(n, of int) float64 {
+	if of == 0 {
+		return 1
+	}
+	return float64(n) / float64(of)
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,239 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"go-snob/internal/actor/ai"
+	"go-snob/internal/actor/vcs"
+	"go-snob/internal/capture"
+	"go-snob/internal/config"
+	"go-snob/internal/feedback"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// feedbackWindow how long reactions on a review are collected after it's posted
+const feedbackWindow = 14 * 24 * time.Hour
+
+// WithFeedback tracks posted review comments in store and collects reactions on them
+func (o *Orchestrator) WithFeedback(store *feedback.Store) *Orchestrator {
+	o.feedback = store
+	return o
+}
+
+// postReview creates the review, its comments are tracked for feedback when the forge can report it
+func (o *Orchestrator) postReview(
+	ctx context.Context,
+	logger *zap.Logger,
+	client vcs.Client,
+	e vcs.Event,
+	r ai.AIReviewResult,
+	configVersion string,
+) error {
+	owner, repo, index := e.Repository.Owner, e.Repository.Name, e.PullRequest.Number
+	review := newReview(e.PullRequest.HeadSHA, r, o.footer(configVersion, r.Model))
+	capture.FromContext(ctx).SetReview(review)
+
+	rf, ok := client.(vcs.ReviewFeedback)
+	if o.feedback == nil || !ok || len(review.Comments) == 0 {
+		return client.CreateReview(ctx, owner, repo, index, review)
+	}
+
+	id, err := rf.CreateReviewWithID(ctx, owner, repo, index, review)
+	if err != nil {
+		return err
+	}
+	posted, err := rf.ListReviewComments(ctx, owner, repo, index, id)
+	if err != nil {
+		logger.Warn("failed to list posted comments, no feedback is collected for them", zap.Error(err))
+		return nil
+	}
+	if err := o.feedback.Track(newFeedbackReview(e, id, r, posted, configVersion)); err != nil {
+		logger.Warn("failed to track review feedback", zap.Error(err))
+	}
+	return nil
+}
+
+func newFeedbackReview(e vcs.Event, id int64, r ai.AIReviewResult, posted []vcs.PostedComment, configVersion string) feedback.Review {
+	// the forge keeps bodies as posted, that's how comments are matched to their categories
+	categories := make(map[string]string, len(r.Comments))
+	for _, c := range r.Comments {
+		categories[c.File+"\x00"+c.Message] = c.Category
+	}
+
+	fr := feedback.Review{
+		Forge:         string(e.Forge),
+		RepoURL:       e.Repository.HTMLURL,
+		Owner:         e.Repository.Owner,
+		Repo:          e.Repository.Name,
+		PR:            e.PullRequest.Number,
+		ID:            id,
+		CommitSHA:     e.PullRequest.HeadSHA,
+		Model:         r.Model,
+		ConfigVersion: configVersion,
+		PostedAt:      time.Now(),
+	}
+	for _, p := range posted {
+		line := p.Line
+		if line == 0 {
+			line = p.OldLine
+		}
+		fr.Comments = append(fr.Comments, feedback.Comment{
+			ID:       p.ID,
+			Path:     p.Path,
+			Line:     line,
+			Category: categories[p.Path+"\x00"+p.Body],
+			Body:     p.Body,
+		})
+	}
+	return fr
+}
+
+// CollectFeedback refreshes reactions and resolutions of reviews posted within the feedback window
+func (o *Orchestrator) CollectFeedback(ctx context.Context) {
+	watched := o.feedback.Watched(time.Now().Add(-feedbackWindow))
+	for _, r := range watched {
+		if ctx.Err() != nil {
+			return
+		}
+		if err := o.collectReview(ctx, r); err != nil {
+			o.logger.Warn("failed to collect review feedback",
+				zap.String("repo", r.Owner+"/"+r.Repo),
+				zap.Int("pr", r.PR),
+				zap.Error(err),
+			)
+		}
+	}
+	setFeedbackMetrics(o.feedback.Stats())
+	o.logger.Debug("feedback collected", zap.Int("reviews", len(watched)))
+}
+
+func (o *Orchestrator) collectReview(ctx context.Context, r feedback.Review) error {
+	cfg := o.cfg.Current().Value.Conventions
+	client, err := o.clients.ResolveRepository(
+		vcs.Forge(r.Forge),
+		vcs.Repository{Owner: r.Owner, Name: r.Repo, HTMLURL: r.RepoURL},
+	)
+	if err != nil {
+		return err
+	}
+	rf, ok := client.(vcs.ReviewFeedback)
+	if !ok {
+		return fmt.Errorf("vcs client can't report feedback")
+	}
+
+	head, err := rf.PullRequestHead(ctx, r.Owner, r.Repo, r.PR)
+	if err != nil {
+		return err
+	}
+	posted, err := rf.ListReviewComments(ctx, r.Owner, r.Repo, r.PR, r.ID)
+	if err != nil {
+		return err
+	}
+	resolved := make(map[int64]bool, len(posted))
+	for _, p := range posted {
+		resolved[p.ID] = p.Resolved
+	}
+	extract := cfg.Extract && o.conventions != nil
+	pr, canCheck := client.(vcs.PermissionReader)
+	if extract && !canCheck {
+		o.logger.Debug("vcs client can't check permissions, conventions aren't extracted", zap.String("forge", r.Forge))
+		extract = false
+	}
+	var isMaintainer func(login string) (bool, error)
+	if extract {
+		isMaintainer = maintainerCheck(ctx, pr, r.Owner, r.Repo)
+	}
+
+	for i, c := range r.Comments {
+		reactions, err := rf.ListCommentReactions(ctx, r.Owner, r.Repo, c.ID)
+		if err != nil {
+			return err
+		}
+		c.Up, c.Down = 0, 0
+		for _, re := range reactions {
+			switch re.Content {
+			case "+1":
+				c.Up++
+			case "-1":
+				c.Down++
+			}
+		}
+		// once dismissed it stays dismissed, even if later commits touch something else
+		c.ResolvedWithoutChange = c.ResolvedWithoutChange || resolved[c.ID] && head == r.CommitSHA
+		if extract && c.Positive() && !c.Extracted {
+			// anyone may react, only maintainers' votes turn a comment into a rule
+			up, down, err := maintainerVotes(reactions, isMaintainer)
+			if err != nil {
+				o.logger.Warn("failed to check reactions of maintainers", zap.Int64("comment", c.ID), zap.Error(err))
+			} else if up > down {
+				c.Extracted = o.extractConvention(ctx, r, c, cfg)
+			}
+		}
+		r.Comments[i] = c
+	}
+	return o.feedback.Update(r)
+}
+
+// avoidExamples the most downvoted comments of the repository the model is asked not to repeat
+func (o *Orchestrator) avoidExamples(repo vcs.Repository, cfg config.FeedbackConfig) []string {
+	if o.feedback == nil || cfg.AvoidExamples == 0 {
+		return nil
+	}
+	var examples []string
+	for _, c := range o.feedback.Downvoted(repo.Owner, repo.Name, cfg.AvoidExamples) {
+		body, _, _ := strings.Cut(c.Body, "\n")
+		// cut by runes, a byte cut could split a character of a non-ASCII comment
+		if r := []rune(body); len(r) > maxAvoidExample {
+			body = string(r[:maxAvoidExample]) + "…"
+		}
+		examples = append(examples, fmt.Sprintf("[%s] %s", c.Category, body))
+	}
+	return examples
+}
+
+// maxAvoidExample runes of a comment quoted as an example to avoid
+const maxAvoidExample = 200
+
+// FeedbackLoop collects feedback every interval, it's an app module
+type FeedbackLoop struct {
+	o        *Orchestrator
+	interval time.Duration
+	stop     chan struct{}
+	done     chan struct{}
+}
+
+func NewFeedbackLoop(o *Orchestrator, interval time.Duration) *FeedbackLoop {
+	return &FeedbackLoop{o: o, interval: interval, stop: make(chan struct{}), done: make(chan struct{})}
+}
+
+func (l *FeedbackLoop) Run(ctx context.Context) error {
+	defer close(l.done)
+
+	// metrics reflect the stored history right away, not after the first interval
+	setFeedbackMetrics(l.o.feedback.Stats())
+
+	ticker := time.NewTicker(l.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-l.stop:
+			return nil
+		case <-ticker.C:
+			l.o.CollectFeedback(ctx)
+		}
+	}
+}
+
+func (l *FeedbackLoop) Stop(ctx context.Context) error {
+	close(l.stop)
+	select {
+	case <-l.done:
+	case <-ctx.Done():
+	}
+	return nil
+}
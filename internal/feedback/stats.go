@@ -0,0 +1,70 @@
+package feedback
+
+// Precision of comments with a signal: positive over positive and negative ones
+type Precision struct {
+	Comments              int     `json:"comments"`
+	Upvoted               int     `json:"upvoted"`
+	Downvoted             int     `json:"downvoted"`
+	ResolvedWithoutChange int     `json:"resolved_without_change"`
+	Positive              int     `json:"positive"`
+	Negative              int     `json:"negative"`
+	Precision             float64 `json:"precision"`
+}
+
+func (p *Precision) add(c Comment) {
+	p.Comments++
+	if c.Up > 0 {
+		p.Upvoted++
+	}
+	if c.Down > 0 {
+		p.Downvoted++
+	}
+	if c.ResolvedWithoutChange {
+		p.ResolvedWithoutChange++
+	}
+	if c.Positive() {
+		p.Positive++
+	}
+	if c.Negative() {
+		p.Negative++
+	}
+	if signals := p.Positive + p.Negative; signals > 0 {
+		p.Precision = float64(p.Positive) / float64(signals)
+	}
+}
+
+type Stats struct {
+	Total           Precision            `json:"total"`
+	ByCategory      map[string]Precision `json:"by_category"`
+	ByModel         map[string]Precision `json:"by_model"`
+	ByConfigVersion map[string]Precision `json:"by_config_version"`
+	ByRepo          map[string]Precision `json:"by_repo"`
+}
+
+// Stats aggregates all comments the store keeps
+func (s *Store) Stats() Stats {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	st := Stats{
+		ByCategory:      make(map[string]Precision),
+		ByModel:         make(map[string]Precision),
+		ByConfigVersion: make(map[string]Precision),
+		ByRepo:          make(map[string]Precision),
+	}
+	add := func(m map[string]Precision, key string, c Comment) {
+		p := m[key]
+		p.add(c)
+		m[key] = p
+	}
+	for _, r := range s.reviews {
+		for _, c := range r.Comments {
+			st.Total.add(c)
+			add(st.ByCategory, c.Category, c)
+			add(st.ByModel, r.Model, c)
+			add(st.ByConfigVersion, r.ConfigVersion, c)
+			add(st.ByRepo, r.Owner+"/"+r.Repo, c)
+		}
+	}
+	return st
+}
@@ -0,0 +1,53 @@
+package feedback
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestStats(t *testing.T) {
+	s, err := NewStore(filepath.Join(t.TempDir(), "feedback.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	reviews := []Review{
+		{Owner: "o", Repo: "a", Model: "m1", ConfigVersion: "v1", Comments: []Comment{
+			{Category: "naming", Up: 2},
+			{Category: "naming", Up: 1, Down: 1},
+			{Category: "bug", Up: 1, ResolvedWithoutChange: true},
+		}},
+		{Owner: "o", Repo: "b", Model: "m2", ConfigVersion: "v1", Comments: []Comment{
+			{Category: "bug", Down: 2},
+			{Category: "bug", Up: 3},
+		}},
+	}
+	for _, r := range reviews {
+		if err := s.Track(r); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	st := s.Stats()
+	want := Precision{
+		Comments: 5, Upvoted: 4, Downvoted: 2, ResolvedWithoutChange: 1, Positive: 2, Negative: 2, Precision: 0.5,
+	}
+	if st.Total != want {
+		t.Errorf("total = %+v, want %+v", st.Total, want)
+	}
+	// a tie has no signal, a dismissed comment is negative whatever its votes
+	if p := st.ByCategory["naming"]; p.Positive != 1 || p.Negative != 0 || p.Precision != 1 {
+		t.Errorf("naming = %+v", p)
+	}
+	if p := st.ByCategory["bug"]; p.Comments != 3 || p.Positive != 1 || p.Negative != 2 {
+		t.Errorf("bug = %+v", p)
+	}
+	if p := st.ByModel["m2"]; p.Comments != 2 || p.Precision != 0.5 {
+		t.Errorf("m2 = %+v", p)
+	}
+	if p := st.ByConfigVersion["v1"]; p.Comments != 5 {
+		t.Errorf("v1 = %+v", p)
+	}
+	if p := st.ByRepo["o/a"]; p.Comments != 3 || p.Positive != 1 || p.Negative != 1 {
+		t.Errorf("o/a = %+v", p)
+	}
+}
@@ -0,0 +1,170 @@
+package feedback
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Review a posted review whose comments are watched for feedback
+type Review struct {
+	Forge string `json:"forge"`
+	// RepoURL html url of the repository, the vcs client is resolved by its host
+	RepoURL       string    `json:"repo_url"`
+	Owner         string    `json:"owner"`
+	Repo          string    `json:"repo"`
+	PR            int       `json:"pr"`
+	ID            int64     `json:"id"`
+	CommitSHA     string    `json:"commit_sha"`
+	Model         string    `json:"model"`
+	ConfigVersion string    `json:"config_version"`
+	PostedAt      time.Time `json:"posted_at"`
+	Comments      []Comment `json:"comments"`
+}
+
+type Comment struct {
+	ID       int64  `json:"id"`
+	Path     string `json:"path"`
+	Line     int    `json:"line"`
+	Category string `json:"category"`
+	Body     string `json:"body"`
+	Up       int    `json:"up"`
+	Down     int    `json:"down"`
+	// ResolvedWithoutChange the thread was resolved while the pull request head stayed at the reviewed commit
+	ResolvedWithoutChange bool `json:"resolved_without_change"`
+	// Extracted a convention was looked for in the comment once it was accepted
+	Extracted bool `json:"extracted,omitempty"`
+}
+
+// Positive the comment was upvoted more than downvoted
+func (c Comment) Positive() bool {
+	return c.Up > c.Down && !c.ResolvedWithoutChange
+}
+
+// Negative the comment was downvoted or dismissed without a change
+func (c Comment) Negative() bool {
+	return c.Down > c.Up || c.ResolvedWithoutChange
+}
+
+// defaultMaxReviews reviews kept in the file, it's rewritten as a whole on every change
+const defaultMaxReviews = 5000
+
+// Store keeps reviews in a JSON file, rewritten as a whole on every change. Only the newest maxReviews
+// reviews are kept, stats and examples to avoid are built from them.
+type Store struct {
+	path       string
+	maxReviews int
+
+	mu      sync.RWMutex
+	reviews []Review
+}
+
+func NewStore(path string) (*Store, error) {
+	s := &Store{path: path, maxReviews: defaultMaxReviews}
+	b, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read feedback store: %w", err)
+	}
+	if err := json.Unmarshal(b, &s.reviews); err != nil {
+		return nil, fmt.Errorf("unmarshal feedback store: %w", err)
+	}
+	return s, nil
+}
+
+// WithMaxReviews keeps only the newest n reviews, the oldest are dropped by the next Track
+func (s *Store) WithMaxReviews(n int) *Store {
+	s.maxReviews = n
+	return s
+}
+
+// Track starts watching the review, the oldest reviews over the limit are dropped
+func (s *Store) Track(r Review) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.reviews = append(s.reviews, r)
+	if over := len(s.reviews) - s.maxReviews; over > 0 {
+		s.reviews = slices.Delete(s.reviews, 0, over)
+	}
+	return s.save()
+}
+
+// Watched returns reviews posted after since, they're still collected
+func (s *Store) Watched(since time.Time) []Review {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var watched []Review
+	for _, r := range s.reviews {
+		if r.PostedAt.After(since) {
+			r.Comments = slices.Clone(r.Comments)
+			watched = append(watched, r)
+		}
+	}
+	return watched
+}
+
+// Update replaces the comments of the review with the same forge, repository and id
+func (s *Store) Update(r Review) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, cur := range s.reviews {
+		if cur.Forge == r.Forge && cur.RepoURL == r.RepoURL && cur.ID == r.ID {
+			s.reviews[i].Comments = r.Comments
+			return s.save()
+		}
+	}
+	return fmt.Errorf("review %d of %s is not tracked", r.ID, r.RepoURL)
+}
+
+// Downvoted returns up to n comments of the repository with the most downvotes, most downvoted first
+func (s *Store) Downvoted(owner string, repo string, n int) []Comment {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var found []Comment
+	for _, r := range s.reviews {
+		if r.Owner != owner || r.Repo != repo {
+			continue
+		}
+		for _, c := range r.Comments {
+			if c.Negative() {
+				found = append(found, c)
+			}
+		}
+	}
+	slices.SortStableFunc(found, func(a, b Comment) int {
+		return cmp.Compare(b.Down-b.Up, a.Down-a.Up)
+	})
+	return found[:min(n, len(found))]
+}
+
+func (s *Store) save() error {
+	b, err := json.Marshal(s.reviews)
+	if err != nil {
+		return fmt.Errorf("marshal feedback store: %w", err)
+	}
+	// a crash mid-write mustn't lose the collected history
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".*")
+	if err != nil {
+		return fmt.Errorf("write feedback store: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(b); err != nil {
+		tmp.Close()
+		return fmt.Errorf("write feedback store: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("write feedback store: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return fmt.Errorf("write feedback store: %w", err)
+	}
+	return nil
+}
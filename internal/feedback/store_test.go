@@ -0,0 +1,88 @@
+package feedback
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "feedback.json")
+	s, err := NewStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	old := Review{
+		Forge: "gitea", RepoURL: "https://git/o/r", Owner: "o", Repo: "r", ID: 1,
+		PostedAt: now.Add(-30 * 24 * time.Hour),
+		Comments: []Comment{{ID: 10, Body: "old", Down: 3}},
+	}
+	recent := Review{
+		Forge: "gitea", RepoURL: "https://git/o/r", Owner: "o", Repo: "r", ID: 2,
+		PostedAt: now,
+		Comments: []Comment{{ID: 20, Body: "recent"}, {ID: 21, Body: "other"}},
+	}
+	for _, r := range []Review{old, recent} {
+		if err := s.Track(r); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	watched := s.Watched(now.Add(-14 * 24 * time.Hour))
+	if len(watched) != 1 || watched[0].ID != 2 {
+		t.Fatalf("unexpected watched reviews: %+v", watched)
+	}
+	// watched reviews are copies, they're only stored by Update
+	watched[0].Comments[0].Down = 5
+	if got := s.Downvoted("o", "r", 5); len(got) != 1 || got[0].Body != "old" {
+		t.Errorf("a watched copy changed the store: %+v", got)
+	}
+
+	watched[0].Comments[1].ResolvedWithoutChange = true
+	if err := s.Update(watched[0]); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	if err := s.Update(Review{Forge: "gitea", RepoURL: "https://git/o/r", ID: 3}); err == nil {
+		t.Error("untracked review was updated")
+	}
+
+	// reopened from the file, the most downvoted first, dismissed comments count as downvoted
+	reopened, err := NewStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := reopened.Downvoted("o", "r", 5)
+	if len(got) != 3 || got[0].Body != "recent" || got[1].Body != "old" || got[2].Body != "other" {
+		t.Errorf("unexpected downvoted comments: %+v", got)
+	}
+	if got := reopened.Downvoted("o", "r", 1); len(got) != 1 {
+		t.Errorf("downvoted comments aren't limited: %+v", got)
+	}
+	if got := reopened.Downvoted("o", "other", 5); len(got) != 0 {
+		t.Errorf("comments of another repository: %+v", got)
+	}
+}
+
+func TestStoreDropsOldestReviews(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "feedback.json")
+	s, err := NewStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.WithMaxReviews(2)
+	for id := int64(1); id <= 3; id++ {
+		if err := s.Track(Review{ID: id, PostedAt: time.Now()}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	reopened, err := NewStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	watched := reopened.Watched(time.Time{})
+	if len(watched) != 2 || watched[0].ID != 2 || watched[1].ID != 3 {
+		t.Errorf("unexpected reviews kept: %+v", watched)
+	}
+}
@@ -0,0 +1,173 @@
+package internal
+
+import (
+	"context"
+	"go-snob/internal/actor/ai"
+	"go-snob/internal/actor/vcs"
+	"go-snob/internal/config"
+	"go-snob/internal/feedback"
+	"go-snob/pkg/hotreload"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"go.uber.org/zap"
+)
+
+func TestAvoidExamplesCutByRunes(t *testing.T) {
+	store, err := feedback.NewStore(filepath.Join(t.TempDir(), "feedback.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = store.Track(feedback.Review{Owner: "o", Repo: "r", Comments: []feedback.Comment{
+		{Category: "naming", Body: strings.Repeat("я", maxAvoidExample+50) + "\nsecond line", Down: 2},
+		{Category: "tests", Body: "short", Down: 1},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	o := &Orchestrator{feedback: store}
+	examples := o.avoidExamples(vcs.Repository{Owner: "o", Name: "r"}, config.FeedbackConfig{AvoidExamples: 5})
+	if len(examples) != 2 {
+		t.Fatalf("unexpected examples: %q", examples)
+	}
+	if !utf8.ValidString(examples[0]) {
+		t.Errorf("example was cut inside a character: %q", examples[0])
+	}
+	if want := "[naming] " + strings.Repeat("я", maxAvoidExample) + "…"; examples[0] != want {
+		t.Errorf("unexpected example: %q", examples[0])
+	}
+	if examples[1] != "[tests] short" {
+		t.Errorf("unexpected example: %q", examples[1])
+	}
+}
+
+// feedbackClient reports reactions and resolutions of posted comments
+type feedbackClient struct {
+	issueClient
+	head      string
+	posted    []vcs.PostedComment
+	reactions map[int64][]vcs.Reaction
+}
+
+func (c *feedbackClient) CreateReviewWithID(context.Context, string, string, int, vcs.Review) (int64, error) {
+	return 7, nil
+}
+
+func (c *feedbackClient) ListReviewComments(context.Context, string, string, int, int64) ([]vcs.PostedComment, error) {
+	return c.posted, nil
+}
+
+func (c *feedbackClient) ListCommentReactions(_ context.Context, _ string, _ string, id int64) ([]vcs.Reaction, error) {
+	return c.reactions[id], nil
+}
+
+func (c *feedbackClient) PullRequestHead(context.Context, string, string, int) (string, error) {
+	return c.head, nil
+}
+
+// newTestConfig a config store over the minimal config followed by extra
+func newTestConfig(t *testing.T, extra string) *hotreload.Store[config.Config] {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	yaml := "system_prompt: review\ngitea:\n  - name: local\n    base_url: http://localhost/api/v1\n" +
+		"ai:\n  url: http://localhost/v1\n" + extra
+	if err := os.WriteFile(path, []byte(yaml), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := hotreload.NewStore(path, config.Parse, zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cfg
+}
+
+func TestNewFeedbackReviewMatchesCategories(t *testing.T) {
+	e := vcs.Event{
+		Forge:       vcs.ForgeGitea,
+		Repository:  vcs.Repository{Owner: "o", Name: "r"},
+		PullRequest: vcs.PullRequest{Number: 3, HeadSHA: "head"},
+	}
+	r := ai.AIReviewResult{Model: "m", Comments: []ai.Comment{
+		{File: "a.go", Message: "x races", Category: "bug"},
+		{File: "b.go", Message: "x races", Category: "concurrency"},
+		{File: "a.go", Message: "rename x", Category: "naming"},
+	}}
+	posted := []vcs.PostedComment{
+		{ID: 1, Path: "b.go", Line: 4, Body: "x races"},
+		{ID: 2, Path: "a.go", OldLine: 9, Body: "rename x"},
+		{ID: 3, Path: "a.go", Line: 1, Body: "edited on the forge"},
+	}
+
+	fr := newFeedbackReview(e, 7, r, posted, "v1")
+
+	if fr.ID != 7 || fr.PR != 3 || fr.CommitSHA != "head" || fr.Model != "m" || fr.ConfigVersion != "v1" {
+		t.Errorf("unexpected review: %+v", fr)
+	}
+	want := []feedback.Comment{
+		{ID: 1, Path: "b.go", Line: 4, Category: "concurrency", Body: "x races"},
+		// a comment on a removed line keeps its old line
+		{ID: 2, Path: "a.go", Line: 9, Category: "naming", Body: "rename x"},
+		{ID: 3, Path: "a.go", Line: 1, Body: "edited on the forge"},
+	}
+	if !slices.Equal(fr.Comments, want) {
+		t.Errorf("comments = %+v, want %+v", fr.Comments, want)
+	}
+}
+
+func TestCollectReviewResolvedWithoutChange(t *testing.T) {
+	tests := []struct {
+		name string
+		head string
+		want bool
+	}{
+		{name: "same head", head: "abc", want: true},
+		{name: "new commit", head: "def", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store, err := feedback.NewStore(filepath.Join(t.TempDir(), "feedback.json"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			r := feedback.Review{
+				Forge: string(vcs.ForgeGitea), Owner: "o", Repo: "r", PR: 3, ID: 7, CommitSHA: "abc",
+				PostedAt: time.Now(),
+				Comments: []feedback.Comment{{ID: 1}, {ID: 2}},
+			}
+			if err := store.Track(r); err != nil {
+				t.Fatal(err)
+			}
+			client := &feedbackClient{
+				head:   tt.head,
+				posted: []vcs.PostedComment{{ID: 1, Resolved: true}, {ID: 2}},
+				reactions: map[int64][]vcs.Reaction{
+					2: {{User: "a", Content: "+1"}, {User: "b", Content: "+1"}, {User: "c", Content: "-1"}},
+				},
+			}
+			o := NewOrchestrator(
+				nil,
+				vcs.NewRegistry().Register(vcs.ForgeGitea, "", client),
+				newTestConfig(t, ""),
+				zap.NewNop(),
+			).WithFeedback(store)
+
+			if err := o.collectReview(context.Background(), r); err != nil {
+				t.Fatal(err)
+			}
+
+			got := store.Watched(time.Time{})[0].Comments
+			if got[0].ResolvedWithoutChange != tt.want {
+				t.Errorf("resolved without change = %v, want %v", got[0].ResolvedWithoutChange, tt.want)
+			}
+			if got[1].ResolvedWithoutChange || got[1].Up != 2 || got[1].Down != 1 {
+				t.Errorf("unexpected unresolved comment: %+v", got[1])
+			}
+		})
+	}
+}
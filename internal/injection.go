@@ -0,0 +1,55 @@
+package internal
+
+import (
+	"fmt"
+	"go-snob/internal/actor/ai"
+	"go-snob/internal/actor/vcs"
+	"go-snob/internal/diff"
+	"go-snob/internal/prompt"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+// maxShownMarkers markers quoted in the summary, the rest is only logged
+const maxShownMarkers = 3
+
+// injectionMarkers suspected prompt injections in texts and lines the diff adds. Removed and context
+// lines aren't checked, they don't come from the author of the change.
+func injectionMarkers(rawDiff string, texts ...string) []string {
+	for _, f := range diff.Parse(rawDiff) {
+		for _, h := range f.Hunks {
+			for _, l := range h.Lines {
+				if l.Kind == diff.LineAdded {
+					texts = append(texts, l.Content)
+				}
+			}
+		}
+	}
+	return prompt.DetectInjection(texts...)
+}
+
+// enforcePolicy keeps the review within what the model may decide. A review of a suspected injection
+// attempt is never an approval: the attempt is reported in the summary and the verdict is COMMENT.
+func enforcePolicy(logger *zap.Logger, r ai.AIReviewResult, markers []string) ai.AIReviewResult {
+	switch vcs.Verdict(r.Verdict) {
+	case vcs.VerdictApproved, vcs.VerdictRequestChanges, vcs.VerdictComment:
+	default:
+		logger.Warn("model returned unknown verdict, using COMMENT", zap.String("verdict", r.Verdict))
+		r.Verdict = string(vcs.VerdictComment)
+	}
+	if len(markers) == 0 {
+		return r
+	}
+
+	logger.Warn("suspected prompt injection, forcing COMMENT",
+		zap.Strings("markers", markers), zap.String("verdict", r.Verdict))
+	shown := make([]string, 0, maxShownMarkers)
+	for _, m := range markers[:min(len(markers), maxShownMarkers)] {
+		shown = append(shown, fmt.Sprintf("%q", m))
+	}
+	r.Verdict = string(vcs.VerdictComment)
+	r.Summary = fmt.Sprintf("⚠️ **Possible prompt injection**: the change contains text addressed to the reviewer "+
+		"(%s). The verdict is limited to COMMENT, a human review is needed.\n\n%s", strings.Join(shown, ", "), r.Summary)
+	return r
+}
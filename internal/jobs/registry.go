@@ -0,0 +1,123 @@
+package jobs
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// tombstoneTTL how long a cancellation is remembered to drop jobs still waiting in the queue
+const tombstoneTTL = time.Hour
+
+// Key identifies a pull request across forges and instances
+type Key struct {
+	Forge  string
+	Host   string
+	Owner  string
+	Repo   string
+	Number int
+}
+
+type job struct {
+	receivedAt time.Time
+	cancel     context.CancelFunc
+}
+
+// Registry tracks running jobs per pull request so they can be cancelled by later events.
+// Events are ordered by the time they were received, not by the time a worker picked them up.
+type Registry struct {
+	mu        sync.Mutex
+	running   map[Key]*job
+	cancelled map[Key]time.Time
+}
+
+func NewRegistry() *Registry {
+	return &Registry{
+		running:   make(map[Key]*job),
+		cancelled: make(map[Key]time.Time),
+	}
+}
+
+// Start registers a job for key and cancels the running one if it was started by an older event.
+// ok is false if the job was cancelled or superseded before it started, done must be called once
+// the job is finished otherwise.
+func (r *Registry) Start(ctx context.Context, key Key, receivedAt time.Time) (context.Context, func(), bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if at, ok := r.cancelled[key]; ok {
+		if receivedAt.Before(at) {
+			return nil, nil, false
+		}
+		delete(r.cancelled, key)
+	}
+
+	if prev, ok := r.running[key]; ok {
+		if receivedAt.Before(prev.receivedAt) {
+			return nil, nil, false
+		}
+		prev.cancel()
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	j := &job{receivedAt: receivedAt, cancel: cancel}
+	r.running[key] = j
+
+	done := func() {
+		cancel()
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		if r.running[key] == j {
+			delete(r.running, key)
+		}
+	}
+	return ctx, done, true
+}
+
+// Cancel stops the running job of key and drops queued ones received before at. It reports whether
+// a running job was cancelled.
+func (r *Registry) Cancel(key Key, at time.Time) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.prune(at)
+	if prev, ok := r.cancelled[key]; !ok || at.After(prev) {
+		r.cancelled[key] = at
+	}
+
+	j, ok := r.running[key]
+	if !ok || j.receivedAt.After(at) {
+		return false
+	}
+	j.cancel()
+	delete(r.running, key)
+	return true
+}
+
+// Supersede cancels the running job of key if it was started by an event received before at, queued
+// jobs are dropped only then. Without a running job nothing changes, a queued job reviews the latest
+// head anyway. It reports whether a running job was cancelled.
+func (r *Registry) Supersede(key Key, at time.Time) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	j, ok := r.running[key]
+	if !ok || j.receivedAt.After(at) {
+		return false
+	}
+	r.prune(at)
+	if prev, ok := r.cancelled[key]; !ok || at.After(prev) {
+		r.cancelled[key] = at
+	}
+	j.cancel()
+	delete(r.running, key)
+	return true
+}
+
+func (r *Registry) prune(now time.Time) {
+	for k, at := range r.cancelled {
+		if now.Sub(at) > tombstoneTTL {
+			delete(r.cancelled, k)
+		}
+	}
+}
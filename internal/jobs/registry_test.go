@@ -0,0 +1,47 @@
+package jobs
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestRegistrySupersede(t *testing.T) {
+	key := Key{Forge: "gitea", Owner: "o", Repo: "r", Number: 1}
+	t0 := time.Now()
+
+	r := NewRegistry()
+	if r.Supersede(key, t0.Add(time.Second)) {
+		t.Fatal("nothing was running")
+	}
+	// a queued job survives a push when nothing was running
+	_, done, ok := r.Start(context.Background(), key, t0)
+	if !ok {
+		t.Fatal("queued job was dropped without a running one")
+	}
+
+	if !r.Supersede(key, t0.Add(time.Second)) {
+		t.Fatal("running job was not cancelled")
+	}
+	done()
+	if _, _, ok := r.Start(context.Background(), key, t0); ok {
+		t.Error("job queued before the push must be dropped")
+	}
+	if _, _, ok := r.Start(context.Background(), key, t0.Add(2*time.Second)); !ok {
+		t.Error("job queued after the push must start")
+	}
+}
+
+func TestRegistrySupersedeNewerJob(t *testing.T) {
+	key := Key{Forge: "gitea", Owner: "o", Repo: "r", Number: 1}
+	t0 := time.Now()
+
+	r := NewRegistry()
+	ctx, _, _ := r.Start(context.Background(), key, t0.Add(time.Second))
+	if r.Supersede(key, t0) {
+		t.Error("job started by a newer event was cancelled")
+	}
+	if ctx.Err() != nil {
+		t.Error("context of the newer job was cancelled")
+	}
+}
@@ -0,0 +1,42 @@
+package internal
+
+import (
+	"go-snob/internal/feedback"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// verifiedComments the rejection rate is rejected over all results, unverified comments are dropped as well
+// unless verification.on_error is "post"
+var verifiedComments = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Namespace: "go-snob",
+		Name:      "verified_comments_total",
+		Help:      "Review comments by verification result: confirmed, rejected or unverified when the check failed or skipped the comment",
+	},
+	[]string{"result"},
+)
+
+// feedbackComments tracked review comments by the feedback they got: positive, negative or none
+var feedbackComments = prometheus.NewGaugeVec(
+	prometheus.GaugeOpts{
+		Namespace: "go-snob",
+		Name:      "feedback_comments",
+		Help:      "Tracked review comments by category and feedback signal: positive, negative or none",
+	},
+	[]string{"category", "signal"},
+)
+
+func init() {
+	prometheus.MustRegister(verifiedComments, feedbackComments)
+}
+
+// setFeedbackMetrics precision of a category is positive over positive and negative comments
+func setFeedbackMetrics(st feedback.Stats) {
+	feedbackComments.Reset()
+	for category, p := range st.ByCategory {
+		feedbackComments.WithLabelValues(category, "positive").Set(float64(p.Positive))
+		feedbackComments.WithLabelValues(category, "negative").Set(float64(p.Negative))
+		feedbackComments.WithLabelValues(category, "none").Set(float64(p.Comments - p.Positive - p.Negative))
+	}
+}
@@ -0,0 +1,151 @@
+package internal
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go-snob/internal/actor/ai"
+	"go-snob/internal/actor/vcs"
+	"go-snob/internal/config"
+	"go-snob/internal/diff"
+	"go-snob/internal/prompt"
+	"slices"
+	"strings"
+	"sync"
+
+	"go.uber.org/zap"
+	"golang.org/x/sync/errgroup"
+)
+
+// multiPassReview reviews every file on its own, then a lead reviewer pass deduplicates the candidate
+// comments, drops false positives, adds cross-file findings and writes the verdict
+func (o *Orchestrator) multiPassReview(
+	ctx context.Context,
+	logger *zap.Logger,
+	rawDiff string,
+	data prompt.Data,
+	cfg config.Config,
+	progress *progressComment,
+) (ai.AIReviewResult, error) {
+	files := diff.Parse(rawDiff)
+	var (
+		mu         sync.Mutex
+		candidates []ai.Comment
+		reviewed   int
+		skipped    []string
+		errs       []error
+	)
+	g := errgroup.Group{}
+	g.SetLimit(cfg.MultiPass.Concurrency)
+	for _, f := range files {
+		if f.Binary || len(f.Hunks) == 0 {
+			continue
+		}
+		g.Go(func() error {
+			comments, err := o.reviewFile(ctx, f, data, cfg)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				// the other files are still worth synthesizing
+				logger.Warn("file pass failed", zap.String("file", f.Path()), zap.Error(err))
+				skipped = append(skipped, f.Path())
+				errs = append(errs, fmt.Errorf("%s: %w", f.Path(), err))
+				return nil
+			}
+			reviewed++
+			candidates = append(candidates, comments...)
+			progress.update(ai.AIReviewResult{Comments: slices.Clone(candidates)})
+			return nil
+		})
+	}
+	_ = g.Wait()
+	if ctx.Err() != nil {
+		return ai.AIReviewResult{}, ctx.Err()
+	}
+	logger.Info("file passes done", zap.Int("candidates", len(candidates)), zap.Int("failed", len(skipped)))
+	if reviewed == 0 && len(skipped) > 0 {
+		return ai.AIReviewResult{}, fmt.Errorf("every file pass failed: %w", errors.Join(errs...))
+	}
+
+	raw, err := json.Marshal(candidates)
+	if err != nil {
+		return ai.AIReviewResult{}, fmt.Errorf("marshal candidates: %w", err)
+	}
+	data.Candidates = prompt.Fence("comments", string(raw))
+	data.DiffSummary = prompt.Fence("diff-summary", diffSummary(files))
+
+	systemPrompt, userPrompt, err := prompt.RenderMessages(
+		cfg.MultiPass.SynthesisSystemPromptTemplate(),
+		cfg.MultiPass.SynthesisUserPromptTemplate(),
+		data,
+	)
+	if err != nil {
+		return ai.AIReviewResult{}, err
+	}
+	r, err := o.aiClient.Send(ctx, systemPrompt, userPrompt)
+	if err != nil {
+		return ai.AIReviewResult{}, err
+	}
+	return withSkippedFiles(r, skipped), nil
+}
+
+// withSkippedFiles a change isn't approved with files nobody reviewed, the summary names them
+func withSkippedFiles(r ai.AIReviewResult, skipped []string) ai.AIReviewResult {
+	if len(skipped) == 0 {
+		return r
+	}
+	if vcs.Verdict(r.Verdict) == vcs.VerdictApproved {
+		r.Verdict = string(vcs.VerdictComment)
+	}
+	skipped = slices.Sorted(slices.Values(skipped))
+	r.Summary += "\n\n⚠️ **Not reviewed**: the review of these files failed, they need a human look: `" +
+		strings.Join(skipped, "`, `") + "`"
+	return r
+}
+
+// reviewFile runs the review prompts over one file, only comments of the answer are kept
+func (o *Orchestrator) reviewFile(ctx context.Context, f diff.File, data prompt.Data, cfg config.Config) ([]ai.Comment, error) {
+	data.File = prompt.File{
+		Path:      f.Path(),
+		OldPath:   f.OldPath,
+		Status:    string(f.Status),
+		Additions: f.Additions,
+		Deletions: f.Deletions,
+	}
+	data.Diff = prompt.Fence("diff", f.Raw)
+	systemPrompt, userPrompt, err := prompt.RenderReviewMessages(cfg.SystemPromptTemplate(), cfg.UserPromptTemplate(), data)
+	if err != nil {
+		return nil, err
+	}
+
+	// small files may be routed to a cheaper model
+	ctx = ai.WithTraits(ctx, aiTraits([]prompt.File{data.File}))
+	review, err := o.aiClient.Send(ctx, systemPrompt, userPrompt)
+	if err != nil {
+		return nil, err
+	}
+
+	comments := review.Comments[:0]
+	for _, c := range review.Comments {
+		// the model sometimes points at other files it guesses about, it hasn't seen them
+		if c.File == "" || c.File == data.File.Path {
+			c.File = data.File.Path
+			comments = append(comments, c)
+		}
+	}
+	return comments, nil
+}
+
+// diffSummary lists changed files with their hunk headers, git puts the enclosing function there
+func diffSummary(files []diff.File) string {
+	var b strings.Builder
+	for _, f := range files {
+		fmt.Fprintf(&b, "%s (%s, +%d -%d)\n", f.Path(), f.Status, f.Additions, f.Deletions)
+		for _, h := range f.Hunks {
+			fmt.Fprintf(&b, "  %s\n", h.Header)
+		}
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
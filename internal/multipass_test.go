@@ -0,0 +1,142 @@
+package internal
+
+import (
+	"context"
+	"go-snob/internal/actor/ai"
+	"go-snob/internal/actor/vcs"
+	"go-snob/internal/testkit"
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+	"resty.dev/v3"
+)
+
+func TestWithSkippedFiles(t *testing.T) {
+	tests := []struct {
+		verdict string
+		skipped []string
+		want    string
+	}{
+		{verdict: "APPROVED", want: "APPROVED"},
+		{verdict: "APPROVED", skipped: []string{"b.go", "a.go"}, want: "COMMENT"},
+		{verdict: "REQUEST_CHANGES", skipped: []string{"a.go"}, want: "REQUEST_CHANGES"},
+	}
+	for _, tt := range tests {
+		r := withSkippedFiles(ai.AIReviewResult{Verdict: tt.verdict, Summary: "ok"}, tt.skipped)
+		if r.Verdict != tt.want {
+			t.Errorf("verdict %s with %v skipped = %s, want %s", tt.verdict, tt.skipped, r.Verdict, tt.want)
+		}
+		if len(tt.skipped) == 0 && r.Summary != "ok" {
+			t.Errorf("summary changed without skipped files: %q", r.Summary)
+		}
+		if len(tt.skipped) == 2 && r.Summary != "ok\n\n⚠️ **Not reviewed**: the review of these files failed, "+
+			"they need a human look: `a.go`, `b.go`" {
+			t.Errorf("unexpected summary: %q", r.Summary)
+		}
+	}
+}
+
+const multiPassDiff = `diff --git a/a.go b/a.go
+--- a/a.go
++++ b/a.go
+@@ -1,1 +1,2 @@
+ package a
++var x = 1
+diff --git a/b.go b/b.go
+--- a/b.go
++++ b/b.go
+@@ -1,1 +1,2 @@
+ package b
++var y = x
+diff --git a/c.go b/c.go
+--- a/c.go
++++ b/c.go
+@@ -1,1 +1,2 @@
+ package c
++var z = 1
+`
+
+// reviewClient a forge serving a diff and keeping the posted reviews
+type reviewClient struct {
+	issueClient
+	diff    string
+	reviews []vcs.Review
+}
+
+func (c *reviewClient) GetDiff(context.Context, string, string, int) (string, error) {
+	return c.diff, nil
+}
+
+func (c *reviewClient) CreateReview(_ context.Context, _ string, _ string, _ int, r vcs.Review) error {
+	c.reviews = append(c.reviews, r)
+	return nil
+}
+
+func TestMultiPassReview(t *testing.T) {
+	// file passes run one at a time in diff order, c.go fails, the last request is the synthesis
+	llm := testkit.NewLLM(t).
+		ReplyJSON(t, testkit.FormatReview, ai.AIReviewResult{Verdict: "APPROVED", Comments: []ai.Comment{
+			{NewPosition: 2, Message: "x is never used", Severity: "low"},
+			{File: "other.go", NewPosition: 1, Message: "guess about other.go", Severity: "low"},
+		}}).
+		ReplyJSON(t, testkit.FormatReview, ai.AIReviewResult{Verdict: "APPROVED", Comments: []ai.Comment{
+			{File: "b.go", NewPosition: 2, Message: "x is used here", Severity: "low"},
+			{File: "b.go", NewPosition: 2, Message: "y copies x", Severity: "medium"},
+		}}).
+		Script(testkit.FormatReview, testkit.Response{Status: http.StatusBadRequest}).
+		ReplyJSON(t, testkit.FormatReview, ai.AIReviewResult{
+			Verdict: "APPROVED",
+			Summary: "fine",
+			Comments: []ai.Comment{
+				{File: "b.go", NewPosition: 2, Message: "y copies x, a.go and b.go share state", Severity: "medium"},
+			},
+		})
+	client := &reviewClient{diff: multiPassDiff}
+	o := NewOrchestrator(
+		ai.NewClient(resty.New(), zap.NewNop(), llm.URL(), "token"),
+		vcs.NewRegistry().Register(vcs.ForgeGitea, "", client),
+		newTestConfig(t, "reviewer_aliases: [snob]\nmulti_pass:\n  enabled: true\n  concurrency: 1\n"),
+		zap.NewNop(),
+	)
+
+	o.Handler(context.Background(), vcs.Event{
+		Forge:             vcs.ForgeGitea,
+		Action:            vcs.ActionReviewRequested,
+		RequestedReviewer: "snob",
+		Repository:        vcs.Repository{Owner: "octo", Name: "hello"},
+		PullRequest:       vcs.PullRequest{Number: 1, HeadSHA: "head"},
+	})
+
+	requests := llm.Requests()
+	if len(requests) != 4 {
+		t.Fatalf("requests = %d, want 3 file passes and the synthesis", len(requests))
+	}
+	synthesis := requests[3].Messages[len(requests[3].Messages)-1].Content
+	for _, s := range []string{"x is never used", "x is used here", "y copies x"} {
+		if !strings.Contains(synthesis, s) {
+			t.Errorf("synthesis didn't get candidate %q:\n%s", s, synthesis)
+		}
+	}
+	if strings.Contains(synthesis, "guess about other.go") {
+		t.Errorf("synthesis got a comment on a file the pass hadn't seen:\n%s", synthesis)
+	}
+
+	if len(client.reviews) != 1 {
+		t.Fatalf("reviews = %d, want 1", len(client.reviews))
+	}
+	r := client.reviews[0]
+	// duplicates and false positives are dropped by the synthesis, only its comments are posted
+	want := []vcs.ReviewComment{{Path: "b.go", NewLine: 2, Body: "y copies x, a.go and b.go share state"}}
+	if !slices.Equal(r.Comments, want) {
+		t.Errorf("comments = %+v, want %+v", r.Comments, want)
+	}
+	if r.Verdict != vcs.VerdictComment {
+		t.Errorf("verdict = %s, want COMMENT with c.go not reviewed", r.Verdict)
+	}
+	if !strings.Contains(r.Body, "fine") || !strings.Contains(r.Body, "`c.go`") {
+		t.Errorf("unexpected body: %q", r.Body)
+	}
+}
@@ -2,55 +2,344 @@ package internal
 
 import (
 	"context"
+	"fmt"
 	"go-snob/internal/actor/ai"
-	"go-snob/internal/actor/vcs/gitea"
-	"go-snob/pkg/giteawebhook"
+	"go-snob/internal/actor/vcs"
+	"go-snob/internal/capture"
+	"go-snob/internal/config"
+	"go-snob/internal/conventions"
+	"go-snob/internal/feedback"
+	"go-snob/internal/jobs"
+	"go-snob/internal/prompt"
+	"go-snob/pkg/hotreload"
+	"net/url"
+	"strings"
+	"sync"
 
 	"go.uber.org/zap"
 )
 
 type Orchestrator struct {
-	aiClient    *ai.Client
-	giteaClient *gitea.Client
-	logger      *zap.Logger
+	aiClient *ai.Client
+	clients  *vcs.Registry
+	cfg      *hotreload.Store[config.Config]
+	jobs     *jobs.Registry
+	logger   *zap.Logger
+
+	// changelogMu a *sync.Mutex per repository, changelog entries of one repository are written one at a time
+	changelogMu sync.Map
+	// feedback is nil unless feedback collection is on
+	feedback *feedback.Store
+	// conventions is nil unless the conventions store is on
+	conventions *conventions.Store
+	// secrets masked in capture bundles
+	secrets []string
+
+	version string
+}
+
+func NewOrchestrator(
+	ai *ai.Client,
+	clients *vcs.Registry,
+	cfg *hotreload.Store[config.Config],
+	logger *zap.Logger,
+) *Orchestrator {
+	return &Orchestrator{aiClient: ai, clients: clients, cfg: cfg, jobs: jobs.NewRegistry(), logger: logger}
+}
+
+// WithVersion app version printed in the review footer
+func (o *Orchestrator) WithVersion(version string) *Orchestrator {
+	o.version = version
+	return o
+}
+
+func (o *Orchestrator) Handler(ctx context.Context, e vcs.Event) {
+	// the whole job works with one config version even if it's reloaded meanwhile
+	cfg := o.cfg.Current()
+	logger := o.logger.With(
+		zap.String("forge", string(e.Forge)),
+		zap.String("repo", e.Repository.Owner+"/"+e.Repository.Name),
+		zap.Int("pr", e.PullRequest.Number),
+		zap.String("action", string(e.Action)),
+		zap.String("config_version", cfg.Version),
+	)
+	ctx, finish := o.startCapture(ctx, logger, capture.KindPullRequest, e, cfg)
+	defer finish()
+
+	switch e.Action {
+	case vcs.ActionOpened:
+		if cfg.Value.Description.Enabled {
+			o.describe(ctx, logger, e, cfg)
+		}
+	case vcs.ActionClosed:
+		if e.PullRequest.Merged {
+			o.changelog(ctx, logger, e, cfg)
+		}
+	case vcs.ActionReviewRequested:
+		if o.isSnobRequested(ctx, logger, e, cfg.Value) && isEligible(logger, e, cfg.Value) {
+			o.review(ctx, logger, e, cfg)
+		}
+	case vcs.ActionReadyForReview:
+		o.reviewWhenReady(ctx, logger, e, cfg, "pull request is ready for review")
+	case vcs.ActionEdited:
+		if leftWIP(e, cfg.Value.Eligibility) {
+			o.reviewWhenReady(ctx, logger, e, cfg, "work in progress prefix removed from title")
+		}
+	case vcs.ActionSynchronized:
+		// a running review of the previous head is stale, it's replaced with a review of the new one
+		if o.jobs.Supersede(newJobKey(e), e.ReceivedAt) && isEligible(logger, e, cfg.Value) {
+			logger.Info("review superseded by new commits, restarting")
+			o.review(ctx, logger, e, cfg)
+		}
+	case vcs.ActionReviewRequestRemoved:
+		if !o.isSnobRequested(ctx, logger, e, cfg.Value) {
+			return
+		}
+		if o.jobs.Cancel(newJobKey(e), e.ReceivedAt) {
+			logger.Info("review request removed, running review cancelled")
+			return
+		}
+		logger.Info("review request removed, queued reviews dropped")
+	}
+}
+
+// reviewWhenReady reviews a pull request which just left draft if the bot is among its reviewers
+func (o *Orchestrator) reviewWhenReady(
+	ctx context.Context,
+	logger *zap.Logger,
+	e vcs.Event,
+	cfg *hotreload.Snapshot[config.Config],
+	why string,
+) {
+	if !o.isSnob(ctx, logger, e, cfg.Value, e.PullRequest.RequestedReviewers...) {
+		logger.Debug("skipping ready pull request, bot is not a requested reviewer")
+		return
+	}
+	if !isEligible(logger, e, cfg.Value) {
+		return
+	}
+	logger.Info("starting deferred review", zap.String("reason", why))
+	o.review(ctx, logger, e, cfg)
+}
+
+func isEligible(logger *zap.Logger, e vcs.Event, cfg config.Config) bool {
+	if reason := skipReason(e.PullRequest, cfg.Eligibility); reason != "" {
+		logger.Info("skipping review", zap.String("reason", reason))
+		return false
+	}
+	return true
 }
 
-func NewOrchestrator(ai *ai.Client, gitea *gitea.Client, logger *zap.Logger) *Orchestrator {
-	return &Orchestrator{aiClient: ai, giteaClient: gitea, logger: logger}
+// isSnobRequested reports whether the review request is addressed to the bot itself or one of its aliases
+func (o *Orchestrator) isSnobRequested(ctx context.Context, logger *zap.Logger, e vcs.Event, cfg config.Config) bool {
+	if e.RequestedReviewer == "" {
+		logger.Info("skipping event without requested reviewer")
+		return false
+	}
+	if !o.isSnob(ctx, logger, e, cfg, e.RequestedReviewer) {
+		logger.Debug("skipping review request for another reviewer", zap.String("reviewer", e.RequestedReviewer))
+		return false
+	}
+	return true
+}
+
+// isSnob reports whether any of logins is the bot itself or one of its aliases
+func (o *Orchestrator) isSnob(
+	ctx context.Context,
+	logger *zap.Logger,
+	e vcs.Event,
+	cfg config.Config,
+	logins ...string,
+) bool {
+	if len(logins) == 0 {
+		return false
+	}
+
+	for _, login := range logins {
+		for _, alias := range cfg.ReviewerAliases {
+			if strings.EqualFold(alias, login) {
+				return true
+			}
+		}
+	}
+
+	me, err := o.clients.Identity(ctx, e)
+	if err != nil {
+		logger.Error("failed to resolve own identity", zap.Error(err))
+		return false
+	}
+	for _, login := range logins {
+		if strings.EqualFold(me.Login, login) {
+			return true
+		}
+	}
+	return false
 }
 
-func (o *Orchestrator) Handler(ctx context.Context, p giteawebhook.Payload) {
-	if p.Action != giteawebhook.ActionReviewRequest {
+func (o *Orchestrator) review(
+	ctx context.Context,
+	logger *zap.Logger,
+	e vcs.Event,
+	cfg *hotreload.Snapshot[config.Config],
+) {
+	ctx, done, ok := o.jobs.Start(ctx, newJobKey(e), e.ReceivedAt)
+	if !ok {
+		logger.Info("skipping review, it was cancelled or superseded while queued")
 		return
 	}
+	defer done()
 
-	o.logger.Info("starting getting diff..")
-	diff, err := o.giteaClient.GetDiff(p.Repository.Owner.Login, p.Repository.Name, p.PullRequest.ID)
+	client, err := o.clients.Resolve(e)
 	if err != nil {
-		o.logger.Error("failed to get diff", zap.Error(err))
+		logger.Error("failed to resolve vcs client", zap.Error(err))
 		return
 	}
-	o.logger.Info("got diff")
 
-	o.logger.Info("starting ai request..")
-	aiReview, err := o.aiClient.Send(diff.String())
+	logger.Info("starting getting diff..")
+	diff, err := client.GetDiff(ctx, e.Repository.Owner, e.Repository.Name, e.PullRequest.Number)
 	if err != nil {
-		o.logger.Error("failed to send ai review", zap.Error(err))
+		logFailure(ctx, logger, "failed to get diff", err)
 		return
 	}
-	o.logger.Info("got ai review")
+	logger.Info("got diff")
+	capture.FromContext(ctx).SetDiff(diff)
 
-	o.logger.Info("starting gitea review..")
-	giteReview, err := o.giteaClient.CreateReview(
-		p.Repository.Owner.Login,
-		p.Repository.Name,
-		p.PullRequest.ID,
-		p.PullRequest.Head.SHA,
-		aiReview,
-	)
+	data := newPromptData(ctx, logger, client, e, diff, cfg.Value)
+	data.Avoid = o.avoidExamples(e.Repository, cfg.Value.Feedback)
+	data.Conventions = o.repoConventions(e.Repository, data.PR.Files)
+	ctx = ai.WithTraits(ctx, aiTraits(data.PR.Files))
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	if cfg.Value.Security.Enabled {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			o.securityReview(ctx, logger, client, e, diff, data, cfg)
+		}()
+	}
+
+	// without streaming a single pass review has nothing to report until it's over
+	var progress *progressComment
+	if cfg.Value.ProgressComment && (cfg.Value.AI.Stream || isMultiPass(cfg.Value, data)) {
+		progress = startProgress(ctx, logger, client, e)
+		defer progress.finish(ctx)
+	}
+
+	aiReview, err := o.reviewDiff(ctx, logger, diff, data, cfg.Value, progress)
+	if err != nil {
+		logFailure(ctx, logger, "failed to send ai review", err)
+		return
+	}
+
+	// the request may have been answered right before cancellation, results are stale anyway
+	if ctx.Err() != nil {
+		logger.Info("review cancelled, dropping results")
+		return
+	}
+
+	logger.Info("starting vcs review..")
+	if err := o.postReview(ctx, logger, client, e, aiReview, cfg.Version); err != nil {
+		logFailure(ctx, logger, "failed to create review", err)
+		return
+	}
+	logger.Info("got vcs review")
+}
+
+// reviewDiff runs the review passes, verification and the verdict policy over the diff of a pull request
+func (o *Orchestrator) reviewDiff(
+	ctx context.Context,
+	logger *zap.Logger,
+	rawDiff string,
+	data prompt.Data,
+	cfg config.Config,
+	progress *progressComment,
+) (ai.AIReviewResult, error) {
+	var r ai.AIReviewResult
+	var err error
+	if isMultiPass(cfg, data) {
+		logger.Info("starting multi-pass ai review..", zap.Int("files", len(data.PR.Files)))
+		r, err = o.multiPassReview(ctx, logger, rawDiff, data, cfg, progress)
+	} else {
+		logger.Info("starting ai request..")
+		r, err = o.singlePassReview(ctx, data, cfg, progress)
+	}
+	if err != nil {
+		return ai.AIReviewResult{}, err
+	}
+	logger.Info("got ai review")
+	if cfg.Verification.Enabled {
+		r = o.verifyComments(ctx, logger, rawDiff, data, r, cfg.Verification)
+	}
+	return enforcePolicy(logger, r, injectionMarkers(rawDiff, data.PR.Title, data.PR.Description)), nil
+}
+
+func isMultiPass(cfg config.Config, data prompt.Data) bool {
+	return cfg.MultiPass.Enabled && len(data.PR.Files) >= cfg.MultiPass.MinFiles
+}
+
+// singlePassReview reviews the whole diff with one request
+func (o *Orchestrator) singlePassReview(
+	ctx context.Context,
+	data prompt.Data,
+	cfg config.Config,
+	progress *progressComment,
+) (ai.AIReviewResult, error) {
+	systemPrompt, userPrompt, err := prompt.RenderReviewMessages(cfg.SystemPromptTemplate(), cfg.UserPromptTemplate(), data)
 	if err != nil {
-		o.logger.Error("failed to create review", zap.Error(err))
+		return ai.AIReviewResult{}, err
+	}
+	return o.aiClient.SendWithProgress(ctx, systemPrompt, userPrompt, func(partial ai.AIReviewResult) {
+		progress.update(partial)
+	})
+}
+
+// logFailure errors of cancelled jobs are expected and logged as such
+func logFailure(ctx context.Context, logger *zap.Logger, msg string, err error) {
+	if ctx.Err() != nil {
+		logger.Info("review cancelled", zap.String("stage", msg), zap.Error(err))
+		return
+	}
+	logger.Error(msg, zap.Error(err))
+}
+
+func newJobKey(e vcs.Event) jobs.Key {
+	var host string
+	if u, err := url.Parse(e.Repository.HTMLURL); err == nil {
+		host = strings.ToLower(u.Host)
+	}
+	return jobs.Key{
+		Forge:  string(e.Forge),
+		Host:   host,
+		Owner:  e.Repository.Owner,
+		Repo:   e.Repository.Name,
+		Number: e.PullRequest.Number,
+	}
+}
+
+// footer lets a posted review be traced back to the prompt and the model it was produced with
+func (o *Orchestrator) footer(configVersion string, model string) string {
+	if model == "" {
+		return fmt.Sprintf("\n\n---\n<sub>go-snob %s · config %s</sub>", o.version, configVersion)
+	}
+	return fmt.Sprintf("\n\n---\n<sub>go-snob %s · config %s · model %s</sub>", o.version, configVersion, model)
+}
+
+func newReview(commitSHA string, r ai.AIReviewResult, footer string) vcs.Review {
+	comments := make([]vcs.ReviewComment, 0, len(r.Comments))
+	for _, c := range r.Comments {
+		comments = append(comments, vcs.ReviewComment{
+			Path:    c.File,
+			NewLine: c.NewPosition,
+			OldLine: c.OldPosition,
+			Body:    c.Message,
+		})
+	}
+
+	return vcs.Review{
+		CommitSHA: commitSHA,
+		Verdict:   vcs.Verdict(r.Verdict),
+		Body:      r.Summary + footer,
+		Comments:  comments,
 	}
-	_ = giteReview
-	o.logger.Info("got gitea review")
 }
@@ -0,0 +1,146 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"go-snob/internal/actor/ai"
+	"go-snob/internal/actor/vcs"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// progressEditInterval limits how often the progress comment is edited
+const progressEditInterval = 5 * time.Second
+
+const progressHeader = "⏳ **Review in progress…**"
+
+// progressComment is a comment edited in place while the review is streamed, it's deleted once the
+// review is over. Edits are made by a goroutine of its own, so a slow forge doesn't stall the stream
+type progressComment struct {
+	editor vcs.CommentEditor
+	logger *zap.Logger
+	e      vcs.Event
+	id     int64
+	// interval between edits, progressEditInterval
+	interval time.Duration
+
+	// updates holds the latest review parsed so far, older ones are dropped
+	updates chan ai.AIReviewResult
+	// mu file passes of a multi-pass review report concurrently
+	mu   sync.Mutex
+	stop chan struct{}
+	done chan struct{}
+}
+
+// startProgress returns nil if the client can't edit comments or the comment couldn't be posted,
+// nil is a valid no-op progress
+func startProgress(ctx context.Context, logger *zap.Logger, client vcs.Client, e vcs.Event) *progressComment {
+	editor, ok := client.(vcs.CommentEditor)
+	if !ok {
+		logger.Debug("vcs client can't edit comments, no progress comment")
+		return nil
+	}
+
+	id, err := editor.CreateCommentWithID(ctx, e.Repository.Owner, e.Repository.Name, e.PullRequest.Number, progressHeader)
+	if err != nil {
+		logger.Warn("failed to post progress comment", zap.Error(err))
+		return nil
+	}
+	p := &progressComment{
+		editor:   editor,
+		logger:   logger,
+		e:        e,
+		id:       id,
+		interval: progressEditInterval,
+		updates:  make(chan ai.AIReviewResult, 1),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	go p.run(ctx)
+	return p
+}
+
+// update is called with the review parsed so far, it never waits for the forge
+func (p *progressComment) update(partial ai.AIReviewResult) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	// the update the goroutine hasn't picked up yet is stale
+	select {
+	case <-p.updates:
+	default:
+	}
+	p.updates <- partial
+}
+
+// run edits the comment with the latest update at most every interval
+func (p *progressComment) run(ctx context.Context) {
+	defer close(p.done)
+	rendered := progressHeader
+	for {
+		select {
+		case <-p.stop:
+			return
+		case <-time.After(p.interval):
+		}
+
+		var partial ai.AIReviewResult
+		select {
+		case <-p.stop:
+			return
+		case partial = <-p.updates:
+		}
+		body := renderProgress(partial)
+		if body == rendered {
+			continue
+		}
+		err := p.editor.EditComment(ctx, p.e.Repository.Owner, p.e.Repository.Name, p.e.PullRequest.Number, p.id, body)
+		if err != nil {
+			p.logger.Warn("failed to update progress comment", zap.Error(err))
+		}
+		rendered = body
+	}
+}
+
+// finish deletes the comment, the final review replaces it
+func (p *progressComment) finish(ctx context.Context) {
+	if p == nil {
+		return
+	}
+	close(p.stop)
+	<-p.done
+	// the job may be cancelled, the comment still has to go
+	ctx = context.WithoutCancel(ctx)
+	err := p.editor.DeleteComment(ctx, p.e.Repository.Owner, p.e.Repository.Name, p.e.PullRequest.Number, p.id)
+	if err != nil {
+		p.logger.Warn("failed to delete progress comment", zap.Error(err))
+	}
+}
+
+// renderProgress lists files with the number of comments found in them so far
+func renderProgress(partial ai.AIReviewResult) string {
+	counts := make(map[string]int)
+	for _, c := range partial.Comments {
+		if c.File != "" {
+			counts[c.File]++
+		}
+	}
+	files := make([]string, 0, len(counts))
+	for f := range counts {
+		files = append(files, f)
+	}
+	slices.Sort(files)
+
+	var b strings.Builder
+	b.WriteString(progressHeader)
+	for _, f := range files {
+		fmt.Fprintf(&b, "\n- `%s`: %d comment(s)", f, counts[f])
+	}
+	return b.String()
+}
@@ -0,0 +1,96 @@
+package internal
+
+import (
+	"context"
+	"go-snob/internal/actor/ai"
+	"go-snob/internal/actor/vcs"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// slowEditor blocks edits until release is closed, started and edited report each edit
+type slowEditor struct {
+	release chan struct{}
+	started chan struct{}
+	edited  chan struct{}
+
+	mu      sync.Mutex
+	edits   []string
+	deleted bool
+}
+
+func (e *slowEditor) CreateCommentWithID(context.Context, string, string, int, string) (int64, error) {
+	return 1, nil
+}
+
+func (e *slowEditor) EditComment(_ context.Context, _ string, _ string, _ int, _ int64, body string) error {
+	e.started <- struct{}{}
+	<-e.release
+	e.mu.Lock()
+	e.edits = append(e.edits, body)
+	e.mu.Unlock()
+	e.edited <- struct{}{}
+	return nil
+}
+
+func (e *slowEditor) DeleteComment(context.Context, string, string, int, int64) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.deleted = true
+	return nil
+}
+
+func TestProgressCommentKeepsLatestUpdate(t *testing.T) {
+	editor := &slowEditor{
+		release: make(chan struct{}),
+		started: make(chan struct{}, 2),
+		edited:  make(chan struct{}, 2),
+	}
+	p := &progressComment{
+		editor:   editor,
+		logger:   zap.NewNop(),
+		interval: time.Millisecond,
+		updates:  make(chan ai.AIReviewResult, 1),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	go p.run(context.Background())
+
+	// updates don't wait for the forge while an edit hangs
+	p.update(ai.AIReviewResult{Comments: []ai.Comment{{File: "a.go"}}})
+	waitFor(t, editor.started)
+	for _, f := range []string{"b.go", "c.go", "d.go"} {
+		p.update(ai.AIReviewResult{Comments: []ai.Comment{{File: f}}})
+	}
+	close(editor.release)
+	waitFor(t, editor.edited)
+	waitFor(t, editor.edited)
+	p.finish(context.Background())
+
+	editor.mu.Lock()
+	defer editor.mu.Unlock()
+	if len(editor.edits) != 2 {
+		t.Fatalf("stale updates must be dropped: %q", editor.edits)
+	}
+	if !strings.Contains(editor.edits[0], "a.go") || !strings.Contains(editor.edits[1], "d.go") {
+		t.Errorf("unexpected edits: %q", editor.edits)
+	}
+	if !editor.deleted {
+		t.Error("progress comment was not deleted")
+	}
+}
+
+func waitFor(t *testing.T, ch <-chan struct{}) {
+	t.Helper()
+	select {
+	case <-ch:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out")
+	}
+}
+
+var _ vcs.CommentEditor = (*slowEditor)(nil)
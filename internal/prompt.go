@@ -0,0 +1,136 @@
+package internal
+
+import (
+	"context"
+	"go-snob/internal/actor/ai"
+	"go-snob/internal/actor/vcs"
+	"go-snob/internal/config"
+	"go-snob/internal/diff"
+	"go-snob/internal/prompt"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+// newPromptData collects template data. Metadata is best effort: a failed call leaves the field empty
+// instead of failing the review.
+func newPromptData(
+	ctx context.Context,
+	logger *zap.Logger,
+	client vcs.Client,
+	e vcs.Event,
+	rawDiff string,
+	cfg config.Config,
+) prompt.Data {
+	fullName := e.Repository.Owner + "/" + e.Repository.Name
+	pr := e.PullRequest
+
+	data := prompt.Data{
+		PR: prompt.PullRequest{
+			Number:      pr.Number,
+			URL:         pr.HTMLURL,
+			Title:       pr.Title,
+			Description: pr.Description,
+			Author:      pr.Author,
+			Labels:      pr.Labels,
+			BaseBranch:  pr.BaseRef,
+			HeadBranch:  pr.HeadRef,
+		},
+		Repo: prompt.Repository{
+			Owner:    e.Repository.Owner,
+			Name:     e.Repository.Name,
+			FullName: fullName,
+		},
+		Diff: prompt.Fence("diff", rawDiff),
+		Vars: cfg.RepoVars(fullName),
+	}
+	data.PR.Files = promptFiles(rawDiff)
+
+	texts := []string{pr.Title, pr.Description}
+	if mp, ok := client.(vcs.MetadataProvider); ok {
+		commits, err := mp.ListCommits(ctx, e.Repository.Owner, e.Repository.Name, pr.Number)
+		if err != nil {
+			logger.Warn("failed to list commits for prompt", zap.Error(err))
+		}
+		for _, c := range commits {
+			data.PR.Commits = append(data.PR.Commits, prompt.Commit{SHA: c.SHA, Message: c.Message})
+			texts = append(texts, c.Message)
+		}
+	}
+	data.Repo.Languages = repoLanguages(ctx, logger, client, e.Repository)
+	data.PR.LinkedIssues = prompt.LinkedIssues(texts...)
+
+	return data
+}
+
+// newCommitPromptData collects template data for a commit pushed without a pull request, the commit
+// stands in for the pull request
+func newCommitPromptData(
+	ctx context.Context,
+	logger *zap.Logger,
+	client vcs.Client,
+	e vcs.PushEvent,
+	c vcs.Commit,
+	rawDiff string,
+	cfg config.Config,
+) prompt.Data {
+	fullName := e.Repository.Owner + "/" + e.Repository.Name
+	title, description, _ := strings.Cut(c.Message, "\n")
+
+	return prompt.Data{
+		PR: prompt.PullRequest{
+			Title:        title,
+			Description:  strings.TrimSpace(description),
+			Author:       c.Author,
+			BaseBranch:   e.Branch,
+			HeadBranch:   e.Branch,
+			Commits:      []prompt.Commit{{SHA: c.SHA, Message: c.Message}},
+			LinkedIssues: prompt.LinkedIssues(c.Message),
+			Files:        promptFiles(rawDiff),
+		},
+		Repo: prompt.Repository{
+			Owner:     e.Repository.Owner,
+			Name:      e.Repository.Name,
+			FullName:  fullName,
+			Languages: repoLanguages(ctx, logger, client, e.Repository),
+		},
+		Diff: prompt.Fence("diff", rawDiff),
+		Vars: cfg.RepoVars(fullName),
+	}
+}
+
+func promptFiles(rawDiff string) []prompt.File {
+	var files []prompt.File
+	for _, f := range diff.Parse(rawDiff) {
+		files = append(files, prompt.File{
+			Path:      f.Path(),
+			OldPath:   f.OldPath,
+			Status:    string(f.Status),
+			Additions: f.Additions,
+			Deletions: f.Deletions,
+		})
+	}
+	return files
+}
+
+func repoLanguages(ctx context.Context, logger *zap.Logger, client vcs.Client, repo vcs.Repository) []string {
+	mp, ok := client.(vcs.MetadataProvider)
+	if !ok {
+		return nil
+	}
+	langs, err := mp.GetLanguages(ctx, repo.Owner, repo.Name)
+	if err != nil {
+		logger.Warn("failed to get languages for prompt", zap.Error(err))
+	}
+	return prompt.SortLanguages(langs)
+}
+
+// aiTraits describes the change for model routing
+func aiTraits(files []prompt.File) ai.Traits {
+	var t ai.Traits
+	for _, f := range files {
+		t.DiffLines += f.Additions + f.Deletions
+		t.Paths = append(t.Paths, f.Path)
+	}
+	return t
+}
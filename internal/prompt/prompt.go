@@ -0,0 +1,161 @@
+package prompt
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+type File struct {
+	Path      string
+	OldPath   string
+	Status    string
+	Additions int
+	Deletions int
+}
+
+type Commit struct {
+	SHA     string
+	Message string
+}
+
+// Title first line of the commit message
+func (c Commit) Title() string {
+	title, _, _ := strings.Cut(c.Message, "\n")
+	return title
+}
+
+type Commits []Commit
+
+// Titles one commit title per line, to be fenced as a whole
+func (cs Commits) Titles() string {
+	titles := make([]string, len(cs))
+	for i, c := range cs {
+		titles[i] = "- " + c.Title()
+	}
+	return strings.Join(titles, "\n")
+}
+
+type Issue struct {
+	Number int
+	// Closing issue is referenced with a closing keyword like "fixes #123"
+	Closing bool
+}
+
+type PullRequest struct {
+	Number       int
+	URL          string
+	Title        string
+	Description  string
+	Author       string
+	Labels       []string
+	BaseBranch   string
+	HeadBranch   string
+	Commits      Commits
+	LinkedIssues []Issue
+	Files        []File
+}
+
+type Repository struct {
+	Owner    string
+	Name     string
+	FullName string
+	// Languages sorted by share in the repository, most used first
+	Languages []string
+}
+
+// Data everything a prompt template can refer to
+type Data struct {
+	PR   PullRequest
+	Repo Repository
+	// Diff the diff fenced as untrusted content
+	Diff string
+	// Vars custom variables from config, per repo values override global ones
+	Vars map[string]string
+
+	// File the file under review in a multi-pass file pass, .Diff is limited to it
+	File File
+	// Candidates comments under synthesis or verification as JSON, fenced
+	Candidates string
+	// DiffSummary changed files with their hunk headers, fenced, set in the synthesis pass only
+	DiffSummary string
+	// Avoid downvoted comments of the repository, appended to the system prompt of reviews
+	Avoid []string
+	// Conventions rules of the repository matching the diff, appended to the system prompt of reviews
+	Conventions []string
+}
+
+var funcs = template.FuncMap{
+	"join":  strings.Join,
+	"lower": strings.ToLower,
+	"upper": strings.ToUpper,
+	"trim":  strings.TrimSpace,
+	// untrusted fences text coming from the pull request, .Diff is fenced already
+	"untrusted": func(s string) string { return Fence("text", s) },
+}
+
+// Parse compiles a prompt template. Missing map keys are errors, so typos in Vars don't silently
+// render as empty strings.
+func Parse(name string, text string) (*template.Template, error) {
+	t, err := template.New(name).Funcs(funcs).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("parse %s template: %w", name, err)
+	}
+	return t, nil
+}
+
+func Render(t *template.Template, data Data) (string, error) {
+	var b bytes.Buffer
+	if err := t.Execute(&b, data); err != nil {
+		return "", fmt.Errorf("render %s template: %w", t.Name(), err)
+	}
+	return b.String(), nil
+}
+
+var (
+	closingIssueRe = regexp.MustCompile(`(?i)\b(?:close[sd]?|fix(?:e[sd])?|resolve[sd]?)\s*:?\s+#(\d+)`)
+	issueRe        = regexp.MustCompile(`(?:^|[^\w/&])#(\d+)\b`)
+)
+
+// LinkedIssues finds "#123" references in texts, ordered by number
+func LinkedIssues(texts ...string) []Issue {
+	found := make(map[int]bool)
+	for _, text := range texts {
+		for _, m := range issueRe.FindAllStringSubmatch(text, -1) {
+			n, _ := strconv.Atoi(m[1])
+			if _, ok := found[n]; !ok {
+				found[n] = false
+			}
+		}
+		for _, m := range closingIssueRe.FindAllStringSubmatch(text, -1) {
+			n, _ := strconv.Atoi(m[1])
+			found[n] = true
+		}
+	}
+
+	issues := make([]Issue, 0, len(found))
+	for n, closing := range found {
+		issues = append(issues, Issue{Number: n, Closing: closing})
+	}
+	sort.Slice(issues, func(i, j int) bool { return issues[i].Number < issues[j].Number })
+	return issues
+}
+
+// SortLanguages orders languages by their size in bytes
+func SortLanguages(sizes map[string]int64) []string {
+	langs := make([]string, 0, len(sizes))
+	for l := range sizes {
+		langs = append(langs, l)
+	}
+	sort.Slice(langs, func(i, j int) bool {
+		if sizes[langs[i]] != sizes[langs[j]] {
+			return sizes[langs[i]] > sizes[langs[j]]
+		}
+		return langs[i] < langs[j]
+	})
+	return langs
+}
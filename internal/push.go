@@ -0,0 +1,198 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"go-snob/internal/actor/ai"
+	"go-snob/internal/actor/vcs"
+	"go-snob/internal/capture"
+	"go-snob/internal/config"
+	"go-snob/internal/prompt"
+	"go-snob/pkg/hotreload"
+	"slices"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+// PushHandler reviews commits pushed directly to branches with push review enabled
+func (o *Orchestrator) PushHandler(ctx context.Context, e vcs.PushEvent) {
+	cfg := o.cfg.Current()
+	fullName := e.Repository.Owner + "/" + e.Repository.Name
+	logger := o.logger.With(
+		zap.String("forge", string(e.Forge)),
+		zap.String("repo", fullName),
+		zap.String("branch", e.Branch),
+		zap.String("config_version", cfg.Version),
+	)
+
+	if !cfg.Value.RepoPushReview(fullName).Matches(e.Branch) {
+		logger.Debug("skipping push to branch without push review")
+		return
+	}
+
+	client, err := o.clients.ResolveRepository(e.Forge, e.Repository)
+	if err != nil {
+		logger.Error("failed to resolve vcs client", zap.Error(err))
+		return
+	}
+	cr, ok := client.(vcs.CommitReader)
+	if !ok {
+		logger.Warn("vcs client can't read commits, skipping push review")
+		return
+	}
+
+	for _, c := range e.Commits {
+		if ctx.Err() != nil {
+			return
+		}
+		o.reviewCommit(ctx, logger.With(zap.String("commit", c.SHA)), client, cr, e, c.SHA, cfg)
+	}
+}
+
+func (o *Orchestrator) reviewCommit(
+	ctx context.Context,
+	logger *zap.Logger,
+	client vcs.Client,
+	cr vcs.CommitReader,
+	e vcs.PushEvent,
+	sha string,
+	cfg *hotreload.Snapshot[config.Config],
+) {
+	owner, repo := e.Repository.Owner, e.Repository.Name
+	// the bundle replays this commit only
+	captured := e
+	captured.Commits = slices.DeleteFunc(slices.Clone(e.Commits), func(c vcs.Commit) bool { return c.SHA != sha })
+	ctx, finish := o.startCapture(ctx, logger, capture.KindPush, captured, cfg)
+	defer finish()
+
+	commit, err := cr.GetCommit(ctx, owner, repo, sha)
+	if err != nil {
+		logFailure(ctx, logger, "failed to get commit", err)
+		return
+	}
+	if len(commit.Parents) > 1 {
+		logger.Info("skipping merge commit")
+		return
+	}
+	if pr, ok, err := cr.CommitPullRequest(ctx, owner, repo, sha); err != nil {
+		logger.Warn("failed to get commit pull request, reviewing anyway", zap.Error(err))
+	} else if ok {
+		logger.Info("skipping commit merged with a pull request", zap.Int("pr", pr))
+		return
+	}
+
+	diff, err := cr.GetCommitDiff(ctx, owner, repo, sha)
+	if err != nil {
+		logFailure(ctx, logger, "failed to get commit diff", err)
+		return
+	}
+	capture.FromContext(ctx).SetDiff(diff)
+
+	data := newCommitPromptData(ctx, logger, client, e, commit, diff, cfg.Value)
+	data.Avoid = o.avoidExamples(e.Repository, cfg.Value.Feedback)
+	data.Conventions = o.repoConventions(e.Repository, data.PR.Files)
+	ctx = ai.WithTraits(ctx, aiTraits(data.PR.Files))
+	systemPrompt, userPrompt, err := prompt.RenderReviewMessages(
+		cfg.Value.SystemPromptTemplate(),
+		cfg.Value.UserPromptTemplate(),
+		data,
+	)
+	if err != nil {
+		logger.Error("failed to render prompts", zap.Error(err))
+		return
+	}
+
+	logger.Info("starting ai commit review..")
+	review, err := o.aiClient.Send(ctx, systemPrompt, userPrompt)
+	if err != nil {
+		logFailure(ctx, logger, "failed to send ai review", err)
+		return
+	}
+	if cfg.Value.Verification.Enabled {
+		review = o.verifyComments(ctx, logger, diff, data, review, cfg.Value.Verification)
+	}
+	review = enforcePolicy(logger, review, injectionMarkers(diff, commit.Message))
+	capture.FromContext(ctx).SetReview(newReview(sha, review, ""))
+
+	if cc, ok := client.(vcs.CommitCommenter); ok {
+		err = o.postCommitComments(ctx, cc, e, sha, review, cfg.Version)
+	} else if findings := highSeverityComments(review.Comments); len(findings) > 0 {
+		review.Comments = findings
+		err = o.createFindingsIssue(ctx, client, e, commit, review, cfg.Version)
+	} else {
+		logger.Info("no high severity findings in commit")
+		return
+	}
+	if err != nil {
+		logFailure(ctx, logger, "failed to post commit review", err)
+		return
+	}
+	logger.Info("posted commit review", zap.String("verdict", review.Verdict))
+}
+
+func (o *Orchestrator) postCommitComments(
+	ctx context.Context,
+	cc vcs.CommitCommenter,
+	e vcs.PushEvent,
+	sha string,
+	r ai.AIReviewResult,
+	configVersion string,
+) error {
+	owner, repo := e.Repository.Owner, e.Repository.Name
+	for _, c := range newReview(sha, r, "").Comments {
+		if c.NewLine == 0 {
+			// removed lines can't be commented, the comment goes to the commit itself
+			c.Body = fmt.Sprintf("`%s` (removed line %d): %s", c.Path, c.OldLine, c.Body)
+			c.Path = ""
+		}
+		if err := cc.CreateCommitComment(ctx, owner, repo, sha, c); err != nil {
+			return err
+		}
+	}
+	return cc.CreateCommitComment(ctx, owner, repo, sha, vcs.ReviewComment{Body: r.Summary + o.footer(configVersion, r.Model)})
+}
+
+// createFindingsIssue is the fallback for forges without commit comments, r only has the high severity findings
+func (o *Orchestrator) createFindingsIssue(
+	ctx context.Context,
+	client vcs.Client,
+	e vcs.PushEvent,
+	commit vcs.Commit,
+	r ai.AIReviewResult,
+	configVersion string,
+) error {
+	ic, ok := client.(vcs.IssueCreator)
+	if !ok {
+		return fmt.Errorf("vcs client can't comment commits nor create issues")
+	}
+
+	short := commit.SHA
+	if len(short) > 10 {
+		short = short[:10]
+	}
+	title := fmt.Sprintf("go-snob: findings in %s pushed to %s", short, e.Branch)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Commit %s by %s was pushed directly to `%s`.\n\n%s\n", commit.SHA, commit.Author, e.Branch, r.Summary)
+	for _, c := range r.Comments {
+		line := c.NewPosition
+		if line == 0 {
+			line = c.OldPosition
+		}
+		fmt.Fprintf(&b, "\n- `%s:%d` %s", c.File, line, c.Message)
+	}
+	b.WriteString(o.footer(configVersion, r.Model))
+
+	return ic.CreateIssue(ctx, e.Repository.Owner, e.Repository.Name, title, b.String())
+}
+
+func highSeverityComments(comments []ai.Comment) []ai.Comment {
+	var res []ai.Comment
+	for _, c := range comments {
+		if ai.HighSeverity(c.Severity) {
+			res = append(res, c)
+		}
+	}
+	return res
+}
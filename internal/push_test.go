@@ -0,0 +1,142 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"go-snob/internal/actor/ai"
+	"go-snob/internal/actor/vcs"
+	"go-snob/internal/config"
+	"go-snob/internal/testkit"
+	"go-snob/pkg/hotreload"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+	"resty.dev/v3"
+)
+
+const pushDiff = `diff --git a/a.go b/a.go
+--- a/a.go
++++ b/a.go
+@@ -1,1 +1,2 @@
+ package a
++var x = 1
+`
+
+// issueClient a forge without commit comments, like Gitea
+type issueClient struct {
+	issues []string
+}
+
+func (c *issueClient) CurrentUser(context.Context) (vcs.User, error) { return vcs.User{}, nil }
+
+func (c *issueClient) GetDiff(context.Context, string, string, int) (string, error) { return "", nil }
+
+func (c *issueClient) CreateReview(context.Context, string, string, int, vcs.Review) error {
+	return nil
+}
+
+func (c *issueClient) CreateComment(context.Context, string, string, int, string) error { return nil }
+
+func (c *issueClient) CreateStatus(context.Context, string, string, string, vcs.Status) error {
+	return nil
+}
+
+func (c *issueClient) GetFile(context.Context, string, string, string, string) ([]byte, error) {
+	return nil, errors.New("not found")
+}
+
+func (c *issueClient) GetCommit(_ context.Context, _ string, _ string, sha string) (vcs.Commit, error) {
+	return vcs.Commit{SHA: sha, Message: "fix: a", Author: "dev", Parents: []string{"p"}}, nil
+}
+
+func (c *issueClient) GetCommitDiff(context.Context, string, string, string) (string, error) {
+	return pushDiff, nil
+}
+
+func (c *issueClient) CommitPullRequest(context.Context, string, string, string) (int, bool, error) {
+	return 0, false, nil
+}
+
+func (c *issueClient) CreateIssue(_ context.Context, _ string, _ string, _ string, body string) error {
+	c.issues = append(c.issues, body)
+	return nil
+}
+
+func TestPushHandlerIssueListsHighSeverityFindings(t *testing.T) {
+	tests := []struct {
+		name     string
+		comments []ai.Comment
+		want     []string
+		wantNot  []string
+	}{
+		{
+			name: "high and low",
+			comments: []ai.Comment{
+				{File: "a.go", NewPosition: 2, Message: "x races", Severity: "critical"},
+				{File: "a.go", NewPosition: 2, Message: "rename x", Severity: "low"},
+			},
+			want:    []string{"`a.go:2` x races"},
+			wantNot: []string{"rename x"},
+		},
+		{
+			name:     "low only",
+			comments: []ai.Comment{{File: "a.go", NewPosition: 2, Message: "rename x", Severity: "low"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			llm := testkit.NewLLM(t).ReplyJSON(t, "ai_review_result", ai.AIReviewResult{
+				Verdict:  "REQUEST_CHANGES",
+				Summary:  "issues",
+				Comments: tt.comments,
+			})
+			path := filepath.Join(t.TempDir(), "config.yaml")
+			yaml := "system_prompt: review\ngitea:\n  - name: local\n    base_url: http://localhost/api/v1\n" +
+				"ai:\n  url: http://localhost/v1\npush_review:\n  branches: [main]\n"
+			if err := os.WriteFile(path, []byte(yaml), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			cfg, err := hotreload.NewStore(path, config.Parse, zap.NewNop())
+			if err != nil {
+				t.Fatal(err)
+			}
+			client := &issueClient{}
+			o := NewOrchestrator(
+				ai.NewClient(resty.New(), zap.NewNop(), llm.URL(), "token"),
+				vcs.NewRegistry().Register(vcs.ForgeGitea, "", client),
+				cfg,
+				zap.NewNop(),
+			)
+
+			o.PushHandler(context.Background(), vcs.PushEvent{
+				Forge:      vcs.ForgeGitea,
+				Repository: vcs.Repository{Owner: "octo", Name: "hello"},
+				Branch:     "main",
+				Commits:    []vcs.Commit{{SHA: "abc"}},
+			})
+
+			if len(tt.want) == 0 {
+				if len(client.issues) != 0 {
+					t.Errorf("unexpected issue: %q", client.issues)
+				}
+				return
+			}
+			if len(client.issues) != 1 {
+				t.Fatalf("issues = %d, want 1", len(client.issues))
+			}
+			for _, s := range tt.want {
+				if !strings.Contains(client.issues[0], s) {
+					t.Errorf("issue has no %q:\n%s", s, client.issues[0])
+				}
+			}
+			for _, s := range tt.wantNot {
+				if strings.Contains(client.issues[0], s) {
+					t.Errorf("issue has %q:\n%s", s, client.issues[0])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,137 @@
+package secrets
+
+import (
+	"go-snob/internal/diff"
+	"math"
+	"path"
+	"regexp"
+	"strings"
+)
+
+type Finding struct {
+	Path string
+	Line int
+	Rule string
+	// Redacted the match with everything but a short prefix masked
+	Redacted string
+	// Confirmed the match has the format of a known credential, unconfirmed ones are high entropy
+	// values assigned to credential-like names
+	Confirmed bool
+}
+
+type rule struct {
+	name string
+	re   *regexp.Regexp
+}
+
+// rules known credential formats, a match is a confirmed secret
+var rules = []rule{
+	{"private-key", regexp.MustCompile(`-----BEGIN (?:RSA |EC |DSA |OPENSSH |PGP |ENCRYPTED )?PRIVATE KEY( BLOCK)?-----`)},
+	{"aws-access-key-id", regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`)},
+	{"github-token", regexp.MustCompile(`\b(?:gh[pousr]_[A-Za-z0-9]{36,}|github_pat_[A-Za-z0-9_]{60,})\b`)},
+	{"gitlab-token", regexp.MustCompile(`\bglpat-[A-Za-z0-9_-]{20,}\b`)},
+	{"slack-token", regexp.MustCompile(`\bxox[baprs]-[A-Za-z0-9-]{10,}\b`)},
+	{"google-api-key", regexp.MustCompile(`\bAIza[0-9A-Za-z_-]{35}\b`)},
+	{"stripe-secret-key", regexp.MustCompile(`\b(?:sk|rk)_live_[0-9A-Za-z]{20,}\b`)},
+	{"jwt", regexp.MustCompile(`\beyJ[A-Za-z0-9_-]{10,}\.eyJ[A-Za-z0-9_-]{10,}\.[A-Za-z0-9_-]{10,}\b`)},
+	{"url-credentials", regexp.MustCompile(`\b[a-z][a-z0-9+.-]*://[^\s:/@]+:[^\s:/@]{6,}@[^\s/]+`)},
+}
+
+// assignment a credential-like name assigned a quoted or bare value
+var assignment = regexp.MustCompile(
+	`(?i)(?:password|passwd|pwd|secret|token|api[_-]?key|access[_-]?key|private[_-]?key|credentials?|auth)` +
+		`[A-Za-z0-9_-]*["']?\s*[:=]{1,2}\s*["']?([A-Za-z0-9+/=_\-.~]{16,})`,
+)
+
+const (
+	// minEntropy bits per char above which an assigned value looks random, hex tops out at 4
+	minEntropy = 3.5
+	visible    = 4
+)
+
+// skipped files full of hashes which aren't secrets
+var skipped = []string{"go.sum", "package-lock.json", "yarn.lock", "pnpm-lock.yaml", "Cargo.lock", "*.min.js"}
+
+// Scan looks for secrets in lines added by the diff
+func Scan(files []diff.File) []Finding {
+	var res []Finding
+	for _, f := range files {
+		if f.Binary || isSkipped(f.Path()) {
+			continue
+		}
+		for _, h := range f.Hunks {
+			for _, l := range h.Lines {
+				if l.Kind != diff.LineAdded {
+					continue
+				}
+				res = append(res, scanLine(f.Path(), l)...)
+			}
+		}
+	}
+	return res
+}
+
+func scanLine(p string, l diff.Line) []Finding {
+	var res []Finding
+	for _, r := range rules {
+		if m := r.re.FindString(l.Content); m != "" {
+			res = append(res, Finding{Path: p, Line: l.NewLine, Rule: r.name, Redacted: redact(m), Confirmed: true})
+		}
+	}
+	if len(res) > 0 {
+		return res
+	}
+
+	for _, m := range assignment.FindAllStringSubmatch(l.Content, -1) {
+		if value := m[1]; entropy(value) >= minEntropy {
+			res = append(res, Finding{Path: p, Line: l.NewLine, Rule: "high-entropy-assignment", Redacted: redact(value)})
+		}
+	}
+	return res
+}
+
+func isSkipped(p string) bool {
+	base := path.Base(p)
+	for _, s := range skipped {
+		if ok, _ := path.Match(s, base); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// entropy Shannon entropy of s in bits per char
+func entropy(s string) float64 {
+	counts := make(map[rune]int)
+	for _, r := range s {
+		counts[r]++
+	}
+	n := float64(len([]rune(s)))
+	var e float64
+	for _, c := range counts {
+		p := float64(c) / n
+		e -= p * math.Log2(p)
+	}
+	return e
+}
+
+func redact(s string) string {
+	if len(s) <= visible {
+		return strings.Repeat("*", len(s))
+	}
+	return s[:visible] + strings.Repeat("*", min(len(s)-visible, 16))
+}
+
+// Redact masks credentials Scan would report anywhere in s
+func Redact(s string) string {
+	for _, r := range rules {
+		s = r.re.ReplaceAllStringFunc(s, redact)
+	}
+	return assignment.ReplaceAllStringFunc(s, func(m string) string {
+		value := assignment.FindStringSubmatch(m)[1]
+		if entropy(value) < minEntropy {
+			return m
+		}
+		return strings.Replace(m, value, redact(value), 1)
+	})
+}
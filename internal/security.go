@@ -0,0 +1,149 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"go-snob/internal/actor/ai"
+	"go-snob/internal/actor/vcs"
+	"go-snob/internal/config"
+	"go-snob/internal/diff"
+	"go-snob/internal/prompt"
+	"go-snob/internal/secrets"
+	"go-snob/pkg/hotreload"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+// securityMarker tells security reviews apart from general ones
+const securityMarker = "<!-- go-snob:security -->"
+
+// securityReview runs the secret scan and the LLM security pass and posts their findings as a separate review
+func (o *Orchestrator) securityReview(
+	ctx context.Context,
+	logger *zap.Logger,
+	client vcs.Client,
+	e vcs.Event,
+	rawDiff string,
+	data prompt.Data,
+	cfg *hotreload.Snapshot[config.Config],
+) {
+	logger = logger.With(zap.String("pass", "security"))
+	sc := cfg.Value.Security
+
+	found := secrets.Scan(diff.Parse(rawDiff))
+	o.setSecretsStatus(ctx, logger, client, e, sc.StatusContext, found)
+
+	llm, err := o.securityLLM(ctx, data, sc)
+	if err != nil {
+		// the secret scan is still worth posting
+		logFailure(ctx, logger, "failed to run llm security pass", err)
+	}
+	if ctx.Err() != nil {
+		logger.Info("review cancelled, dropping security results")
+		return
+	}
+
+	if len(found) == 0 && len(llm.Findings) == 0 {
+		logger.Info("no security findings")
+		return
+	}
+	err = client.CreateReview(
+		ctx,
+		e.Repository.Owner,
+		e.Repository.Name,
+		e.PullRequest.Number,
+		newSecurityReview(e.PullRequest.HeadSHA, found, llm, o.footer(cfg.Version, llm.Model)),
+	)
+	if err != nil {
+		logFailure(ctx, logger, "failed to create security review", err)
+		return
+	}
+	logger.Info("posted security review", zap.Int("secrets", len(found)), zap.Int("findings", len(llm.Findings)))
+}
+
+func (o *Orchestrator) securityLLM(ctx context.Context, data prompt.Data, sc config.SecurityConfig) (ai.AISecurityResult, error) {
+	systemPrompt, userPrompt, err := prompt.RenderMessages(
+		sc.SystemPromptTemplate(),
+		sc.UserPromptTemplate(),
+		data,
+	)
+	if err != nil {
+		return ai.AISecurityResult{}, err
+	}
+	return o.aiClient.SecurityReview(ctx, systemPrompt, userPrompt)
+}
+
+// setSecretsStatus fails the head commit on confirmed secrets
+func (o *Orchestrator) setSecretsStatus(
+	ctx context.Context,
+	logger *zap.Logger,
+	client vcs.Client,
+	e vcs.Event,
+	statusContext string,
+	found []secrets.Finding,
+) {
+	var confirmed int
+	for _, f := range found {
+		if f.Confirmed {
+			confirmed++
+		}
+	}
+
+	status := vcs.Status{State: vcs.StatusSuccess, Context: statusContext, Description: "No secrets in added lines"}
+	switch {
+	case confirmed > 0:
+		status.State = vcs.StatusFailure
+		status.Description = fmt.Sprintf("%d secret(s) in added lines", confirmed)
+	case len(found) > 0:
+		status.Description = fmt.Sprintf("%d possible secret(s) to check", len(found))
+	}
+
+	err := client.CreateStatus(ctx, e.Repository.Owner, e.Repository.Name, e.PullRequest.HeadSHA, status)
+	if err != nil {
+		logFailure(ctx, logger, "failed to create secrets status", err)
+	}
+}
+
+func newSecurityReview(commitSHA string, found []secrets.Finding, llm ai.AISecurityResult, footer string) vcs.Review {
+	verdict := vcs.VerdictComment
+	var comments []vcs.ReviewComment
+	var confirmed int
+
+	for _, f := range found {
+		body := fmt.Sprintf("🔒 **Possible secret** `%s`: `%s`\n\nMake sure it isn't a real credential.", f.Rule, f.Redacted)
+		if f.Confirmed {
+			confirmed++
+			verdict = vcs.VerdictRequestChanges
+			body = fmt.Sprintf("🔒 **Secret** `%s`: `%s`\n\nRevoke it, removing it from the branch isn't enough, "+
+				"it stays in the git history.", f.Rule, f.Redacted)
+		}
+		comments = append(comments, vcs.ReviewComment{Path: f.Path, NewLine: f.Line, Body: body})
+	}
+
+	for _, f := range llm.Findings {
+		if ai.HighSeverity(f.Severity) {
+			verdict = vcs.VerdictRequestChanges
+		}
+		body := fmt.Sprintf("🔒 **Security** · %s · %s\n\n%s", f.CWE, f.Severity, f.Message)
+		if f.Exploitability != "" {
+			body += "\n\n**Exploitability:** " + f.Exploitability
+		}
+		comments = append(comments, vcs.ReviewComment{
+			Path:    f.File,
+			NewLine: f.NewPosition,
+			OldLine: f.OldPosition,
+			Body:    body,
+		})
+	}
+
+	var b strings.Builder
+	b.WriteString(securityMarker + "\n## 🔒 Security review\n\n")
+	fmt.Fprintf(&b, "Secret scan: %d confirmed, %d possible.", confirmed, len(found)-confirmed)
+	if s := strings.TrimSpace(llm.Summary); s != "" {
+		b.WriteString("\n\n" + s)
+	}
+	b.WriteString(footer)
+
+	return vcs.Review{CommitSHA: commitSHA, Verdict: verdict, Body: b.String(), Comments: comments}
+}
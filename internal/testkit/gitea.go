@@ -0,0 +1,285 @@
+package testkit
+
+import (
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// Gitea a fake Gitea API. It serves the diffs and files it was given, answers the rest with 404 and
+// records reviews, comments and statuses the bot posts
+type Gitea struct {
+	server *httptest.Server
+	login  string
+
+	mu       sync.Mutex
+	nextID   int64
+	diffs    map[string]string
+	files    map[string][]byte
+	reviews  []Review
+	comments []Comment
+	statuses []Status
+}
+
+// Review a posted pull request review
+type Review struct {
+	Owner    string          `json:"-"`
+	Repo     string          `json:"-"`
+	Index    int             `json:"-"`
+	ID       int64           `json:"-"`
+	Body     string          `json:"body"`
+	Event    string          `json:"event"`
+	CommitID string          `json:"commit_id"`
+	Comments []ReviewComment `json:"comments"`
+}
+
+type ReviewComment struct {
+	Path        string `json:"path"`
+	Body        string `json:"body"`
+	NewPosition int    `json:"new_position"`
+	OldPosition int    `json:"old_position"`
+}
+
+// Comment a posted issue comment, Deleted once the bot removed it
+type Comment struct {
+	Owner   string
+	Repo    string
+	Index   int
+	ID      int64
+	Body    string
+	Deleted bool
+}
+
+// Status a commit status set by the bot
+type Status struct {
+	Owner       string `json:"-"`
+	Repo        string `json:"-"`
+	SHA         string `json:"-"`
+	State       string `json:"state"`
+	Context     string `json:"context"`
+	Description string `json:"description"`
+	TargetURL   string `json:"target_url"`
+}
+
+// NewGitea starts a fake Gitea whose token owner is login, it's closed with the test
+func NewGitea(t testing.TB, login string) *Gitea {
+	g := &Gitea{
+		login:  login,
+		nextID: 1,
+		diffs:  make(map[string]string),
+		files:  make(map[string][]byte),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/v1/user", g.user)
+	mux.HandleFunc("GET /api/v1/repos/{owner}/{repo}/pulls/{diff}", g.diff)
+	mux.HandleFunc("POST /api/v1/repos/{owner}/{repo}/pulls/{index}/reviews", g.createReview)
+	mux.HandleFunc("POST /api/v1/repos/{owner}/{repo}/issues/{index}/comments", g.createComment)
+	mux.HandleFunc("PATCH /api/v1/repos/{owner}/{repo}/issues/comments/{id}", g.editComment)
+	mux.HandleFunc("DELETE /api/v1/repos/{owner}/{repo}/issues/comments/{id}", g.deleteComment)
+	mux.HandleFunc("POST /api/v1/repos/{owner}/{repo}/statuses/{sha}", g.createStatus)
+	mux.HandleFunc("GET /api/v1/repos/{owner}/{repo}/raw/{path...}", g.raw)
+	mux.HandleFunc("GET /api/v1/repos/{owner}/{repo}/contents/{path...}", g.contents)
+
+	g.server = httptest.NewServer(mux)
+	t.Cleanup(g.server.Close)
+	return g
+}
+
+// URL API base url, what the base_url of a gitea instance is set to
+func (g *Gitea) URL() string {
+	return g.server.URL + "/api/v1"
+}
+
+// HTMLURL web url of the repository, events are routed by its host
+func (g *Gitea) HTMLURL(owner string, repo string) string {
+	return g.server.URL + "/" + owner + "/" + repo
+}
+
+func (g *Gitea) SetDiff(owner string, repo string, index int, diff string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.diffs[pullKey(owner, repo, index)] = diff
+}
+
+func (g *Gitea) SetFile(owner string, repo string, path string, content []byte) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.files[owner+"/"+repo+"/"+path] = content
+}
+
+func (g *Gitea) Reviews() []Review {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return append([]Review(nil), g.reviews...)
+}
+
+func (g *Gitea) Comments() []Comment {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return append([]Comment(nil), g.comments...)
+}
+
+func (g *Gitea) Statuses() []Status {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return append([]Status(nil), g.statuses...)
+}
+
+func (g *Gitea) user(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, map[string]string{"login": g.login})
+}
+
+func (g *Gitea) diff(w http.ResponseWriter, r *http.Request) {
+	index, ok := strings.CutSuffix(r.PathValue("diff"), ".diff")
+	n, err := strconv.Atoi(index)
+	if !ok || err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	g.mu.Lock()
+	diff, ok := g.diffs[pullKey(r.PathValue("owner"), r.PathValue("repo"), n)]
+	g.mu.Unlock()
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	_, _ = w.Write([]byte(diff))
+}
+
+func (g *Gitea) createReview(w http.ResponseWriter, r *http.Request) {
+	var review Review
+	if !decode(w, r, &review) {
+		return
+	}
+	review.Owner, review.Repo = r.PathValue("owner"), r.PathValue("repo")
+	review.Index, _ = strconv.Atoi(r.PathValue("index"))
+
+	g.mu.Lock()
+	review.ID = g.id()
+	g.reviews = append(g.reviews, review)
+	g.mu.Unlock()
+	writeJSON(w, map[string]int64{"id": review.ID})
+}
+
+func (g *Gitea) createComment(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Body string `json:"body"`
+	}
+	if !decode(w, r, &body) {
+		return
+	}
+	c := Comment{Owner: r.PathValue("owner"), Repo: r.PathValue("repo"), Body: body.Body}
+	c.Index, _ = strconv.Atoi(r.PathValue("index"))
+
+	g.mu.Lock()
+	c.ID = g.id()
+	g.comments = append(g.comments, c)
+	g.mu.Unlock()
+	writeJSON(w, map[string]int64{"id": c.ID})
+}
+
+func (g *Gitea) editComment(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Body string `json:"body"`
+	}
+	if !decode(w, r, &body) {
+		return
+	}
+	g.updateComment(w, r, func(c *Comment) { c.Body = body.Body })
+}
+
+func (g *Gitea) deleteComment(w http.ResponseWriter, r *http.Request) {
+	g.updateComment(w, r, func(c *Comment) { c.Deleted = true })
+}
+
+func (g *Gitea) updateComment(w http.ResponseWriter, r *http.Request, update func(c *Comment)) {
+	id, _ := strconv.ParseInt(r.PathValue("id"), 10, 64)
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	for i := range g.comments {
+		if g.comments[i].ID == id && !g.comments[i].Deleted {
+			update(&g.comments[i])
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+	}
+	http.NotFound(w, r)
+}
+
+func (g *Gitea) createStatus(w http.ResponseWriter, r *http.Request) {
+	var s Status
+	if !decode(w, r, &s) {
+		return
+	}
+	s.Owner, s.Repo, s.SHA = r.PathValue("owner"), r.PathValue("repo"), r.PathValue("sha")
+
+	g.mu.Lock()
+	g.statuses = append(g.statuses, s)
+	g.mu.Unlock()
+	w.WriteHeader(http.StatusCreated)
+}
+
+func (g *Gitea) raw(w http.ResponseWriter, r *http.Request) {
+	content, ok := g.file(r)
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	_, _ = w.Write(content)
+}
+
+func (g *Gitea) contents(w http.ResponseWriter, r *http.Request) {
+	content, ok := g.file(r)
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	sum := sha1.Sum(content)
+	writeJSON(w, map[string]string{
+		"type":     "file",
+		"encoding": "base64",
+		"sha":      hex.EncodeToString(sum[:]),
+		"content":  base64.StdEncoding.EncodeToString(content),
+	})
+}
+
+func (g *Gitea) file(r *http.Request) ([]byte, bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	content, ok := g.files[r.PathValue("owner")+"/"+r.PathValue("repo")+"/"+r.PathValue("path")]
+	return content, ok
+}
+
+// id callers hold mu
+func (g *Gitea) id() int64 {
+	id := g.nextID
+	g.nextID++
+	return id
+}
+
+func pullKey(owner string, repo string, index int) string {
+	return owner + "/" + repo + "#" + strconv.Itoa(index)
+}
+
+func decode(w http.ResponseWriter, r *http.Request, v any) bool {
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return false
+	}
+	return true
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
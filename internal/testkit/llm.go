@@ -0,0 +1,165 @@
+package testkit
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+// response_format names of the requests the bot sends
+const (
+	FormatReview       = "ai_review_result"
+	FormatDescription  = "ai_description_result"
+	FormatChangelog    = "ai_changelog_result"
+	FormatSecurity     = "ai_security_result"
+	FormatVerification = "ai_verification_result"
+	FormatConvention   = "ai_convention_result"
+)
+
+// Response a scripted answer, Status defaults to 200 and FinishReason to "stop"
+type Response struct {
+	Status       int
+	Content      string
+	FinishReason string
+}
+
+// Message a chat message of a received request
+type Message struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// Request a chat completion the LLM received
+type Request struct {
+	Model    string
+	Format   string
+	Stream   bool
+	Messages []Message
+}
+
+// LLM a fake OpenAI compatible chat completions endpoint. Requests are answered in order with the
+// responses scripted for their response format, a request with nothing left gets 500
+type LLM struct {
+	server *httptest.Server
+
+	mu       sync.Mutex
+	scripts  map[string][]Response
+	requests []Request
+}
+
+// NewLLM starts a fake LLM, it's closed with the test
+func NewLLM(t testing.TB) *LLM {
+	l := &LLM{scripts: make(map[string][]Response)}
+	l.server = httptest.NewServer(http.HandlerFunc(l.serve))
+	t.Cleanup(l.server.Close)
+	return l
+}
+
+// URL chat completions url, what ai.url is set to
+func (l *LLM) URL() string {
+	return l.server.URL + "/v1/chat/completions"
+}
+
+// Script queues responses for requests of the format
+func (l *LLM) Script(format string, responses ...Response) *LLM {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.scripts[format] = append(l.scripts[format], responses...)
+	return l
+}
+
+// Reply queues successful responses with the contents for requests of the format
+func (l *LLM) Reply(format string, contents ...string) *LLM {
+	for _, c := range contents {
+		l.Script(format, Response{Content: c})
+	}
+	return l
+}
+
+// ReplyJSON queues a successful response with v encoded as its content
+func (l *LLM) ReplyJSON(t testing.TB, format string, v any) *LLM {
+	t.Helper()
+	content, err := json.Marshal(v)
+	if err != nil {
+		t.Fatalf("marshal llm reply: %v", err)
+	}
+	return l.Reply(format, string(content))
+}
+
+func (l *LLM) Requests() []Request {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]Request(nil), l.requests...)
+}
+
+func (l *LLM) serve(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Model          string    `json:"model"`
+		Stream         bool      `json:"stream"`
+		Messages       []Message `json:"messages"`
+		ResponseFormat struct {
+			JSONSchema struct {
+				Name string `json:"name"`
+			} `json:"json_schema"`
+		} `json:"response_format"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	format := body.ResponseFormat.JSONSchema.Name
+
+	l.mu.Lock()
+	l.requests = append(l.requests, Request{
+		Model:    body.Model,
+		Format:   format,
+		Stream:   body.Stream,
+		Messages: body.Messages,
+	})
+	script := l.scripts[format]
+	var resp Response
+	ok := len(script) > 0
+	if ok {
+		resp, l.scripts[format] = script[0], script[1:]
+	}
+	l.mu.Unlock()
+
+	if !ok {
+		http.Error(w, fmt.Sprintf("no scripted response for %q", format), http.StatusInternalServerError)
+		return
+	}
+	if resp.Status != 0 && resp.Status != http.StatusOK {
+		http.Error(w, resp.Content, resp.Status)
+		return
+	}
+	if resp.FinishReason == "" {
+		resp.FinishReason = "stop"
+	}
+
+	if body.Stream {
+		writeStream(w, resp)
+		return
+	}
+	writeJSON(w, map[string]any{
+		"choices": []map[string]any{{
+			"message":       map[string]string{"role": "assistant", "content": resp.Content},
+			"finish_reason": resp.FinishReason,
+		}},
+	})
+}
+
+// writeStream sends the content as a single delta followed by the finish reason
+func writeStream(w http.ResponseWriter, resp Response) {
+	w.Header().Set("Content-Type", "text/event-stream")
+	for _, choice := range []map[string]any{
+		{"delta": map[string]string{"content": resp.Content}},
+		{"delta": map[string]string{}, "finish_reason": resp.FinishReason},
+	} {
+		chunk, _ := json.Marshal(map[string]any{"choices": []map[string]any{choice}})
+		_, _ = fmt.Fprintf(w, "data: %s\n\n", chunk)
+	}
+	_, _ = fmt.Fprint(w, "data: [DONE]\n\n")
+}
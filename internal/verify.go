@@ -0,0 +1,171 @@
+package internal
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"go-snob/internal/actor/ai"
+	"go-snob/internal/actor/vcs"
+	"go-snob/internal/config"
+	"go-snob/internal/diff"
+	"go-snob/internal/prompt"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+// verificationItem a comment as the verifying model sees it
+type verificationItem struct {
+	ID   int    `json:"id"`
+	File string `json:"file"`
+	Line int    `json:"line"`
+	// Side "new" for lines after the change, "old" for removed ones
+	Side    string `json:"side"`
+	Message string `json:"message"`
+	Hunk    string `json:"hunk"`
+}
+
+// verification what became of a comment, the result label of go-snob_verified_comments_total
+type verification string
+
+const (
+	verificationConfirmed verification = "confirmed"
+	verificationRejected  verification = "rejected"
+	// verificationUnverified the batch failed or the model skipped the comment, it's dropped unless
+	// verification.on_error is "post"
+	verificationUnverified verification = "unverified"
+)
+
+// verifyComments sends comments back to the model in batches together with their hunks and keeps only the
+// ones it confirms. Comments of a failed batch or without a verdict are kept only with on_error "post".
+func (o *Orchestrator) verifyComments(
+	ctx context.Context,
+	logger *zap.Logger,
+	rawDiff string,
+	data prompt.Data,
+	r ai.AIReviewResult,
+	cfg config.VerificationConfig,
+) ai.AIReviewResult {
+	if len(r.Comments) == 0 {
+		return r
+	}
+	files := diff.Parse(rawDiff)
+
+	kept := make([]ai.Comment, 0, len(r.Comments))
+	for start := 0; start < len(r.Comments); start += cfg.BatchSize {
+		batch := r.Comments[start:min(start+cfg.BatchSize, len(r.Comments))]
+		results, err := o.verifyBatch(ctx, files, data, batch, cfg)
+		if err != nil {
+			logFailure(ctx, logger.With(zap.String("on_error", cfg.OnError)), "failed to verify comments", err)
+			results = make([]verification, len(batch))
+			for i := range results {
+				results[i] = verificationUnverified
+			}
+		}
+		for i, c := range batch {
+			verifiedComments.WithLabelValues(string(results[i])).Inc()
+			switch {
+			case results[i] == verificationConfirmed:
+			case results[i] == verificationUnverified && cfg.OnError == config.VerificationOnErrorPost:
+			default:
+				logger.Debug("comment dropped by verification", zap.String("result", string(results[i])),
+					zap.String("file", c.File), zap.String("message", c.Message))
+				continue
+			}
+			kept = append(kept, c)
+		}
+	}
+	logger.Info("comments verified", zap.Int("comments", len(r.Comments)), zap.Int("kept", len(kept)))
+
+	r.Comments = kept
+	// changes can't be requested without anything to change
+	if len(kept) == 0 && vcs.Verdict(r.Verdict) == vcs.VerdictRequestChanges {
+		r.Verdict = string(vcs.VerdictComment)
+	}
+	return r
+}
+
+// verifyBatch reports the verification of every comment of batch, comments the model skipped are unverified
+func (o *Orchestrator) verifyBatch(
+	ctx context.Context,
+	files []diff.File,
+	data prompt.Data,
+	batch []ai.Comment,
+	cfg config.VerificationConfig,
+) ([]verification, error) {
+	items := make([]verificationItem, 0, len(batch))
+	for i, c := range batch {
+		line, side := c.NewPosition, "new"
+		if line == 0 {
+			line, side = c.OldPosition, "old"
+		}
+		items = append(items, verificationItem{
+			ID:      i,
+			File:    c.File,
+			Line:    line,
+			Side:    side,
+			Message: c.Message,
+			Hunk:    hunkOf(files, c),
+		})
+	}
+	raw, err := json.Marshal(items)
+	if err != nil {
+		return nil, fmt.Errorf("marshal comments: %w", err)
+	}
+	data.Candidates = prompt.Fence("comments", string(raw))
+
+	systemPrompt, userPrompt, err := prompt.RenderMessages(cfg.SystemPromptTemplate(), cfg.UserPromptTemplate(), data)
+	if err != nil {
+		return nil, err
+	}
+	res, err := o.aiClient.Verify(ctx, systemPrompt, userPrompt)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]verification, len(batch))
+	for i := range results {
+		results[i] = verificationUnverified
+	}
+	for _, v := range res.Verdicts {
+		if v.ID < 0 || v.ID >= len(batch) {
+			continue
+		}
+		results[v.ID] = verificationRejected
+		if v.Valid && v.Confidence >= cfg.Threshold {
+			results[v.ID] = verificationConfirmed
+		}
+	}
+	return results, nil
+}
+
+// hunkOf returns the hunk the comment points at, empty if the line isn't in the diff
+func hunkOf(files []diff.File, c ai.Comment) string {
+	if c.NewPosition == 0 && c.OldPosition == 0 {
+		return ""
+	}
+	for _, f := range files {
+		if f.Path() != c.File {
+			continue
+		}
+		for _, h := range f.Hunks {
+			for _, l := range h.Lines {
+				if c.NewPosition > 0 && l.NewLine == c.NewPosition || c.NewPosition == 0 && l.OldLine == c.OldPosition {
+					return renderHunk(h)
+				}
+			}
+		}
+	}
+	return ""
+}
+
+func renderHunk(h diff.Hunk) string {
+	var b strings.Builder
+	b.WriteString(h.Header)
+	for _, l := range h.Lines {
+		b.WriteByte('\n')
+		b.WriteByte(byte(l.Kind))
+		b.WriteString(l.Content)
+	}
+	return b.String()
+}
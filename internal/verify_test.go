@@ -0,0 +1,74 @@
+package internal
+
+import (
+	"context"
+	"go-snob/internal/actor/ai"
+	"go-snob/internal/config"
+	"go-snob/internal/prompt"
+	"go-snob/internal/testkit"
+	"net/http"
+	"slices"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"go.uber.org/zap"
+	"resty.dev/v3"
+)
+
+func TestVerifyCommentsUnverified(t *testing.T) {
+	tests := []struct {
+		onError string
+		want    []string
+	}{
+		{onError: "", want: []string{"confirmed"}},
+		{onError: "drop", want: []string{"confirmed"}},
+		{onError: "post", want: []string{"confirmed", "no verdict", "failed batch"}},
+	}
+	for _, tt := range tests {
+		t.Run("on_error "+tt.onError, func(t *testing.T) {
+			// the first batch skips a comment, the second one fails
+			llm := testkit.NewLLM(t).
+				ReplyJSON(t, testkit.FormatVerification, ai.AIVerificationResult{Verdicts: []ai.CommentVerdict{
+					{ID: 0, Valid: true, Confidence: 0.9},
+					{ID: 2, Valid: false, Confidence: 0.9},
+				}}).
+				Script(testkit.FormatVerification, testkit.Response{Status: http.StatusBadRequest})
+			o := &Orchestrator{aiClient: ai.NewClient(resty.New(), zap.NewNop(), llm.URL(), "token")}
+			yaml := "system_prompt: review\ngitea:\n  - name: local\n    base_url: http://localhost/api/v1\n" +
+				"ai:\n  url: http://localhost/v1\nverification:\n  batch_size: 3\n"
+			if tt.onError != "" {
+				yaml += "  on_error: " + tt.onError + "\n"
+			}
+			parsed, err := config.Parse([]byte(yaml))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			before := map[verification]float64{}
+			for _, v := range []verification{verificationConfirmed, verificationRejected, verificationUnverified} {
+				before[v] = testutil.ToFloat64(verifiedComments.WithLabelValues(string(v)))
+			}
+
+			r := o.verifyComments(context.Background(), zap.NewNop(), "", prompt.Data{}, ai.AIReviewResult{
+				Verdict: "REQUEST_CHANGES",
+				Comments: []ai.Comment{
+					{Message: "confirmed"}, {Message: "no verdict"}, {Message: "rejected"}, {Message: "failed batch"},
+				},
+			}, parsed.Verification)
+
+			var got []string
+			for _, c := range r.Comments {
+				got = append(got, c.Message)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("posted %q, want %q", got, tt.want)
+			}
+			counted := map[verification]float64{verificationConfirmed: 1, verificationRejected: 1, verificationUnverified: 2}
+			for v, want := range counted {
+				if got := testutil.ToFloat64(verifiedComments.WithLabelValues(string(v))) - before[v]; got != want {
+					t.Errorf("%s comments = %v, want %v", v, got, want)
+				}
+			}
+		})
+	}
+}
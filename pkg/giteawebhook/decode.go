@@ -0,0 +1,51 @@
+package giteawebhook
+
+import (
+	"encoding/json"
+	"fmt"
+	"go-snob/pkg/http/pipeline"
+	"io"
+	"net/http"
+	"time"
+)
+
+// DecodeDelivery picks the payload type by X-Gitea-Event and validates the decoded body
+func DecodeDelivery() pipeline.HandlerOut[Delivery] {
+	return func(ctx *pipeline.Ctx) (Delivery, error) {
+		d := Delivery{
+			ID:         ctx.Request.Header.Get("X-Gitea-Delivery"),
+			Event:      EventType(ctx.Request.Header.Get("X-Gitea-Event")),
+			ReceivedAt: time.Now(),
+		}
+
+		newPayload, ok := newPayloads[d.Event]
+		if !ok {
+			return d, pipeline.NewError(http.StatusBadRequest, "unsupported event",
+				fmt.Errorf("unsupported event: %q", d.Event))
+		}
+
+		body, err := io.ReadAll(ctx.Request.Body)
+		if err != nil {
+			return d, pipeline.NewError(http.StatusBadRequest, "failed to read body", err)
+		}
+		d.Body = body
+		d.Payload = newPayload()
+		if err := json.Unmarshal(body, d.Payload); err != nil {
+			return d, pipeline.NewError(http.StatusBadRequest, "bad request body decode", err)
+		}
+		if err := d.Payload.Validate(); err != nil {
+			return d, pipeline.NewError(http.StatusBadRequest, fmt.Sprintf("invalid %s payload: %v", d.Event, err), err)
+		}
+
+		return d, nil
+	}
+}
+
+// SupportedEvents event types the webhook decodes
+func SupportedEvents() []string {
+	events := make([]string, 0, len(newPayloads))
+	for e := range newPayloads {
+		events = append(events, string(e))
+	}
+	return events
+}
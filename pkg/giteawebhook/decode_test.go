@@ -0,0 +1,56 @@
+package giteawebhook
+
+import (
+	"errors"
+	"go-snob/pkg/http/pipeline"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+func TestDecodeDeliveryOnlyReturnsErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		event string
+		body  string
+	}{
+		{name: "unsupported event", event: "wiki", body: `{}`},
+		{name: "malformed body", event: "push", body: `{`},
+		{name: "invalid payload", event: "pull_request", body: `{}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/webhook", strings.NewReader(tt.body))
+			r.Header.Set("X-Gitea-Event", tt.event)
+			w := httptest.NewRecorder()
+
+			_, err := DecodeDelivery()(pipeline.NewCtx(w, r))
+			var pe *pipeline.Error
+			if !errors.As(err, &pe) || pe.Status != http.StatusBadRequest {
+				t.Fatalf("expected a 400 pipeline error, got %v", err)
+			}
+			// the pipeline renders the error, a response written here would be written twice
+			if w.Body.Len() != 0 || len(w.Header()) != 0 {
+				t.Errorf("decoder wrote the response: %q", w.Body.String())
+			}
+		})
+	}
+}
+
+func TestDecodeDeliveryRendersOnce(t *testing.T) {
+	p := pipeline.NewPipeline(zap.NewNop(), pipeline.Out(DecodeDelivery()))
+	r := httptest.NewRequest(http.MethodPost, "/webhook", strings.NewReader(`{`))
+	r.Header.Set("X-Gitea-Event", "push")
+	w := httptest.NewRecorder()
+	p.ServeHTTP(w, r)
+
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("unexpected status: %d", w.Code)
+	}
+	if got := w.Body.String(); strings.Count(got, "bad request body decode") != 1 {
+		t.Errorf("error must be rendered once: %q", got)
+	}
+}
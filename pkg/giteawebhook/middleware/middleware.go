@@ -8,49 +8,19 @@ import (
 	"go-snob/pkg/http/pipeline"
 	"io"
 	"net/http"
-	"os"
-
-	"go.uber.org/zap"
 )
 
-func RawWebhookLog(logger *zap.Logger) pipeline.MiddlewareFunc {
-	return func(ctx *pipeline.Ctx, next pipeline.NextFunc) {
-		buf := new(bytes.Buffer)
-
-		tee := io.TeeReader(ctx.Request.Body, buf)
-
-		bodyBytes, err := io.ReadAll(tee)
-		if err != nil {
-			logger.Error("failed to read body", zap.Error(err))
-			return
-		}
-		_ = ctx.Request.Body.Close()
-
-		// TODO: need to restore bytes cause reader has read them till EOF, have to think about good solution
-		ctx.Request.Body = io.NopCloser(bytes.NewReader(bodyBytes))
-
-		// RawWebhookLog TODO: need to do something with this shit and path correct dir
-		if f, err := os.CreateTemp(".", "webhook_*.json"); err == nil {
-			_, _ = f.Write(buf.Bytes())
-			_ = f.Close()
-			logger.Info("raw webhook was logged", zap.String("path", f.Name()))
-		}
-
-		next()
-	}
-}
-
 func CheckSecret(secret string) pipeline.MiddlewareFunc {
 	return func(ctx *pipeline.Ctx, next pipeline.NextFunc) {
 		body, err := io.ReadAll(ctx.Request.Body)
 		if err != nil || len(body) == 0 {
-			http.Error(ctx.Writer, "empty payload", http.StatusBadRequest)
+			ctx.Fail(pipeline.NewError(http.StatusBadRequest, "empty payload", err))
 			return
 		}
 
 		headerSig := ctx.Request.Header.Get("X-Gitea-Signature")
 		if headerSig == "" {
-			http.Error(ctx.Writer, "signature header missing", http.StatusBadRequest)
+			ctx.Fail(pipeline.NewError(http.StatusBadRequest, "signature header missing", nil))
 			return
 		}
 
@@ -59,7 +29,7 @@ func CheckSecret(secret string) pipeline.MiddlewareFunc {
 		expectedSig := hex.EncodeToString(mac.Sum(nil))
 
 		if headerSig != expectedSig {
-			http.Error(ctx.Writer, "invalid hmac signature", http.StatusUnauthorized)
+			ctx.Fail(pipeline.NewError(http.StatusUnauthorized, "invalid hmac signature", nil))
 			return
 		}
 
@@ -70,14 +40,19 @@ func CheckSecret(secret string) pipeline.MiddlewareFunc {
 	}
 }
 
-func Push[P any](ch chan<- P) pipeline.HandlerIn[P] {
-	return func(ctx *pipeline.Ctx, in P) error {
-		select {
-		case <-ctx.Request.Context().Done():
-			http.Error(ctx.Writer, "request cancelled", http.StatusRequestTimeout)
-		case ch <- in:
+// AllowedEvents acknowledges deliveries of other event types without processing them
+func AllowedEvents(events ...string) pipeline.MiddlewareFunc {
+	allowed := make(map[string]struct{}, len(events))
+	for _, e := range events {
+		allowed[e] = struct{}{}
+	}
+
+	return func(ctx *pipeline.Ctx, next pipeline.NextFunc) {
+		if _, ok := allowed[ctx.Request.Header.Get("X-Gitea-Event")]; ok {
+			next()
+			return
 		}
 
-		return nil
+		ctx.Writer.WriteHeader(http.StatusNoContent)
 	}
 }
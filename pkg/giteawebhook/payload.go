@@ -1,19 +1,101 @@
 package giteawebhook
 
-import "fmt"
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// EventType value of X-Gitea-Event header
+type EventType string
+
+const (
+	EventPullRequest         EventType = "pull_request"
+	EventPullRequestApproved EventType = "pull_request_approved"
+	EventPullRequestRejected EventType = "pull_request_rejected"
+	EventPullRequestComment  EventType = "pull_request_comment"
+	EventIssueComment        EventType = "issue_comment"
+	EventPush                EventType = "push"
+	EventRepository          EventType = "repository"
+)
+
+// Payload is implemented by every typed webhook body
+type Payload interface {
+	Validate() error
+}
+
+// newPayloads creates an empty payload to decode a body of the event type into
+var newPayloads = map[EventType]func() Payload{
+	EventPullRequest:         func() Payload { return &PullRequestPayload{} },
+	EventPullRequestApproved: func() Payload { return &PullRequestReviewPayload{} },
+	EventPullRequestRejected: func() Payload { return &PullRequestReviewPayload{} },
+	EventPullRequestComment:  func() Payload { return &PullRequestReviewPayload{} },
+	EventIssueComment:        func() Payload { return &IssueCommentPayload{} },
+	EventPush:                func() Payload { return &PushPayload{} },
+	EventRepository:          func() Payload { return &RepositoryPayload{} },
+}
+
+// Delivery a single webhook call with its decoded payload
+type Delivery struct {
+	// ID value of X-Gitea-Delivery header
+	ID         string
+	Event      EventType
+	ReceivedAt time.Time
+	Payload    Payload
+	// Body raw request body the payload was decoded from
+	Body []byte
+}
 
 type Action string
 
 const (
+	ActionOpened               Action = "opened"
+	ActionEdited               Action = "edited"
+	ActionClosed               Action = "closed"
+	ActionReopened             Action = "reopened"
+	ActionAssigned             Action = "assigned"
+	ActionUnassigned           Action = "unassigned"
+	ActionLabelUpdated         Action = "label_updated"
+	ActionLabelCleared         Action = "label_cleared"
+	ActionSynchronized         Action = "synchronized"
+	ActionMilestoned           Action = "milestoned"
+	ActionDemilestoned         Action = "demilestoned"
+	ActionReviewed             Action = "reviewed"
 	ActionReviewRequest        Action = "review_requested"
 	ActionReviewRequestRemoved Action = "review_request_removed"
+
+	ActionCreated Action = "created"
+	ActionDeleted Action = "deleted"
 )
 
 var validActions = map[Action]struct{}{
+	ActionOpened:               {},
+	ActionEdited:               {},
+	ActionClosed:               {},
+	ActionReopened:             {},
+	ActionAssigned:             {},
+	ActionUnassigned:           {},
+	ActionLabelUpdated:         {},
+	ActionLabelCleared:         {},
+	ActionSynchronized:         {},
+	ActionMilestoned:           {},
+	ActionDemilestoned:         {},
+	ActionReviewed:             {},
 	ActionReviewRequest:        {},
 	ActionReviewRequestRemoved: {},
 }
 
+var validCommentActions = map[Action]struct{}{
+	ActionCreated: {},
+	ActionEdited:  {},
+	ActionDeleted: {},
+}
+
+var validRepositoryActions = map[Action]struct{}{
+	ActionCreated: {},
+	ActionDeleted: {},
+}
+
 func (a Action) Validate() error {
 	if _, ok := validActions[a]; !ok {
 		return fmt.Errorf("invalid Action: %q", a)
@@ -21,25 +103,260 @@ func (a Action) Validate() error {
 	return nil
 }
 
-type PullRequest struct {
-	ID      int    `json:"id"`
-	DiffURL string `json:"diff_url"`
-	Head    struct {
-		SHA string `json:"sha"`
-	} `json:"head"`
+type User struct {
+	ID       int64  `json:"id"`
+	Login    string `json:"login"`
+	FullName string `json:"full_name"`
+	Email    string `json:"email"`
+	HTMLURL  string `json:"html_url"`
+	IsAdmin  bool   `json:"is_admin"`
 }
 
-type Owner struct {
-	Login string `json:"login"`
+type Label struct {
+	ID          int64  `json:"id"`
+	Name        string `json:"name"`
+	Color       string `json:"color"`
+	Description string `json:"description"`
 }
 
 type Repository struct {
-	Name  string `json:"name"`
-	Owner Owner  `json:"owner"`
+	ID            int64  `json:"id"`
+	Name          string `json:"name"`
+	FullName      string `json:"full_name"`
+	Description   string `json:"description"`
+	HTMLURL       string `json:"html_url"`
+	CloneURL      string `json:"clone_url"`
+	SSHURL        string `json:"ssh_url"`
+	DefaultBranch string `json:"default_branch"`
+	Private       bool   `json:"private"`
+	Fork          bool   `json:"fork"`
+	Archived      bool   `json:"archived"`
+	Owner         User   `json:"owner"`
+}
+
+func (r Repository) Validate() error {
+	if r.Name == "" {
+		return errors.New("repository name is empty")
+	}
+	if r.Owner.Login == "" {
+		return errors.New("repository owner login is empty")
+	}
+	return nil
+}
+
+type Branch struct {
+	Label  string      `json:"label"`
+	Ref    string      `json:"ref"`
+	SHA    string      `json:"sha"`
+	RepoID int64       `json:"repo_id"`
+	Repo   *Repository `json:"repo,omitempty"`
+}
+
+type Milestone struct {
+	ID    int64  `json:"id"`
+	Title string `json:"title"`
+	State string `json:"state"`
+}
+
+// PullRequest ID is the database id, Number is the index used in api paths and urls
+type PullRequest struct {
+	ID                 int64      `json:"id"`
+	Number             int        `json:"number"`
+	Title              string     `json:"title"`
+	Body               string     `json:"body"`
+	User               User       `json:"user"`
+	Labels             []Label    `json:"labels"`
+	Milestone          *Milestone `json:"milestone,omitempty"`
+	Assignees          []User     `json:"assignees"`
+	RequestedReviewers []User     `json:"requested_reviewers"`
+	State              string     `json:"state"`
+	Draft              bool       `json:"draft"`
+	IsLocked           bool       `json:"is_locked"`
+	Comments           int        `json:"comments"`
+	HTMLURL            string     `json:"html_url"`
+	DiffURL            string     `json:"diff_url"`
+	PatchURL           string     `json:"patch_url"`
+	Mergeable          bool       `json:"mergeable"`
+	Merged             bool       `json:"merged"`
+	MergedAt           *time.Time `json:"merged_at,omitempty"`
+	MergeCommitSHA     string     `json:"merge_commit_sha"`
+	MergedBy           *User      `json:"merged_by,omitempty"`
+	MergeBase          string     `json:"merge_base"`
+	Head               Branch     `json:"head"`
+	Base               Branch     `json:"base"`
+	CreatedAt          time.Time  `json:"created_at"`
+	UpdatedAt          time.Time  `json:"updated_at"`
+	ClosedAt           *time.Time `json:"closed_at,omitempty"`
+}
+
+func (p PullRequest) Validate() error {
+	if p.Number <= 0 {
+		return errors.New("pull request number is not set")
+	}
+	if p.Head.SHA == "" {
+		return errors.New("pull request head sha is empty")
+	}
+	return nil
+}
+
+type ChangeFrom struct {
+	From string `json:"from"`
+}
+
+// Changes previous values for "edited" actions
+type Changes struct {
+	Title *ChangeFrom `json:"title,omitempty"`
+	Body  *ChangeFrom `json:"body,omitempty"`
+	Ref   *ChangeFrom `json:"ref,omitempty"`
+}
+
+type Review struct {
+	// Type "pull_request_review_approved", "pull_request_review_rejected" or "pull_request_review_comment"
+	Type    string `json:"type"`
+	Content string `json:"content"`
+}
+
+type PullRequestPayload struct {
+	Action            Action      `json:"action"`
+	Number            int         `json:"number"`
+	PullRequest       PullRequest `json:"pull_request"`
+	RequestedReviewer *User       `json:"requested_reviewer,omitempty"`
+	Label             *Label      `json:"label,omitempty"`
+	Changes           *Changes    `json:"changes,omitempty"`
+	Repository        Repository  `json:"repository"`
+	Sender            User        `json:"sender"`
+	CommitID          string      `json:"commit_id"`
 }
 
-type Payload struct {
+func (p *PullRequestPayload) Validate() error {
+	return errors.Join(p.Action.Validate(), p.PullRequest.Validate(), p.Repository.Validate())
+}
+
+// PullRequestReviewPayload sent for pull_request_approved, pull_request_rejected and pull_request_comment
+type PullRequestReviewPayload struct {
 	Action      Action      `json:"action"`
+	Number      int         `json:"number"`
 	PullRequest PullRequest `json:"pull_request"`
+	Review      Review      `json:"review"`
 	Repository  Repository  `json:"repository"`
+	Sender      User        `json:"sender"`
+	CommitID    string      `json:"commit_id"`
+}
+
+func (p *PullRequestReviewPayload) Validate() error {
+	var errs []error
+	if p.Action != ActionReviewed {
+		errs = append(errs, fmt.Errorf("invalid review Action: %q", p.Action))
+	}
+	if p.Review.Type == "" {
+		errs = append(errs, errors.New("review type is empty"))
+	}
+	return errors.Join(append(errs, p.PullRequest.Validate(), p.Repository.Validate())...)
+}
+
+type Issue struct {
+	ID        int64   `json:"id"`
+	Number    int     `json:"number"`
+	Title     string  `json:"title"`
+	Body      string  `json:"body"`
+	User      User    `json:"user"`
+	Labels    []Label `json:"labels"`
+	Assignees []User  `json:"assignees"`
+	State     string  `json:"state"`
+	HTMLURL   string  `json:"html_url"`
+	// PullRequest is set if the issue is a pull request
+	PullRequest *struct {
+		Merged  bool   `json:"merged"`
+		HTMLURL string `json:"html_url"`
+	} `json:"pull_request,omitempty"`
+}
+
+type Comment struct {
+	ID        int64     `json:"id"`
+	HTMLURL   string    `json:"html_url"`
+	User      User      `json:"user"`
+	Body      string    `json:"body"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type IssueCommentPayload struct {
+	Action     Action     `json:"action"`
+	Issue      Issue      `json:"issue"`
+	Comment    Comment    `json:"comment"`
+	Changes    *Changes   `json:"changes,omitempty"`
+	Repository Repository `json:"repository"`
+	Sender     User       `json:"sender"`
+	IsPull     bool       `json:"is_pull"`
+}
+
+func (p *IssueCommentPayload) Validate() error {
+	var errs []error
+	if _, ok := validCommentActions[p.Action]; !ok {
+		errs = append(errs, fmt.Errorf("invalid comment Action: %q", p.Action))
+	}
+	if p.Issue.Number <= 0 {
+		errs = append(errs, errors.New("issue number is not set"))
+	}
+	if p.Comment.ID <= 0 {
+		errs = append(errs, errors.New("comment id is not set"))
+	}
+	return errors.Join(append(errs, p.Repository.Validate())...)
+}
+
+type CommitUser struct {
+	Name     string `json:"name"`
+	Email    string `json:"email"`
+	Username string `json:"username"`
+}
+
+type Commit struct {
+	ID        string     `json:"id"`
+	Message   string     `json:"message"`
+	URL       string     `json:"url"`
+	Author    CommitUser `json:"author"`
+	Committer CommitUser `json:"committer"`
+	Timestamp time.Time  `json:"timestamp"`
+	Added     []string   `json:"added"`
+	Removed   []string   `json:"removed"`
+	Modified  []string   `json:"modified"`
+}
+
+type PushPayload struct {
+	Ref          string     `json:"ref"`
+	Before       string     `json:"before"`
+	After        string     `json:"after"`
+	CompareURL   string     `json:"compare_url"`
+	Commits      []Commit   `json:"commits"`
+	TotalCommits int        `json:"total_commits"`
+	HeadCommit   *Commit    `json:"head_commit,omitempty"`
+	Repository   Repository `json:"repository"`
+	Pusher       User       `json:"pusher"`
+	Sender       User       `json:"sender"`
+}
+
+func (p *PushPayload) Validate() error {
+	var errs []error
+	if p.Ref == "" {
+		errs = append(errs, errors.New("push ref is empty"))
+	}
+	if p.After == "" {
+		errs = append(errs, errors.New("push after sha is empty"))
+	}
+	return errors.Join(append(errs, p.Repository.Validate())...)
+}
+
+type RepositoryPayload struct {
+	Action       Action     `json:"action"`
+	Repository   Repository `json:"repository"`
+	Organization *User      `json:"organization,omitempty"`
+	Sender       User       `json:"sender"`
+}
+
+func (p *RepositoryPayload) Validate() error {
+	var errs []error
+	if _, ok := validRepositoryActions[p.Action]; !ok {
+		errs = append(errs, fmt.Errorf("invalid repository Action: %q", p.Action))
+	}
+	return errors.Join(append(errs, p.Repository.Validate())...)
 }
@@ -15,10 +15,9 @@ const defaultPayloadChanCapacity = 1024
 type Webhook struct {
 	logger *zap.Logger
 
-	debug  bool
 	secret string
 
-	workers *workerpool.Pool[Payload]
+	workers *workerpool.Pool[Delivery]
 }
 
 func (wh *Webhook) Run(ctx context.Context) error {
@@ -35,35 +34,26 @@ func (wh *Webhook) Stop(ctx context.Context) error {
 	return nil
 }
 
-func NewWebhook(proc workerpool.Processor[Payload], logger *zap.Logger) *Webhook {
+func NewWebhook(proc workerpool.Processor[Delivery], logger *zap.Logger) *Webhook {
 	return &Webhook{
 		logger:  logger,
-		workers: workerpool.NewPool[Payload](proc, 5, defaultPayloadChanCapacity),
+		workers: workerpool.NewPool[Delivery](proc, 5, defaultPayloadChanCapacity),
 	}
 }
 
-func (wh *Webhook) WithDebug() *Webhook {
-	wh.debug = true
-	return wh
-}
-
 func (wh *Webhook) WithSecret(secret string) *Webhook {
 	wh.secret = secret
 	return wh
 }
 
 func (wh *Webhook) WithPayloadChanCapacity(capacity int) *Webhook {
-	wh.workers.SetChan(make(chan Payload, capacity))
+	wh.workers.SetChan(make(chan Delivery, capacity))
 	return wh
 }
 
 func (wh *Webhook) Handler() http.Handler {
-	p := pipeline.NewPipeline()
-	if wh.debug {
-		p.WithMiddlewares(middleware.RawWebhookLog(wh.logger))
-	}
-
-	p.WithMiddlewares(
+	p := pipeline.NewPipeline(
+		wh.logger,
 		pipeline.AllowedMethods(http.MethodPost),
 		pipeline.AllowedContentType("application/json"),
 	)
@@ -73,8 +63,9 @@ func (wh *Webhook) Handler() http.Handler {
 	}
 
 	p.WithMiddlewares(
-		pipeline.Out(pipeline.DecodeJSON[Payload]()),
-		pipeline.In(middleware.Push[Payload](wh.workers.WrChan())),
+		middleware.AllowedEvents(SupportedEvents()...),
+		pipeline.Out(DecodeDelivery()),
+		pipeline.In(pipeline.Push[Delivery](wh.workers.WrChan())),
 	)
 
 	return p
@@ -0,0 +1,40 @@
+package githubwebhook
+
+import (
+	"encoding/json"
+	"go-snob/pkg/http/pipeline"
+	"io"
+	"net/http"
+	"time"
+)
+
+// Delivery a decoded webhook request
+type Delivery struct {
+	// ID value of X-GitHub-Delivery header
+	ID    string
+	Event string
+	// ReceivedAt when the request came in, jobs are ordered by it rather than by the time a worker took them
+	ReceivedAt time.Time
+	Payload    Payload
+	// Body raw request body the payload was decoded from
+	Body []byte
+}
+
+func DecodeDelivery() pipeline.HandlerOut[Delivery] {
+	return func(ctx *pipeline.Ctx) (Delivery, error) {
+		d := Delivery{
+			ID:         ctx.Request.Header.Get("X-GitHub-Delivery"),
+			Event:      ctx.Request.Header.Get("X-GitHub-Event"),
+			ReceivedAt: time.Now(),
+		}
+		body, err := io.ReadAll(ctx.Request.Body)
+		if err != nil {
+			return d, pipeline.NewError(http.StatusBadRequest, "failed to read body", err)
+		}
+		d.Body = body
+		if err := json.Unmarshal(body, &d.Payload); err != nil {
+			return d, pipeline.NewError(http.StatusBadRequest, "bad request body decode", err)
+		}
+		return d, nil
+	}
+}
@@ -0,0 +1,60 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"go-snob/pkg/http/pipeline"
+	"io"
+	"net/http"
+	"strings"
+)
+
+const signaturePrefix = "sha256="
+
+func CheckSignature(secret string) pipeline.MiddlewareFunc {
+	return func(ctx *pipeline.Ctx, next pipeline.NextFunc) {
+		body, err := io.ReadAll(ctx.Request.Body)
+		if err != nil || len(body) == 0 {
+			ctx.Fail(pipeline.NewError(http.StatusBadRequest, "empty payload", err))
+			return
+		}
+
+		headerSig := ctx.Request.Header.Get("X-Hub-Signature-256")
+		if !strings.HasPrefix(headerSig, signaturePrefix) {
+			ctx.Fail(pipeline.NewError(http.StatusBadRequest, "signature header missing", nil))
+			return
+		}
+
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write(body)
+		expectedSig := hex.EncodeToString(mac.Sum(nil))
+
+		if !hmac.Equal([]byte(strings.TrimPrefix(headerSig, signaturePrefix)), []byte(expectedSig)) {
+			ctx.Fail(pipeline.NewError(http.StatusUnauthorized, "invalid hmac signature", nil))
+			return
+		}
+
+		ctx.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		next()
+	}
+}
+
+// AllowedEvents acknowledges deliveries of other event types (e.g. "ping") without processing them
+func AllowedEvents(events ...string) pipeline.MiddlewareFunc {
+	allowed := make(map[string]struct{}, len(events))
+	for _, e := range events {
+		allowed[e] = struct{}{}
+	}
+
+	return func(ctx *pipeline.Ctx, next pipeline.NextFunc) {
+		if _, ok := allowed[ctx.Request.Header.Get("X-GitHub-Event")]; ok {
+			next()
+			return
+		}
+
+		ctx.Writer.WriteHeader(http.StatusNoContent)
+	}
+}
@@ -0,0 +1,116 @@
+package githubwebhook
+
+import "fmt"
+
+type Action string
+
+const (
+	ActionOpened               Action = "opened"
+	ActionEdited               Action = "edited"
+	ActionSynchronize          Action = "synchronize"
+	ActionReadyForReview       Action = "ready_for_review"
+	ActionConvertedToDraft     Action = "converted_to_draft"
+	ActionClosed               Action = "closed"
+	ActionReviewRequested      Action = "review_requested"
+	ActionReviewRequestRemoved Action = "review_request_removed"
+)
+
+var validActions = map[Action]struct{}{
+	ActionOpened:               {},
+	ActionEdited:               {},
+	ActionSynchronize:          {},
+	ActionReadyForReview:       {},
+	ActionConvertedToDraft:     {},
+	ActionClosed:               {},
+	ActionReviewRequested:      {},
+	ActionReviewRequestRemoved: {},
+}
+
+func (a Action) Validate() error {
+	if _, ok := validActions[a]; !ok {
+		return fmt.Errorf("invalid Action: %q", a)
+	}
+	return nil
+}
+
+type Ref struct {
+	Ref string `json:"ref"`
+	SHA string `json:"sha"`
+}
+
+type Label struct {
+	Name string `json:"name"`
+}
+
+type PullRequest struct {
+	ID                 int     `json:"id"`
+	Number             int     `json:"number"`
+	Title              string  `json:"title"`
+	Body               string  `json:"body"`
+	User               User    `json:"user"`
+	Labels             []Label `json:"labels"`
+	Draft              bool    `json:"draft"`
+	Merged             bool    `json:"merged"`
+	RequestedReviewers []User  `json:"requested_reviewers"`
+	RequestedTeams     []Team  `json:"requested_teams"`
+	HTMLURL            string  `json:"html_url"`
+	DiffURL            string  `json:"diff_url"`
+	Head               Ref     `json:"head"`
+	Base               Ref     `json:"base"`
+}
+
+type User struct {
+	Login string `json:"login"`
+}
+
+type Team struct {
+	Name string `json:"name"`
+	Slug string `json:"slug"`
+}
+
+type Repository struct {
+	Name     string `json:"name"`
+	FullName string `json:"full_name"`
+	HTMLURL  string `json:"html_url"`
+	Owner    User   `json:"owner"`
+}
+
+type ChangeFrom struct {
+	From string `json:"from"`
+}
+
+// Changes previous values for "edited" actions
+type Changes struct {
+	Title *ChangeFrom `json:"title,omitempty"`
+	Body  *ChangeFrom `json:"body,omitempty"`
+}
+
+type CommitUser struct {
+	Name     string `json:"name"`
+	Email    string `json:"email"`
+	Username string `json:"username"`
+}
+
+type Commit struct {
+	ID      string     `json:"id"`
+	Message string     `json:"message"`
+	Author  CommitUser `json:"author"`
+}
+
+// Payload of pull_request and push deliveries, Ref, Before, After, Pusher and Commits are only set for pushes
+type Payload struct {
+	Action            Action      `json:"action"`
+	Number            int         `json:"number"`
+	PullRequest       PullRequest `json:"pull_request"`
+	Repository        Repository  `json:"repository"`
+	RequestedReviewer *User       `json:"requested_reviewer,omitempty"`
+	RequestedTeam     *Team       `json:"requested_team,omitempty"`
+	Changes           *Changes    `json:"changes,omitempty"`
+	Sender            User        `json:"sender"`
+
+	Ref     string     `json:"ref"`
+	Before  string     `json:"before"`
+	After   string     `json:"after"`
+	Pusher  CommitUser `json:"pusher"`
+	Commits []Commit   `json:"commits"`
+}
@@ -0,0 +1,77 @@
+package githubwebhook
+
+import (
+	"context"
+	"go-snob/pkg/githubwebhook/middleware"
+	"go-snob/pkg/http/pipeline"
+	"go-snob/pkg/workerpool"
+	"net/http"
+
+	"go.uber.org/zap"
+)
+
+const (
+	defaultPayloadChanCapacity = 1024
+
+	EventPullRequest = "pull_request"
+	EventPush        = "push"
+)
+
+type Webhook struct {
+	logger *zap.Logger
+
+	secret string
+
+	workers *workerpool.Pool[Delivery]
+}
+
+func (wh *Webhook) Run(ctx context.Context) error {
+	wh.logger.Info("start handling github webhook..")
+	wh.workers.Start(ctx)
+	wh.logger.Info("successfully starting background github webhook handling")
+	return nil
+}
+
+func (wh *Webhook) Stop(ctx context.Context) error {
+	wh.logger.Info("stop handling github webhook..")
+	wh.workers.Shutdown(ctx)
+	wh.logger.Info("github webhook handling stopped")
+	return nil
+}
+
+func NewWebhook(proc workerpool.Processor[Delivery], logger *zap.Logger) *Webhook {
+	return &Webhook{
+		logger:  logger,
+		workers: workerpool.NewPool[Delivery](proc, 5, defaultPayloadChanCapacity),
+	}
+}
+
+func (wh *Webhook) WithSecret(secret string) *Webhook {
+	wh.secret = secret
+	return wh
+}
+
+func (wh *Webhook) WithPayloadChanCapacity(capacity int) *Webhook {
+	wh.workers.SetChan(make(chan Delivery, capacity))
+	return wh
+}
+
+func (wh *Webhook) Handler() http.Handler {
+	p := pipeline.NewPipeline(
+		wh.logger,
+		pipeline.AllowedMethods(http.MethodPost),
+		pipeline.AllowedContentType("application/json"),
+	)
+
+	if wh.secret != "" {
+		p.WithMiddlewares(middleware.CheckSignature(wh.secret))
+	}
+
+	p.WithMiddlewares(
+		middleware.AllowedEvents(EventPullRequest, EventPush),
+		pipeline.Out(DecodeDelivery()),
+		pipeline.In(pipeline.Push[Delivery](wh.workers.WrChan())),
+	)
+
+	return p
+}
@@ -0,0 +1,77 @@
+package githubwebhook
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+const testBody = `{"action":"review_requested","number":7,"pull_request":{"number":7,"head":{"sha":"abc"}},"repository":{"name":"hello","owner":{"login":"octo"}}}`
+
+func sign(secret, body string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(body))
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestWebhookHandler(t *testing.T) {
+	tests := []struct {
+		name       string
+		event      string
+		signature  string
+		wantStatus int
+		wantPush   bool
+	}{
+		{name: "valid", event: EventPullRequest, signature: sign("s3cr3t", testBody), wantStatus: http.StatusOK, wantPush: true},
+		{name: "push", event: EventPush, signature: sign("s3cr3t", testBody), wantStatus: http.StatusOK, wantPush: true},
+		{name: "ping", event: "ping", signature: sign("s3cr3t", testBody), wantStatus: http.StatusNoContent},
+		{name: "bad signature", event: EventPullRequest, signature: sign("other", testBody), wantStatus: http.StatusUnauthorized},
+		{name: "no signature", event: EventPullRequest, wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			deliveries := make(chan Delivery, 1)
+			wh := NewWebhook(func(context.Context, Delivery) {}, zap.NewNop()).WithSecret("s3cr3t")
+			wh.workers.SetChan(deliveries)
+
+			req := httptest.NewRequest(http.MethodPost, "/webhook/github", strings.NewReader(testBody))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("X-GitHub-Event", tt.event)
+			req.Header.Set("X-GitHub-Delivery", "d-1")
+			if tt.signature != "" {
+				req.Header.Set("X-Hub-Signature-256", tt.signature)
+			}
+			rec := httptest.NewRecorder()
+
+			wh.Handler().ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			select {
+			case d := <-deliveries:
+				if !tt.wantPush {
+					t.Errorf("unexpected delivery pushed: %+v", d)
+				}
+				if d.ID != "d-1" || d.Event != tt.event || d.ReceivedAt.IsZero() || string(d.Body) != testBody {
+					t.Errorf("unexpected delivery: %+v", d)
+				}
+				if p := d.Payload; p.Action != ActionReviewRequested || p.PullRequest.Head.SHA != "abc" {
+					t.Errorf("unexpected payload: %+v", p)
+				}
+			default:
+				if tt.wantPush {
+					t.Error("delivery was not pushed")
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,41 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"go-snob/pkg/http/pipeline"
+	"net/http"
+)
+
+func CheckToken(secret string) pipeline.MiddlewareFunc {
+	return func(ctx *pipeline.Ctx, next pipeline.NextFunc) {
+		token := ctx.Request.Header.Get("X-Gitlab-Token")
+		if token == "" {
+			ctx.Fail(pipeline.NewError(http.StatusBadRequest, "token header missing", nil))
+			return
+		}
+
+		if subtle.ConstantTimeCompare([]byte(token), []byte(secret)) != 1 {
+			ctx.Fail(pipeline.NewError(http.StatusUnauthorized, "invalid token", nil))
+			return
+		}
+
+		next()
+	}
+}
+
+// AllowedEvents acknowledges deliveries of other event types without processing them
+func AllowedEvents(events ...string) pipeline.MiddlewareFunc {
+	allowed := make(map[string]struct{}, len(events))
+	for _, e := range events {
+		allowed[e] = struct{}{}
+	}
+
+	return func(ctx *pipeline.Ctx, next pipeline.NextFunc) {
+		if _, ok := allowed[ctx.Request.Header.Get("X-Gitlab-Event")]; ok {
+			next()
+			return
+		}
+
+		ctx.Writer.WriteHeader(http.StatusNoContent)
+	}
+}
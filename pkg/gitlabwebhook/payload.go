@@ -0,0 +1,98 @@
+package gitlabwebhook
+
+import "fmt"
+
+type Action string
+
+const (
+	ActionOpen       Action = "open"
+	ActionReopen     Action = "reopen"
+	ActionUpdate     Action = "update"
+	ActionClose      Action = "close"
+	ActionMerge      Action = "merge"
+	ActionApproved   Action = "approved"
+	ActionUnapproved Action = "unapproved"
+)
+
+var validActions = map[Action]struct{}{
+	ActionOpen:       {},
+	ActionReopen:     {},
+	ActionUpdate:     {},
+	ActionClose:      {},
+	ActionMerge:      {},
+	ActionApproved:   {},
+	ActionUnapproved: {},
+}
+
+func (a Action) Validate() error {
+	if _, ok := validActions[a]; !ok {
+		return fmt.Errorf("invalid Action: %q", a)
+	}
+	return nil
+}
+
+type User struct {
+	ID       int    `json:"id"`
+	Username string `json:"username"`
+}
+
+type Project struct {
+	ID                int    `json:"id"`
+	Name              string `json:"name"`
+	PathWithNamespace string `json:"path_with_namespace"`
+	WebURL            string `json:"web_url"`
+}
+
+type Commit struct {
+	ID string `json:"id"`
+}
+
+type Label struct {
+	Title string `json:"title"`
+}
+
+type MergeRequest struct {
+	ID           int     `json:"id"`
+	IID          int     `json:"iid"`
+	Action       Action  `json:"action"`
+	URL          string  `json:"url"`
+	Title        string  `json:"title"`
+	Description  string  `json:"description"`
+	Labels       []Label `json:"labels"`
+	Draft        bool    `json:"draft"`
+	SourceBranch string  `json:"source_branch"`
+	TargetBranch string  `json:"target_branch"`
+	// OldRev is set on update events that pushed new commits
+	OldRev     string `json:"oldrev,omitempty"`
+	LastCommit Commit `json:"last_commit"`
+}
+
+type UsersChange struct {
+	Previous []User `json:"previous"`
+	Current  []User `json:"current"`
+}
+
+type StringChange struct {
+	Previous string `json:"previous"`
+	Current  string `json:"current"`
+}
+
+type BoolChange struct {
+	Previous bool `json:"previous"`
+	Current  bool `json:"current"`
+}
+
+type Changes struct {
+	Reviewers *UsersChange  `json:"reviewers,omitempty"`
+	Title     *StringChange `json:"title,omitempty"`
+	Draft     *BoolChange   `json:"draft,omitempty"`
+}
+
+type Payload struct {
+	ObjectKind       string       `json:"object_kind"`
+	User             User         `json:"user"`
+	Project          Project      `json:"project"`
+	ObjectAttributes MergeRequest `json:"object_attributes"`
+	Reviewers        []User       `json:"reviewers"`
+	Changes          Changes      `json:"changes"`
+}
@@ -0,0 +1,68 @@
+package gitlabwebhook
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+const testBody = `{"object_kind":"merge_request","project":{"path_with_namespace":"group/hello"},` +
+	`"object_attributes":{"iid":7,"action":"update","oldrev":"abc","last_commit":{"id":"def"}}}`
+
+func TestWebhookHandler(t *testing.T) {
+	tests := []struct {
+		name       string
+		event      string
+		token      string
+		wantStatus int
+		wantPush   bool
+	}{
+		{name: "valid", event: EventMergeRequest, token: "s3cr3t", wantStatus: http.StatusOK, wantPush: true},
+		{name: "other event", event: "Push Hook", token: "s3cr3t", wantStatus: http.StatusNoContent},
+		{name: "wrong token", event: EventMergeRequest, token: "other", wantStatus: http.StatusUnauthorized},
+		{name: "no token", event: EventMergeRequest, wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			deliveries := make(chan Delivery, 1)
+			wh := NewWebhook(func(context.Context, Delivery) {}, zap.NewNop()).WithSecret("s3cr3t")
+			wh.workers.SetChan(deliveries)
+
+			req := httptest.NewRequest(http.MethodPost, "/webhook/gitlab", strings.NewReader(testBody))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("X-Gitlab-Event", tt.event)
+			req.Header.Set("X-Gitlab-Event-UUID", "d-1")
+			if tt.token != "" {
+				req.Header.Set("X-Gitlab-Token", tt.token)
+			}
+			rec := httptest.NewRecorder()
+
+			wh.Handler().ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			select {
+			case d := <-deliveries:
+				if !tt.wantPush {
+					t.Errorf("unexpected delivery pushed: %+v", d)
+				}
+				if d.ID != "d-1" || d.Event != EventMergeRequest || d.ReceivedAt.IsZero() || string(d.Body) != testBody {
+					t.Errorf("unexpected delivery: %+v", d)
+				}
+				if a := d.Payload.ObjectAttributes; a.IID != 7 || a.OldRev != "abc" {
+					t.Errorf("unexpected payload: %+v", d.Payload)
+				}
+			default:
+				if tt.wantPush {
+					t.Error("delivery was not pushed")
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,235 @@
+package hotreload
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"go-snob/pkg/promreg"
+	"os"
+	"os/signal"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+)
+
+const (
+	defaultPollInterval = 5 * time.Second
+	versionLength       = 12
+)
+
+// Parser decodes and validates raw file content. A failed parse keeps the previous value active.
+type Parser[T any] func(b []byte) (T, error)
+
+// Snapshot immutable loaded value. Jobs should take one snapshot when they start and use it till the end.
+type Snapshot[T any] struct {
+	Value T
+	// Version short content hash of the file the value was loaded from
+	Version  string
+	LoadedAt time.Time
+}
+
+type metrics struct {
+	info    *prometheus.GaugeVec
+	reloads *prometheus.CounterVec
+}
+
+// Store keeps the last valid value of a file and reloads it on change or SIGHUP
+type Store[T any] struct {
+	logger *zap.Logger
+	path   string
+	parse  Parser[T]
+
+	pollInterval time.Duration
+	onReload     []func(prev, cur *Snapshot[T])
+
+	cur     atomic.Pointer[Snapshot[T]]
+	mu      sync.Mutex
+	modTime time.Time
+	metrics *metrics
+
+	stop     chan struct{}
+	stopOnce sync.Once
+	done     chan struct{}
+}
+
+// NewStore loads the file once, so a broken file fails the startup instead of the first reload
+func NewStore[T any](path string, parse Parser[T], logger *zap.Logger) (*Store[T], error) {
+	s := &Store[T]{
+		logger:       logger,
+		path:         path,
+		parse:        parse,
+		pollInterval: defaultPollInterval,
+		stop:         make(chan struct{}),
+		done:         make(chan struct{}),
+	}
+	if _, err := s.load(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *Store[T]) WithPollInterval(d time.Duration) *Store[T] {
+	s.pollInterval = d
+	return s
+}
+
+// WithMetrics exposes the active version as <ns>_<name>_info{version} gauge and counts reloads
+func (s *Store[T]) WithMetrics(ns string, name string) *Store[T] {
+	s.metrics = &metrics{
+		info: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace: ns,
+				Name:      name + "_info",
+				Help:      "Active version of the file, always 1",
+			},
+			[]string{"version"},
+		),
+		reloads: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: ns,
+				Name:      name + "_reloads_total",
+				Help:      "Reload attempts by result",
+			},
+			[]string{"result"},
+		),
+	}
+	s.metrics.info = promreg.Register(s.metrics.info)
+	s.metrics.reloads = promreg.Register(s.metrics.reloads)
+	s.metrics.info.With(prometheus.Labels{"version": s.Current().Version}).Set(1)
+	return s
+}
+
+// OnReload registers f to be called after a new version is swapped in
+func (s *Store[T]) OnReload(f func(prev, cur *Snapshot[T])) *Store[T] {
+	s.onReload = append(s.onReload, f)
+	return s
+}
+
+func (s *Store[T]) Current() *Snapshot[T] {
+	return s.cur.Load()
+}
+
+// Reload reads the file and swaps the value in if it is valid and differs from the active one
+func (s *Store[T]) Reload() error {
+	changed, err := s.load()
+	if s.metrics != nil {
+		result := "unchanged"
+		switch {
+		case err != nil:
+			result = "failure"
+		case changed:
+			result = "success"
+		}
+		s.metrics.reloads.With(prometheus.Labels{"result": result}).Inc()
+	}
+	return err
+}
+
+func (s *Store[T]) load() (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	info, err := os.Stat(s.path)
+	if err != nil {
+		return false, fmt.Errorf("stat %q: %w", s.path, err)
+	}
+	b, err := os.ReadFile(s.path)
+	if err != nil {
+		return false, fmt.Errorf("read %q: %w", s.path, err)
+	}
+	s.modTime = info.ModTime()
+
+	sum := sha256.Sum256(b)
+	version := hex.EncodeToString(sum[:])[:versionLength]
+	prev := s.cur.Load()
+	if prev != nil && prev.Version == version {
+		return false, nil
+	}
+
+	v, err := s.parse(b)
+	if err != nil {
+		return false, fmt.Errorf("parse %q: %w", s.path, err)
+	}
+
+	cur := &Snapshot[T]{Value: v, Version: version, LoadedAt: time.Now()}
+	s.cur.Store(cur)
+
+	if prev != nil {
+		if s.metrics != nil {
+			s.metrics.info.Delete(prometheus.Labels{"version": prev.Version})
+			s.metrics.info.With(prometheus.Labels{"version": version}).Set(1)
+		}
+		for _, f := range s.onReload {
+			f(prev, cur)
+		}
+	}
+	return true, nil
+}
+
+func (s *Store[T]) modified() bool {
+	info, err := os.Stat(s.path)
+	if err != nil {
+		return false
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return !info.ModTime().Equal(s.modTime)
+}
+
+func (s *Store[T]) reload(reason string) {
+	prev := s.Current().Version
+	if err := s.Reload(); err != nil {
+		s.logger.Error("config reload failed, keeping previous version",
+			zap.String("reason", reason), zap.String("config_version", prev), zap.Error(err))
+		return
+	}
+	if cur := s.Current().Version; cur != prev {
+		s.logger.Info("config reloaded",
+			zap.String("reason", reason), zap.String("previous_version", prev), zap.String("config_version", cur))
+	}
+}
+
+// Run watches the file by polling its modification time, fsnotify is not worth a dependency here,
+// and reloads it on SIGHUP
+func (s *Store[T]) Run(ctx context.Context) error {
+	defer close(s.done)
+
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	ticker := time.NewTicker(s.pollInterval)
+	defer ticker.Stop()
+
+	s.logger.Info("watching config", zap.String("path", s.path), zap.String("config_version", s.Current().Version))
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-s.stop:
+			return nil
+		case <-hup:
+			s.reload("sighup")
+		case <-ticker.C:
+			if s.modified() {
+				s.reload("file changed")
+			}
+		}
+	}
+}
+
+// Stop may be called more than once, e.g. by the app and a deferred cleanup
+func (s *Store[T]) Stop(ctx context.Context) error {
+	s.stopOnce.Do(func() { close(s.stop) })
+	select {
+	case <-s.done:
+	case <-ctx.Done():
+	}
+	return nil
+}
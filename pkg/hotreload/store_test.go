@@ -0,0 +1,172 @@
+package hotreload
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+type testConfig struct {
+	Name string
+}
+
+// parseTestConfig accepts "name=<name>"
+func parseTestConfig(b []byte) (testConfig, error) {
+	name, ok := strings.CutPrefix(strings.TrimSpace(string(b)), "name=")
+	if !ok {
+		return testConfig{}, errors.New("no name")
+	}
+	return testConfig{Name: name}, nil
+}
+
+func newTestStore(t *testing.T, content string) (*Store[testConfig], string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config")
+	writeFile(t, path, content)
+	s, err := NewStore(path, parseTestConfig, zap.NewNop())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s, path
+}
+
+// writeFile moves the modification time forward, so polling sees the change on coarse clocks too
+func writeFile(t *testing.T, path string, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	mod := time.Now().Add(time.Duration(len(content)) * time.Second)
+	if err := os.Chtimes(path, mod, mod); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func reloads(s *Store[testConfig]) <-chan *Snapshot[testConfig] {
+	ch := make(chan *Snapshot[testConfig], 1)
+	s.OnReload(func(_, cur *Snapshot[testConfig]) {
+		select {
+		case ch <- cur:
+		default:
+		}
+	})
+	return ch
+}
+
+func TestStoreReload(t *testing.T) {
+	s, path := newTestStore(t, "name=a")
+	before := s.Current()
+	if before.Value.Name != "a" || before.Version == "" {
+		t.Fatalf("unexpected first snapshot: %+v", before)
+	}
+
+	writeFile(t, path, "name=b")
+	if err := s.Reload(); err != nil {
+		t.Fatalf("Reload: %v", err)
+	}
+	cur := s.Current()
+	if cur.Value.Name != "b" || cur.Version == before.Version {
+		t.Errorf("changed file wasn't swapped in: %+v", cur)
+	}
+	// jobs started before the reload keep working with their snapshot
+	if before.Value.Name != "a" {
+		t.Errorf("snapshot taken before the reload changed: %+v", before)
+	}
+
+	if err := s.Reload(); err != nil {
+		t.Fatalf("Reload of the same file: %v", err)
+	}
+	if s.Current() != cur {
+		t.Error("unchanged file replaced the snapshot")
+	}
+}
+
+func TestStoreKeepsValidSnapshot(t *testing.T) {
+	s, path := newTestStore(t, "name=a")
+	before := s.Current()
+
+	writeFile(t, path, "broken")
+	if err := s.Reload(); err == nil {
+		t.Fatal("invalid file was accepted")
+	}
+	if s.Current() != before {
+		t.Errorf("invalid file replaced the snapshot: %+v", s.Current())
+	}
+
+	if _, err := NewStore(path, parseTestConfig, zap.NewNop()); err == nil {
+		t.Error("invalid file didn't fail the first load")
+	}
+}
+
+func TestStoreRunReloads(t *testing.T) {
+	tests := []struct {
+		name    string
+		poll    time.Duration
+		trigger func(t *testing.T)
+	}{
+		{name: "file changed", poll: 10 * time.Millisecond},
+		{
+			name: "sighup",
+			poll: time.Hour,
+			trigger: func(t *testing.T) {
+				if err := syscall.Kill(os.Getpid(), syscall.SIGHUP); err != nil {
+					t.Fatal(err)
+				}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// a SIGHUP sent before Run subscribes mustn't kill the test binary
+			hup := make(chan os.Signal, 1)
+			signal.Notify(hup, syscall.SIGHUP)
+			defer signal.Stop(hup)
+
+			s, path := newTestStore(t, "name=a")
+			s.WithPollInterval(tt.poll)
+			reloaded := reloads(s)
+			go func() { _ = s.Run(context.Background()) }()
+			defer func() { _ = s.Stop(context.Background()) }()
+
+			writeFile(t, path, "name=bb")
+			timeout := time.After(5 * time.Second)
+			// the signal is repeated, Run may not be subscribed to it yet
+			tick := time.NewTicker(20 * time.Millisecond)
+			defer tick.Stop()
+			for {
+				select {
+				case cur := <-reloaded:
+					if cur.Value.Name != "bb" {
+						t.Errorf("unexpected snapshot: %+v", cur)
+					}
+					return
+				case <-tick.C:
+					if tt.trigger != nil {
+						tt.trigger(t)
+					}
+				case <-timeout:
+					t.Fatal("file wasn't reloaded")
+				}
+			}
+		})
+	}
+}
+
+func TestStoreStopTwice(t *testing.T) {
+	s, _ := newTestStore(t, "name=a")
+
+	go func() { _ = s.Run(context.Background()) }()
+	for range 2 {
+		if err := s.Stop(context.Background()); err != nil {
+			t.Fatalf("Stop: %v", err)
+		}
+	}
+}
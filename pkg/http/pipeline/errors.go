@@ -0,0 +1,99 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+const problemContentType = "application/problem+json"
+
+// Error an error with the response it's rendered as. Message is shown to the client, Cause is only logged
+type Error struct {
+	Status  int
+	Message string
+	Cause   error
+}
+
+func NewError(status int, message string, cause error) *Error {
+	return &Error{Status: status, Message: message, Cause: cause}
+}
+
+func (e *Error) Error() string {
+	if e.Cause == nil {
+		return fmt.Sprintf("%d %s", e.Status, e.Message)
+	}
+	return fmt.Sprintf("%d %s: %v", e.Status, e.Message, e.Cause)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Cause
+}
+
+// AsError the Error in err's chain, errors without one are internal and their text isn't shown
+func AsError(err error) *Error {
+	var e *Error
+	if errors.As(err, &e) {
+		return e
+	}
+	return NewError(http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError), err)
+}
+
+// ErrorRenderer writes the response of a failed request
+type ErrorRenderer func(w http.ResponseWriter, r *http.Request, err *Error)
+
+// ErrorHook is called with every failed request before its error is rendered
+type ErrorHook func(ctx *Ctx, err *Error)
+
+// RenderError writes an RFC 9457 problem when the client accepts application/problem+json and
+// {"error": message} otherwise
+func RenderError(w http.ResponseWriter, r *http.Request, err *Error) {
+	h := w.Header()
+	h.Set("X-Content-Type-Options", "nosniff")
+	if strings.Contains(r.Header.Get("Accept"), problemContentType) {
+		h.Set("Content-Type", problemContentType)
+		w.WriteHeader(err.Status)
+		_ = json.NewEncoder(w).Encode(struct {
+			Type   string `json:"type"`
+			Title  string `json:"title"`
+			Status int    `json:"status"`
+			Detail string `json:"detail,omitempty"`
+		}{
+			Type:   "about:blank",
+			Title:  http.StatusText(err.Status),
+			Status: err.Status,
+			Detail: err.Message,
+		})
+		return
+	}
+
+	h.Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(err.Status)
+	_ = json.NewEncoder(w).Encode(struct {
+		Error string `json:"error"`
+	}{Error: err.Message})
+}
+
+// LogErrors logs server errors with their cause at error level, rejected requests at info level
+func LogErrors(logger *zap.Logger) ErrorHook {
+	return func(ctx *Ctx, err *Error) {
+		fields := []zap.Field{
+			zap.String("method", ctx.Request.Method),
+			zap.String("path", ctx.Request.URL.Path),
+			zap.Int("status", err.Status),
+			zap.String("message", err.Message),
+		}
+		if err.Cause != nil {
+			fields = append(fields, zap.Error(err.Cause))
+		}
+		if err.Status >= http.StatusInternalServerError {
+			logger.Error("request failed", fields...)
+			return
+		}
+		logger.Info("request rejected", fields...)
+	}
+}
@@ -1,19 +1,24 @@
 package pipeline
 
 import (
+	"bytes"
+	"crypto/subtle"
 	"encoding/json"
-	"errors"
+	"fmt"
 	"net/http"
+	"strings"
 )
 
 func EncodeJSON[T any]() HandlerIn[T] {
 	return func(ctx *Ctx, t T) error {
-		ctx.Writer.Header().Add("Content-Type", "application/json; charset=utf-8")
-
-		err := json.NewEncoder(ctx.Writer).Encode(t)
-		if err != nil {
-			http.Error(ctx.Writer, "bad response body decode", http.StatusInternalServerError)
+		// encoded up front, a failure is still rendered as an error response
+		var buf bytes.Buffer
+		if err := json.NewEncoder(&buf).Encode(t); err != nil {
+			return fmt.Errorf("encode response body: %w", err)
 		}
+
+		ctx.Writer.Header().Add("Content-Type", "application/json; charset=utf-8")
+		_, _ = ctx.Writer.Write(buf.Bytes())
 		return nil
 	}
 }
@@ -30,7 +35,21 @@ func AllowedMethods(methods ...string) MiddlewareFunc {
 			return
 		}
 
-		http.Error(ctx.Writer, "method not allowed", http.StatusMethodNotAllowed)
+		ctx.Writer.Header().Set("Allow", strings.Join(methods, ", "))
+		ctx.Fail(NewError(http.StatusMethodNotAllowed, "method not allowed", nil))
+	}
+}
+
+// BearerToken rejects requests without "Authorization: Bearer <token>"
+func BearerToken(token string) MiddlewareFunc {
+	return func(ctx *Ctx, next NextFunc) {
+		got, ok := strings.CutPrefix(ctx.Request.Header.Get("Authorization"), "Bearer ")
+		if ok && subtle.ConstantTimeCompare([]byte(got), []byte(token)) == 1 {
+			next()
+			return
+		}
+
+		ctx.Fail(NewError(http.StatusUnauthorized, "unauthorized", nil))
 	}
 }
 
@@ -45,17 +64,27 @@ func AllowedContentType(contentType ...string) MiddlewareFunc {
 			return
 		}
 
-		http.Error(ctx.Writer, "unsupported media type", http.StatusUnsupportedMediaType)
+		ctx.Fail(NewError(http.StatusUnsupportedMediaType, "unsupported media type", nil))
 	}
 }
 
 func DecodeJSON[T any]() HandlerOut[T] {
 	return func(ctx *Ctx) (t T, err error) {
 		if err := json.NewDecoder(ctx.Request.Body).Decode(&t); err != nil {
-			http.Error(ctx.Writer, "bad request body decode", http.StatusBadRequest)
-
-			return t, errors.New("bad request")
+			return t, NewError(http.StatusBadRequest, "bad request body decode", err)
 		}
 		return t, nil
 	}
 }
+
+func Push[P any](ch chan<- P) HandlerIn[P] {
+	return func(ctx *Ctx, in P) error {
+		select {
+		case <-ctx.Request.Context().Done():
+			return NewError(http.StatusRequestTimeout, "request cancelled", ctx.Request.Context().Err())
+		case ch <- in:
+		}
+
+		return nil
+	}
+}
@@ -2,33 +2,55 @@ package pipeline
 
 import (
 	"context"
+	"fmt"
 	"net/http"
 	"reflect"
+
+	"go.uber.org/zap"
 )
 
 type Ctx struct {
 	Writer  http.ResponseWriter
 	Request *http.Request
 	RCtx    context.Context
+
+	w   *responseWriter
+	err *Error
 }
 
 func NewCtx(w http.ResponseWriter, r *http.Request) *Ctx {
+	rw := &responseWriter{ResponseWriter: w}
 	return &Ctx{
-		Writer:  w,
+		Writer:  rw,
 		Request: r,
 		RCtx:    r.Context(),
+		w:       rw,
 	}
 }
 
+// Fail stops the request with err, the pipeline renders it once the chain returns. Middlewares return
+// without calling next after it
+func (ctx *Ctx) Fail(err error) {
+	ctx.err = AsError(err)
+}
+
+// Err the error the request failed with, nil if it didn't
+func (ctx *Ctx) Err() *Error {
+	return ctx.err
+}
+
 type NextFunc func()
 type MiddlewareFunc func(ctx *Ctx, next NextFunc)
 
 type Pipeline struct {
 	middlewares []MiddlewareFunc
+	render      ErrorRenderer
+	onError     ErrorHook
 }
 
-func NewPipeline(mw ...MiddlewareFunc) *Pipeline {
-	return &Pipeline{middlewares: mw}
+// NewPipeline failed requests are logged with logger, WithErrorHook replaces it
+func NewPipeline(logger *zap.Logger, mw ...MiddlewareFunc) *Pipeline {
+	return &Pipeline{middlewares: mw, render: RenderError, onError: LogErrors(logger)}
 }
 
 func (p *Pipeline) ServeHTTP(w http.ResponseWriter, r *http.Request) {
@@ -37,7 +59,7 @@ func (p *Pipeline) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 
 	var next NextFunc
 	next = func() {
-		if execIndex >= len(p.middlewares) {
+		if execIndex >= len(p.middlewares) || ctx.err != nil {
 			return
 		}
 
@@ -47,6 +69,17 @@ func (p *Pipeline) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	}
 
 	next()
+
+	if ctx.err == nil {
+		return
+	}
+	if p.onError != nil {
+		p.onError(ctx, ctx.err)
+	}
+	// a response that was already started can't be replaced
+	if !ctx.w.written {
+		p.render(ctx.w, r, ctx.err)
+	}
 }
 
 func (p *Pipeline) WithMiddlewares(mw ...MiddlewareFunc) *Pipeline {
@@ -54,26 +87,32 @@ func (p *Pipeline) WithMiddlewares(mw ...MiddlewareFunc) *Pipeline {
 	return p
 }
 
+// WithErrorRenderer replaces RenderError
+func (p *Pipeline) WithErrorRenderer(render ErrorRenderer) *Pipeline {
+	p.render = render
+	return p
+}
+
+// WithErrorHook is called with the error of every failed request instead of LogErrors
+func (p *Pipeline) WithErrorHook(hook ErrorHook) *Pipeline {
+	p.onError = hook
+	return p
+}
+
 type HandlerInOut[In any, Out any] func(ctx *Ctx, in In) (Out, error)
 type HandlerIn[In any] func(ctx *Ctx, in In) error
 type HandlerOut[Out any] func(ctx *Ctx) (Out, error)
 
 func In[I any](h HandlerIn[I]) MiddlewareFunc {
-	return func(ctx *Ctx, next NextFunc) {
-		if err := ctx.RCtx.Err(); err != nil {
-			ctx.Writer.WriteHeader(http.StatusGatewayTimeout)
-			return
-		}
+	inType := reflect.TypeOf((*I)(nil)).Elem()
 
-		raw := ctx.RCtx.Value(reflect.TypeOf((*I)(nil)).Elem())
-		if raw == nil {
-			ctx.Writer.WriteHeader(http.StatusBadRequest)
+	return func(ctx *Ctx, next NextFunc) {
+		in, ok := input[I](ctx, inType)
+		if !ok {
 			return
 		}
-
-		in := raw.(I)
 		if err := h(ctx, in); err != nil {
-			ctx.Writer.WriteHeader(http.StatusBadRequest)
+			ctx.Fail(err)
 			return
 		}
 		next()
@@ -84,14 +123,13 @@ func Out[O any](h HandlerOut[O]) MiddlewareFunc {
 	outType := reflect.TypeOf((*O)(nil)).Elem()
 
 	return func(ctx *Ctx, next NextFunc) {
-		if err := ctx.RCtx.Err(); err != nil {
-			ctx.Writer.WriteHeader(http.StatusGatewayTimeout)
+		if !alive(ctx) {
 			return
 		}
 
 		out, err := h(ctx)
 		if err != nil {
-			ctx.Writer.WriteHeader(http.StatusBadRequest)
+			ctx.Fail(err)
 			return
 		}
 
@@ -99,26 +137,19 @@ func Out[O any](h HandlerOut[O]) MiddlewareFunc {
 		next()
 	}
 }
+
 func InOut[I any, O any](h HandlerInOut[I, O]) MiddlewareFunc {
 	inType := reflect.TypeOf((*I)(nil)).Elem()
 	outType := reflect.TypeOf((*O)(nil)).Elem()
 
 	return func(ctx *Ctx, next NextFunc) {
-		if err := ctx.RCtx.Err(); err != nil {
-			ctx.Writer.WriteHeader(http.StatusGatewayTimeout)
-			return
-		}
-
-		raw := ctx.RCtx.Value(inType)
-		if raw == nil {
-			ctx.Writer.WriteHeader(http.StatusBadRequest)
+		in, ok := input[I](ctx, inType)
+		if !ok {
 			return
 		}
-
-		in := raw.(I)
 		out, err := h(ctx, in)
 		if err != nil {
-			ctx.Writer.WriteHeader(http.StatusBadRequest)
+			ctx.Fail(err)
 			return
 		}
 
@@ -126,3 +157,46 @@ func InOut[I any, O any](h HandlerInOut[I, O]) MiddlewareFunc {
 		next()
 	}
 }
+
+// input the value an earlier Out or InOut stored for the handler
+func input[I any](ctx *Ctx, inType reflect.Type) (I, bool) {
+	var in I
+	if !alive(ctx) {
+		return in, false
+	}
+	raw := ctx.RCtx.Value(inType)
+	if raw == nil {
+		ctx.Fail(fmt.Errorf("no pipeline value of type %s", inType))
+		return in, false
+	}
+	return raw.(I), true
+}
+
+func alive(ctx *Ctx) bool {
+	if err := ctx.RCtx.Err(); err != nil {
+		ctx.Fail(NewError(http.StatusGatewayTimeout, "request cancelled", err))
+		return false
+	}
+	return true
+}
+
+// responseWriter remembers whether the response was started
+type responseWriter struct {
+	http.ResponseWriter
+	written bool
+}
+
+func (w *responseWriter) WriteHeader(code int) {
+	w.written = true
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *responseWriter) Write(b []byte) (int, error) {
+	w.written = true
+	return w.ResponseWriter.Write(b)
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer
+func (w *responseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,152 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func fail(err error) MiddlewareFunc {
+	return func(ctx *Ctx, _ NextFunc) {
+		ctx.Fail(err)
+	}
+}
+
+func TestPipelineRendersError(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		accept     string
+		wantStatus int
+		wantType   string
+		wantBody   map[string]any
+		notInBody  string
+	}{
+		{
+			name:       "json",
+			err:        NewError(http.StatusBadRequest, "bad payload", errors.New("line 3")),
+			wantStatus: http.StatusBadRequest,
+			wantType:   "application/json; charset=utf-8",
+			wantBody:   map[string]any{"error": "bad payload"},
+			notInBody:  "line 3",
+		},
+		{
+			name:       "problem",
+			err:        NewError(http.StatusUnauthorized, "invalid signature", nil),
+			accept:     "application/problem+json, application/json",
+			wantStatus: http.StatusUnauthorized,
+			wantType:   problemContentType,
+			wantBody: map[string]any{
+				"type": "about:blank", "title": "Unauthorized", "status": float64(401), "detail": "invalid signature",
+			},
+		},
+		{
+			name:       "plain error is internal",
+			err:        errors.New("dial tcp 10.0.0.1:5432: connection refused"),
+			wantStatus: http.StatusInternalServerError,
+			wantType:   "application/json; charset=utf-8",
+			wantBody:   map[string]any{"error": "Internal Server Error"},
+			notInBody:  "10.0.0.1",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/", nil)
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+			rec := httptest.NewRecorder()
+
+			NewPipeline(zap.NewNop(), fail(tt.err)).ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if got := rec.Header().Get("Content-Type"); got != tt.wantType {
+				t.Errorf("content type = %q, want %q", got, tt.wantType)
+			}
+			raw := rec.Body.String()
+			if tt.notInBody != "" && strings.Contains(raw, tt.notInBody) {
+				t.Errorf("cause leaked into the body: %s", raw)
+			}
+			var body map[string]any
+			if err := json.Unmarshal([]byte(raw), &body); err != nil {
+				t.Fatalf("decode body %q: %v", raw, err)
+			}
+			if len(body) != len(tt.wantBody) {
+				t.Errorf("body = %v, want %v", body, tt.wantBody)
+			}
+			for k, v := range tt.wantBody {
+				if body[k] != v {
+					t.Errorf("body[%s] = %v, want %v", k, body[k], v)
+				}
+			}
+		})
+	}
+}
+
+func TestPipelineFailStopsChain(t *testing.T) {
+	var called bool
+	rec := httptest.NewRecorder()
+
+	NewPipeline(
+		zap.NewNop(),
+		fail(NewError(http.StatusForbidden, "forbidden", nil)),
+		func(ctx *Ctx, next NextFunc) {
+			called = true
+			next()
+		},
+	).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if called {
+		t.Error("middleware after Fail was called")
+	}
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusForbidden)
+	}
+}
+
+func TestPipelineKeepsStartedResponse(t *testing.T) {
+	var hooked int
+	rec := httptest.NewRecorder()
+
+	NewPipeline(zap.NewNop(), func(ctx *Ctx, next NextFunc) {
+		ctx.Writer.WriteHeader(http.StatusAccepted)
+		_, _ = io.WriteString(ctx.Writer, "partial")
+		ctx.Fail(errors.New("broken pipe"))
+	}).WithErrorHook(func(*Ctx, *Error) {
+		hooked++
+	}).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if rec.Code != http.StatusAccepted || rec.Body.String() != "partial" {
+		t.Errorf("started response was replaced: %d %q", rec.Code, rec.Body.String())
+	}
+	if hooked != 1 {
+		t.Errorf("hook called %d times, want 1", hooked)
+	}
+}
+
+func TestPipelineLogsErrorsByDefault(t *testing.T) {
+	core, logs := observer.New(zapcore.InfoLevel)
+
+	NewPipeline(zap.New(core), fail(errors.New("disk full"))).
+		ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/hook", nil))
+
+	entries := logs.FilterMessage("request failed").All()
+	if len(entries) != 1 {
+		t.Fatalf("logged %d failures, want 1: %v", len(entries), logs.All())
+	}
+	fields := entries[0].ContextMap()
+	if fields["path"] != "/hook" || fields["status"] != int64(http.StatusInternalServerError) ||
+		fields["error"] != "disk full" {
+		t.Errorf("unexpected fields: %v", fields)
+	}
+}
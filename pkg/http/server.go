@@ -7,6 +7,7 @@ import (
 	"go-snob/pkg/http/pipeline"
 	"net/http"
 
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"go.uber.org/zap"
 )
 
@@ -33,10 +34,15 @@ func NewServer(logger *zap.Logger, addr string) *Server {
 
 func (s *Server) WithPingHandler() *Server {
 	return s.WithHandler("/ping", pipeline.NewPipeline(
+		s.logger,
 		pipeline.AllowedMethods(http.MethodGet),
 	))
 }
 
+func (s *Server) WithMetricsHandler() *Server {
+	return s.WithHandler("/metrics", promhttp.Handler())
+}
+
 func (s *Server) WithHandler(path string, handler http.Handler) *Server {
 	s.mux.Handle(path, handler)
 	return s
@@ -0,0 +1,23 @@
+package promreg
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestRegisterReusesCollector(t *testing.T) {
+	opts := prometheus.CounterOpts{Namespace: "promreg_test", Name: "calls_total", Help: "Calls"}
+	first := Register(prometheus.NewCounterVec(opts, []string{"result"}))
+	second := Register(prometheus.NewCounterVec(opts, []string{"result"}))
+	if first != second {
+		t.Error("second registration didn't return the registered collector")
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("conflicting registration didn't panic")
+		}
+	}()
+	Register(prometheus.NewCounterVec(opts, []string{"other"}))
+}
@@ -0,0 +1,184 @@
+package restyclient
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"resty.dev/v3"
+)
+
+type AuthType string
+
+const (
+	AuthToken  AuthType = "token"
+	AuthBasic  AuthType = "basic"
+	AuthOAuth2 AuthType = "oauth2"
+)
+
+// tokenExpiryLeeway refresh oauth2 tokens a bit earlier than they actually expire
+const tokenExpiryLeeway = 30 * time.Second
+
+// Auth describes how requests are authenticated. String values support ${ENV} expansion so secrets
+// don't have to be kept in the config file.
+type Auth struct {
+	Type AuthType `yaml:"type"`
+
+	// Scheme authorization scheme for token auth, "token" by default
+	Scheme string `yaml:"scheme"`
+	Token  string `yaml:"token"`
+
+	Username string `yaml:"username"`
+	Password string `yaml:"password"`
+
+	// OAuth2 application. Tokens are requested with the client credentials grant, or with the refresh
+	// token grant if RefreshToken is set: servers like Gitea only issue tokens to users
+	ClientID     string   `yaml:"client_id"`
+	ClientSecret string   `yaml:"client_secret"`
+	TokenURL     string   `yaml:"token_url"`
+	Scopes       []string `yaml:"scopes"`
+	RefreshToken string   `yaml:"refresh_token"`
+}
+
+// expand returns a with ${ENV} tokens replaced, an unset variable leaves the value empty
+func (a Auth) expand() Auth {
+	for _, v := range []*string{
+		&a.Scheme, &a.Token, &a.Username, &a.Password, &a.ClientID, &a.ClientSecret, &a.TokenURL, &a.RefreshToken,
+	} {
+		*v = os.ExpandEnv(*v)
+	}
+	return a
+}
+
+// Validate checks a with ${ENV} tokens expanded, so an unset variable is reported
+func (a Auth) Validate() error {
+	a = a.expand()
+	switch a.Type {
+	case "":
+	case AuthToken:
+		if a.Token == "" {
+			return fmt.Errorf("token auth: token is required")
+		}
+	case AuthBasic:
+		if a.Username == "" {
+			return fmt.Errorf("basic auth: username is required")
+		}
+	case AuthOAuth2:
+		if a.ClientID == "" || a.ClientSecret == "" || a.TokenURL == "" {
+			return fmt.Errorf("oauth2 auth: client_id, client_secret and token_url are required")
+		}
+	default:
+		return fmt.Errorf("unknown auth type: %q", a.Type)
+	}
+	return nil
+}
+
+// Apply sets up authentication of every request made by c
+func (a Auth) Apply(c *resty.Client) error {
+	if err := a.Validate(); err != nil {
+		return err
+	}
+
+	a = a.expand()
+	switch a.Type {
+	case AuthToken:
+		scheme := a.Scheme
+		if scheme == "" {
+			scheme = "token"
+		}
+		c.SetAuthScheme(scheme).SetAuthToken(a.Token)
+	case AuthBasic:
+		c.SetBasicAuth(a.Username, a.Password)
+	case AuthOAuth2:
+		src := &tokenSource{
+			client:       c.Clone(context.Background()),
+			clientID:     a.ClientID,
+			clientSecret: a.ClientSecret,
+			tokenURL:     a.TokenURL,
+			scopes:       a.Scopes,
+			refreshToken: a.RefreshToken,
+		}
+		c.AddRequestMiddleware(func(_ *resty.Client, r *resty.Request) error {
+			token, err := src.Token()
+			if err != nil {
+				return fmt.Errorf("oauth2: %w", err)
+			}
+			r.SetAuthScheme("Bearer").SetAuthToken(token)
+			return nil
+		})
+	}
+
+	return nil
+}
+
+// tokenSource fetches and caches tokens via the client credentials or the refresh token grant
+type tokenSource struct {
+	client       *resty.Client
+	clientID     string
+	clientSecret string
+	tokenURL     string
+	scopes       []string
+	// refreshToken the server may rotate it with every token, the latest one is kept
+	refreshToken string
+
+	mu      sync.Mutex
+	token   string
+	expires time.Time
+}
+
+type tokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	ExpiresIn    int64  `json:"expires_in"`
+	RefreshToken string `json:"refresh_token"`
+}
+
+func (s *tokenSource) Token() (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.token != "" && (s.expires.IsZero() || time.Now().Before(s.expires)) {
+		return s.token, nil
+	}
+
+	form := map[string]string{"grant_type": "client_credentials"}
+	if s.refreshToken != "" {
+		form = map[string]string{"grant_type": "refresh_token", "refresh_token": s.refreshToken}
+	}
+	if len(s.scopes) > 0 {
+		form["scope"] = strings.Join(s.scopes, " ")
+	}
+	r, err := s.client.R().
+		SetBasicAuth(s.clientID, s.clientSecret).
+		SetFormData(form).
+		SetHeader("Accept", "application/json").
+		Post(s.tokenURL)
+	if err != nil {
+		return "", fmt.Errorf("request token: %w", err)
+	}
+	if r.StatusCode() != http.StatusOK {
+		return "", fmt.Errorf("request token: unexpected status code: %v", r.StatusCode())
+	}
+
+	var resp tokenResponse
+	if err := json.Unmarshal(r.Bytes(), &resp); err != nil {
+		return "", fmt.Errorf("unmarshal token: %w", err)
+	}
+	if resp.AccessToken == "" {
+		return "", fmt.Errorf("empty access token")
+	}
+
+	s.token = resp.AccessToken
+	if resp.RefreshToken != "" && s.refreshToken != "" {
+		s.refreshToken = resp.RefreshToken
+	}
+	s.expires = time.Time{}
+	if resp.ExpiresIn > 0 {
+		s.expires = time.Now().Add(time.Duration(resp.ExpiresIn)*time.Second - tokenExpiryLeeway)
+	}
+	return s.token, nil
+}
@@ -0,0 +1,73 @@
+package restyclient
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"resty.dev/v3"
+)
+
+func TestAuthValidateExpandsEnv(t *testing.T) {
+	t.Setenv("SNOB_TEST_TOKEN", "")
+	a := Auth{Type: AuthToken, Token: "${SNOB_TEST_TOKEN}"}
+	if err := a.Validate(); err == nil {
+		t.Error("token from an unset variable must be rejected")
+	}
+	t.Setenv("SNOB_TEST_TOKEN", "secret")
+	if err := a.Validate(); err != nil {
+		t.Errorf("Validate: %v", err)
+	}
+}
+
+func TestAuthOAuth2RefreshToken(t *testing.T) {
+	var refreshTokens []string
+	tokens := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if id, secret, ok := r.BasicAuth(); !ok || id != "app" || secret != "s3cret" {
+			t.Errorf("unexpected client credentials: %s %s", id, secret)
+		}
+		if r.FormValue("grant_type") != "refresh_token" {
+			t.Errorf("unexpected grant: %s", r.FormValue("grant_type"))
+		}
+		refreshTokens = append(refreshTokens, r.FormValue("refresh_token"))
+		// expired right away, every request refreshes
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"access_token":  "access-" + r.FormValue("refresh_token"),
+			"refresh_token": "rotated",
+			"expires_in":    1,
+		})
+	}))
+	defer tokens.Close()
+
+	var auths []string
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auths = append(auths, r.Header.Get("Authorization"))
+	}))
+	defer api.Close()
+
+	t.Setenv("SNOB_TEST_REFRESH", "initial")
+	c := resty.New()
+	err := Auth{
+		Type:         AuthOAuth2,
+		ClientID:     "app",
+		ClientSecret: "s3cret",
+		TokenURL:     tokens.URL,
+		RefreshToken: "${SNOB_TEST_REFRESH}",
+	}.Apply(c)
+	if err != nil {
+		t.Fatalf("Apply: %v", err)
+	}
+	for range 2 {
+		if _, err := c.R().Get(api.URL); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if len(refreshTokens) != 2 || refreshTokens[0] != "initial" || refreshTokens[1] != "rotated" {
+		t.Errorf("rotated refresh token must be used: %v", refreshTokens)
+	}
+	if len(auths) != 2 || auths[0] != "Bearer access-initial" || auths[1] != "Bearer access-rotated" {
+		t.Errorf("unexpected authorization: %v", auths)
+	}
+}
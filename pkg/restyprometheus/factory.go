@@ -2,6 +2,7 @@ package restyprometheus
 
 import (
 	"fmt"
+	"go-snob/pkg/promreg"
 	"net/url"
 
 	"github.com/prometheus/client_golang/prometheus"
@@ -51,12 +52,10 @@ func NewClient(c *resty.Client, ns string, serviceNamePrefix string) *resty.Clie
 		),
 	}
 
-	prometheus.MustRegister(
-		m.responseTimePerURL,
-		m.responseStatusCounter,
-		m.successCounterByURL,
-		m.failureCounterByURL,
-	)
+	m.responseTimePerURL = promreg.Register(m.responseTimePerURL)
+	m.responseStatusCounter = promreg.Register(m.responseStatusCounter)
+	m.successCounterByURL = promreg.Register(m.successCounterByURL)
+	m.failureCounterByURL = promreg.Register(m.failureCounterByURL)
 	c.AddResponseMiddleware(func(c *resty.Client, r *resty.Response) error {
 		m.collect(r)
 		return nil